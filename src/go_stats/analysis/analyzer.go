@@ -0,0 +1,296 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type (
+	// CharFilter transforma o texto bruto antes da tokenização (ex.: minúsculas, remoção de URLs).
+	CharFilter interface {
+		Filter(text string) string
+	}
+
+	// Tokenizer divide o texto filtrado em tokens.
+	Tokenizer interface {
+		Tokenize(text string) []string
+	}
+
+	// TokenFilter transforma ou remove tokens (ex.: stopwords, stemming).
+	TokenFilter interface {
+		Filter(tokens []string) []string
+	}
+
+	// CharFilterFunc adapta uma função a CharFilter.
+	CharFilterFunc func(string) string
+
+	// TokenFilterFunc adapta uma função a TokenFilter.
+	TokenFilterFunc func([]string) []string
+)
+
+func (f CharFilterFunc) Filter(text string) string {
+	return f(text)
+}
+
+func (f TokenFilterFunc) Filter(tokens []string) []string {
+	return f(tokens)
+}
+
+// Config descreve um Analyzer por nomes de componentes registrados, de forma que
+// possa ser serializada junto ao índice e reconstruída com New.
+type Config struct {
+	// Extractors rodam sobre o texto bruto; os trechos reconhecidos viram tokens especiais.
+	Extractors   []string `json:"extractors,omitempty"`
+	CharFilters  []string `json:"charFilters"`
+	Tokenizer    string   `json:"tokenizer"`
+	TokenFilters []string `json:"tokenFilters"`
+	// AccentsFile é o JSON de substituições usado pelo char filter "fold_accents".
+	AccentsFile string `json:"accentsFile,omitempty"`
+	// LemmaFile é o dicionário usado pelo token filter "lemmatize".
+	LemmaFile string `json:"lemmaFile,omitempty"`
+	// StopwordFiles e Stopwords alimentam o token filter "stopwords_custom".
+	StopwordFiles []string `json:"stopwordFiles,omitempty"`
+	Stopwords     []string `json:"stopwords,omitempty"`
+	// StopwordsHash é o SHA-256 do conteúdo de StopwordFiles, preenchido por Signature para que
+	// a edição de um arquivo de stopwords também mude a assinatura do índice.
+	StopwordsHash string `json:"stopwordsHash,omitempty"`
+}
+
+// Version identifica o comportamento dos componentes registrados neste pacote. Deve ser
+// incrementada sempre que uma mudança em um filtro, tokenizer ou extrator alterar os tokens
+// produzidos para a mesma Config, para que snapshots do corpus (ver corpus.Manifest) deixem
+// de ser considerados equivalentes.
+const Version = "1"
+
+// DefaultAccentsFile é o caminho padrão (relativo à raiz do repositório) do mapa de acentos.
+const DefaultAccentsFile = "misc/replaces.json"
+
+// DefaultConfig reproduz a sequência fixa do antigo utils.CleanText: minúsculas, remoção de
+// URLs, números romanos, números e datas, troca de hífens por espaço, remoção de pontuação,
+// remoção de acentos e de stopwords em português. Citações legais (ver ExtractCitations)
+// são preservadas como tokens canônicos.
+func DefaultConfig() Config {
+	return Config{
+		Extractors: []string{"legal_citations"},
+		CharFilters: []string{
+			"lowercase",
+			"remove_urls",
+			"remove_roman",
+			"remove_numbers",
+			"remove_dates",
+			"dashes_to_space",
+			"strip_punct",
+			"fold_accents",
+		},
+		Tokenizer:    "whitespace",
+		TokenFilters: []string{"stopwords_pt"},
+		AccentsFile:  DefaultAccentsFile,
+	}
+}
+
+// Signature retorna a forma canônica (JSON) da configuração, usada para gravar e comparar
+// a configuração de um índice. Inclui o hash do conteúdo atual de StopwordFiles (ver
+// StopwordsHash); um arquivo ilegível entra no hash pelo erro de leitura.
+func (c Config) Signature() string {
+	c.StopwordsHash = ""
+	if len(c.StopwordFiles) > 0 {
+		h := sha256.New()
+		for _, path := range c.StopwordFiles {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				data = []byte(err.Error())
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+			h.Write(data)
+		}
+		c.StopwordsHash = hex.EncodeToString(h.Sum(nil))
+	}
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+// ParseConfig reconstrói uma Config a partir de Signature.
+func ParseConfig(signature string) (Config, error) {
+	var ret Config
+	if err := json.Unmarshal([]byte(signature), &ret); err != nil {
+		return ret, fmt.Errorf("invalid analyzer config: %v", err)
+	}
+	return ret, nil
+}
+
+// Analyzer aplica char filters, tokenizer e token filters, nessa ordem. O mesmo Analyzer
+// deve ser usado para documentos e consultas de um índice; é seguro para uso concorrente
+// desde que os componentes também sejam.
+type Analyzer struct {
+	extractors   []Extractor
+	charFilters  []CharFilter
+	tokenizer    Tokenizer
+	tokenFilters []TokenFilter
+	config       Config
+}
+
+// NewAnalyzer monta um Analyzer a partir de componentes já construídos. O Analyzer
+// resultante não tem Config serializável (Config() retorna o valor zero).
+func NewAnalyzer(tokenizer Tokenizer, charFilters []CharFilter, tokenFilters []TokenFilter) *Analyzer {
+	return &Analyzer{
+		charFilters:  charFilters,
+		tokenizer:    tokenizer,
+		tokenFilters: tokenFilters,
+	}
+}
+
+// New constrói um Analyzer a partir dos componentes registrados nomeados em cfg.
+func New(cfg Config) (*Analyzer, error) {
+	ret := &Analyzer{config: cfg}
+
+	for _, name := range cfg.Extractors {
+		factory, ok := extractors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown extractor %q (available: %s)", name, keys(extractors))
+		}
+		e, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("extractor %q: %v", name, err)
+		}
+		ret.extractors = append(ret.extractors, e)
+	}
+
+	for _, name := range cfg.CharFilters {
+		factory, ok := charFilters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown char filter %q (available: %s)", name, keys(charFilters))
+		}
+		f, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("char filter %q: %v", name, err)
+		}
+		ret.charFilters = append(ret.charFilters, f)
+	}
+
+	name := cfg.Tokenizer
+	if name == "" {
+		name = "whitespace"
+	}
+	factory, ok := tokenizers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q (available: %s)", name, keys(tokenizers))
+	}
+	tk, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer %q: %v", name, err)
+	}
+	ret.tokenizer = tk
+
+	for _, name := range cfg.TokenFilters {
+		factory, ok := tokenFilters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown token filter %q (available: %s)", name, keys(tokenFilters))
+		}
+		f, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("token filter %q: %v", name, err)
+		}
+		ret.tokenFilters = append(ret.tokenFilters, f)
+	}
+
+	return ret, nil
+}
+
+// Config retorna a configuração usada para construir o Analyzer.
+func (a *Analyzer) Config() Config {
+	return a.config
+}
+
+// Analyze executa o pipeline completo e retorna os tokens resultantes. Os trechos
+// reconhecidos pelos extratores entram no lugar em que aparecem no texto, sem passar
+// pelos filtros; o texto entre eles é analisado normalmente.
+func (a *Analyzer) Analyze(text string) []string {
+	spans := a.Spans(text)
+	if len(spans) == 0 {
+		return a.analyze(text)
+	}
+
+	var ret []string
+	last := 0
+	for _, s := range spans {
+		ret = append(ret, a.analyze(text[last:s.Start])...)
+		ret = append(ret, s.Token)
+		last = s.End
+	}
+	return append(ret, a.analyze(text[last:])...)
+}
+
+// Spans retorna os trechos reconhecidos pelos extratores, ordenados e sem sobreposição.
+func (a *Analyzer) Spans(text string) []Span {
+	var ret []Span
+	for _, e := range a.extractors {
+		ret = append(ret, e.Extract(text)...)
+	}
+	if len(a.extractors) > 1 {
+		sort.SliceStable(ret, func(i, j int) bool {
+			return ret[i].Start < ret[j].Start
+		})
+		kept := ret[:0]
+		for _, s := range ret {
+			if len(kept) == 0 || s.Start >= kept[len(kept)-1].End {
+				kept = append(kept, s)
+			}
+		}
+		ret = kept
+	}
+	return ret
+}
+
+func (a *Analyzer) analyze(text string) []string {
+	for _, f := range a.charFilters {
+		text = f.Filter(text)
+	}
+	tokens := a.tokenizer.Tokenize(text)
+	for _, f := range a.tokenFilters {
+		tokens = f.Filter(tokens)
+	}
+	return tokens
+}
+
+// AnalyzeString executa o pipeline e junta os tokens com espaço, no formato dos
+// arquivos de misc/corpus/clean.
+func (a *Analyzer) AnalyzeString(text string) string {
+	return strings.Join(a.Analyze(text), " ")
+}
+
+// ---- Registro de componentes ----
+
+var (
+	charFilters  = map[string]func(Config) (CharFilter, error){}
+	tokenizers   = map[string]func(Config) (Tokenizer, error){}
+	tokenFilters = map[string]func(Config) (TokenFilter, error){}
+)
+
+// RegisterCharFilter registra um char filter para uso em Config.CharFilters.
+func RegisterCharFilter(name string, factory func(Config) (CharFilter, error)) {
+	charFilters[name] = factory
+}
+
+// RegisterTokenizer registra um tokenizer para uso em Config.Tokenizer.
+func RegisterTokenizer(name string, factory func(Config) (Tokenizer, error)) {
+	tokenizers[name] = factory
+}
+
+// RegisterTokenFilter registra um token filter para uso em Config.TokenFilters.
+func RegisterTokenFilter(name string, factory func(Config) (TokenFilter, error)) {
+	tokenFilters[name] = factory
+}
+
+func keys[V any](m map[string]V) string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDefaultAnalyzer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccentsFile = "../../../misc/replaces.json"
+
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := a.Analyze("A Comissão aprovou, em 12/05/2019, o PROJETO de lei https://camara.leg.br/x sobre educação-básica.")
+	want := []string{"comissao", "aprovou", "projeto", "lei", "educacao", "basica"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Documentos já limpos não mudam ao passar de novo pelo pipeline.
+	if again := a.Analyze(a.AnalyzeString("A Comissão aprovou o projeto")); !reflect.DeepEqual(again, want[:3]) {
+		t.Errorf("analysis is not idempotent: %v", again)
+	}
+
+	parsed, err := ParseConfig(cfg.Signature())
+	if err != nil || parsed.Signature() != cfg.Signature() {
+		t.Errorf("config round trip failed: %v %v", parsed, err)
+	}
+
+	if _, err = New(Config{CharFilters: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown char filter")
+	}
+}
+
+func TestStemmers(t *testing.T) {
+	for word, want := range map[string]string{
+		"licitação":     "licit",
+		"licitações":    "licit",
+		"licitar":       "licit",
+		"proposições":   "propos",
+		"deputados":     "deput",
+		"administração": "administr",
+	} {
+		if got := StemRSLP(word); got != want {
+			t.Errorf("StemRSLP(%q) = %q, want %q", word, got, want)
+		}
+	}
+
+	lemmatizer := NewLemmatizer(map[string]string{"licitações": "licitação", "dispõe": "dispor"})
+	got := lemmatizer.Filter([]string{"licitacoes", "dispoe", "sobre"})
+	if want := []string{"licitacao", "dispor", "sobre"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lemmatizer: got %v, want %v", got, want)
+	}
+
+	cfg, err := DefaultConfig().WithStemmer("lemma+rslp")
+	if err != nil || cfg.Stemmer() != "lemma+rslp" || DefaultConfig().Stemmer() != "none" {
+		t.Errorf("WithStemmer: %v %v", cfg.TokenFilters, err)
+	}
+	if _, err = LoadLemmas("../../../misc/lemmas_pt.tsv"); err != nil {
+		t.Errorf("default lemma file: %v", err)
+	}
+}
+
+func TestExtractCitations(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccentsFile = "../../../misc/replaces.json"
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := a.Analyze("Altera o art. 5º, § 2º, inciso IV, da Lei nº 8.666/93 e a PEC nº 45/2019.")
+	want := []string{"altera", "art:5", "par:2", "inc:iv", "lei:8666/1993", "pec:45/2019"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Tokens canônicos sobrevivem a uma nova análise.
+	if again := a.Analyze(a.AnalyzeString("Lei 8.666, de 21 de junho de 1993")); !reflect.DeepEqual(again, []string{"lei:8666/1993"}) {
+		t.Errorf("citation lost on re-analysis: %v", again)
+	}
+	if kind, ok := IsCitation("mpv:870/2019"); !ok || kind != "mpv" {
+		t.Errorf("IsCitation: %q %v", kind, ok)
+	}
+}
+
+func TestStopwords(t *testing.T) {
+	df := NewDocFreq()
+	df.Add([]string{"camara", "deputados", "saude", "lei:8666/1993"})
+	df.Add([]string{"camara", "deputados", "educacao", "lei:8666/1993"})
+	df.Add([]string{"camara", "transporte"})
+	if got, want := df.Stopwords(0.6), []string{"camara", "deputados"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("derived stopwords: got %v, want %v", got, want)
+	}
+
+	cfg := DefaultConfig().WithStopwords(nil, []string{"Câmara"})
+	cfg.AccentsFile = "../../../misc/replaces.json"
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Analyze("A Câmara aprovou"); !reflect.DeepEqual(got, []string{"aprovou"}) {
+		t.Errorf("custom stopwords: got %v", got)
+	}
+	if _, err = LoadStopwords("../../../misc/stopwords_legislativo.txt"); err != nil {
+		t.Errorf("legislative stopword file: %v", err)
+	}
+
+	// Editar um arquivo de stopwords muda a assinatura, mesmo com o mesmo caminho.
+	path := filepath.Join(t.TempDir(), "stopwords.txt")
+	if err = os.WriteFile(path, []byte("camara\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg = DefaultConfig().WithStopwords([]string{path}, nil)
+	before := cfg.Signature()
+	if parsed, err := ParseConfig(before); err != nil || parsed.Signature() != before {
+		t.Errorf("config round trip failed: %v %v", parsed, err)
+	}
+	if err = os.WriteFile(path, []byte("camara\nsenado\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Signature() == before {
+		t.Error("signature ignores stopword file contents")
+	}
+
+	// O digest cobre o conjunto efetivo: a lista fixa e as palavras normalizadas.
+	digest := func(c Config) string {
+		t.Helper()
+		d, err := c.StopwordsDigest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	base := digest(DefaultConfig())
+	if digest(DefaultConfig()) != base {
+		t.Error("stopwords digest is not stable")
+	}
+	noBuiltin := DefaultConfig()
+	noBuiltin.TokenFilters = slices.DeleteFunc(slices.Clone(noBuiltin.TokenFilters), func(f string) bool { return f == "stopwords_pt" })
+	if digest(noBuiltin) == base {
+		t.Error("stopwords digest ignores the built-in list")
+	}
+	custom := digest(DefaultConfig().WithStopwords(nil, []string{"Câmara"}))
+	if custom == base || digest(DefaultConfig().WithStopwords(nil, []string{"camara"})) != custom {
+		t.Error("stopwords digest should change with custom words and use their folded form")
+	}
+	if digest(cfg) == digest(DefaultConfig().WithStopwords(nil, []string{"camara"})) {
+		t.Error("stopwords digest ignores stopword file contents")
+	}
+}
+
+func TestAnalyzeSpans(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccentsFile = "../../../misc/replaces.json"
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := "Altera a Lei nº 8.666/1993 sobre licitações\n  e educação-básica."
+	var got []string
+	for _, s := range a.AnalyzeSpans(text) {
+		got = append(got, s.Token+"="+text[s.Start:s.End])
+	}
+	want := []string{"altera=Altera", "lei:8666/1993=Lei nº 8.666/1993", "licitacoes=licitações", "educacao=educação-básica.", "basica=educação-básica."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAlignSpans(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccentsFile = "../../../misc/replaces.json"
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A URL consome a quebra de linha e a palavra seguinte só quando o texto é analisado
+	// inteiro, então AnalyzeSpans tem um token ("proposta") a mais que Analyze.
+	text := "Veja https://camara.leg.br/x\nproposta sobre licitações"
+	tokens := a.Analyze(text)
+	spans := a.AnalyzeSpans(text)
+	if len(spans) == len(tokens) {
+		t.Fatalf("expected AnalyzeSpans to diverge from Analyze: %v", tokens)
+	}
+
+	var got []string
+	for _, s := range AlignSpans(tokens, spans) {
+		got = append(got, s.Token+"="+text[s.Start:s.End])
+	}
+	want := []string{"veja=Veja", "licitacoes=licitações"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Tokens sem span recebem um trecho vazio no fim do anterior, sem tomar o do seguinte
+	got = got[:0]
+	for _, s := range AlignSpans([]string{"x", "veja", "y", "sobre"}, []Span{{0, 4, "veja"}, {6, 11, "sobre"}}) {
+		got = append(got, fmt.Sprintf("%s=%d:%d", s.Token, s.Start, s.End))
+	}
+	if want = []string{"x=0:0", "veja=0:4", "y=4:4", "sobre=6:11"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
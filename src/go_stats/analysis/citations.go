@@ -0,0 +1,192 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Span é um trecho do texto bruto reconhecido por um Extractor, substituído no fluxo
+// de tokens por Token (sem passar pelos filtros do Analyzer).
+type Span struct {
+	Start int
+	End   int
+	Token string
+}
+
+// Extractor reconhece trechos do texto bruto que devem virar tokens especiais antes
+// que os char filters removam números e pontuação.
+type Extractor interface {
+	Extract(text string) []Span
+}
+
+// ExtractorFunc adapta uma função a Extractor.
+type ExtractorFunc func(string) []Span
+
+func (f ExtractorFunc) Extract(text string) []Span {
+	return f(text)
+}
+
+var extractors = map[string]func(Config) (Extractor, error){}
+
+// RegisterExtractor registra um extrator para uso em Config.Extractors.
+func RegisterExtractor(name string, factory func(Config) (Extractor, error)) {
+	extractors[name] = factory
+}
+
+// Tipos de citação legal, usados como prefixo dos tokens canônicos ("lei:8666/1993").
+const (
+	CitationLaw        = "lei"
+	CitationComplement = "lc"
+	CitationDecree     = "decreto"
+	CitationDecreeLaw  = "dl"
+	CitationArticle    = "art"
+	CitationParagraph  = "par"
+	CitationInciso     = "inc"
+)
+
+// Siglas de proposições reconhecidas; "MP" é normalizada para "mpv".
+var ProposalKinds = []string{"pec", "plp", "plv", "pdl", "pl", "mpv"}
+
+// Regexes dos extratores de citações, compiladas uma única vez. A de proposições segue a
+// ideia de docNameRgx (sigla + número/ano), mas aceita números e anos de qualquer tamanho.
+var (
+	numberPart = `(\d{1,3}(?:\.\d{3})+|\d+)`
+	ordinal    = `n\.?\s?[º°o]?\.?\s*`
+
+	lawRgx = regexp.MustCompile(`(?i)\b(lei complementar|lei|decreto-lei|decreto)\s+(?:` + ordinal + `)?` + numberPart +
+		`(?:\s*/\s*(\d{4}|\d{2})\b|,?\s+de\s+\d{1,2}º?\s+de\s+[a-zç]+\s+de\s+(\d{4}))?`)
+	articleRgx   = regexp.MustCompile(`(?i)\bart(?:igo)?s?\.?\s*(\d+)\s*(?:º|°|o\b)?(?:\s*-\s*([a-z])\b)?`)
+	paragraphRgx = regexp.MustCompile(`(?i)(?:§+\s*(\d+)\s*[º°o]?|par[áa]grafo\s+([úu]nico|\d+))`)
+	incisoRgx    = regexp.MustCompile(`(?i)\binciso\s+([ivxlcdm]+)\b`)
+	proposalRgx  = regexp.MustCompile(`(?i)\b(pec|plp|plv|pdl|pl|mpv|mp)\s*(?:` + ordinal + `)?` + numberPart + `\s*/\s*(\d{4}|\d{2})\b`)
+
+	// canonicalRgx reconhece os tokens já canônicos, para que reanalisar um texto limpo os preserve.
+	canonicalRgx = regexp.MustCompile(`\b((?:lei|lc|decreto|dl|art|par|inc|pec|plp|plv|pdl|pl|mpv):[0-9a-z/]+)`)
+	citationRgx  = regexp.MustCompile(`^(lei|lc|decreto|dl|art|par|inc|pec|plp|plv|pdl|pl|mpv):[0-9a-z/]+$`)
+)
+
+func init() {
+	RegisterExtractor("legal_citations", static[Extractor](ExtractorFunc(ExtractCitations)))
+}
+
+// IsCitation informa se token é uma citação canônica e retorna o seu tipo.
+func IsCitation(token string) (string, bool) {
+	m := citationRgx.FindStringSubmatch(token)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// ExtractCitations reconhece referências legislativas no texto bruto e as converte em
+// tokens canônicos:
+//   - "Lei nº 8.666/93", "Lei 8.666, de 21 de junho de 1993" -> lei:8666/1993
+//   - "Lei Complementar 101/2000" -> lc:101/2000; "Decreto-Lei 200/67" -> dl:200/1967
+//   - "art. 5º", "artigo 37-A" -> art:5, art:37a
+//   - "§ 2º", "parágrafo único" -> par:2, par:unico
+//   - "inciso IV" -> inc:iv
+//   - "PL 1234/2019", "PEC nº 45/2019", "MP 870/19" -> pl:1234/2019, pec:45/2019, mpv:870/2019
+//
+// Trechos sobrepostos são resolvidos a favor do que começa antes (e, empatando, do mais longo).
+func ExtractCitations(text string) []Span {
+	var spans []Span
+
+	collect := func(rgx *regexp.Regexp, canon func(m []string) string) {
+		for _, idx := range rgx.FindAllStringSubmatchIndex(text, -1) {
+			m := make([]string, len(idx)/2)
+			for i := range m {
+				if idx[2*i] >= 0 {
+					m[i] = text[idx[2*i]:idx[2*i+1]]
+				}
+			}
+			spans = append(spans, Span{Start: idx[0], End: idx[1], Token: canon(m)})
+		}
+	}
+
+	collect(canonicalRgx, func(m []string) string {
+		return m[1]
+	})
+	collect(lawRgx, func(m []string) string {
+		kind := CitationLaw
+		switch strings.ToLower(m[1]) {
+		case "lei complementar":
+			kind = CitationComplement
+		case "decreto-lei":
+			kind = CitationDecreeLaw
+		case "decreto":
+			kind = CitationDecree
+		}
+		year := m[3]
+		if year == "" {
+			year = m[4]
+		}
+		return citationToken(kind, m[2], year)
+	})
+	collect(proposalRgx, func(m []string) string {
+		kind := strings.ToLower(m[1])
+		if kind == "mp" {
+			kind = "mpv"
+		}
+		return citationToken(kind, m[2], m[3])
+	})
+	collect(articleRgx, func(m []string) string {
+		return fmt.Sprintf("%s:%s%s", CitationArticle, trimNumber(m[1]), strings.ToLower(m[2]))
+	})
+	collect(paragraphRgx, func(m []string) string {
+		if m[1] != "" {
+			return fmt.Sprintf("%s:%s", CitationParagraph, trimNumber(m[1]))
+		}
+		return fmt.Sprintf("%s:%s", CitationParagraph, Fold(strings.ToLower(m[2])))
+	})
+	collect(incisoRgx, func(m []string) string {
+		return fmt.Sprintf("%s:%s", CitationInciso, strings.ToLower(m[1]))
+	})
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].End > spans[j].End
+	})
+
+	var ret []Span
+	for _, s := range spans {
+		if len(ret) > 0 && s.Start < ret[len(ret)-1].End {
+			continue
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+// citationToken monta "tipo:número/ano", sem pontos no número e com o ano em 4 dígitos.
+func citationToken(kind, number, year string) string {
+	ret := kind + ":" + trimNumber(number)
+	if year != "" {
+		ret += "/" + fullYear(year)
+	}
+	return ret
+}
+
+func trimNumber(s string) string {
+	s = strings.ReplaceAll(s, ".", "")
+	if n, err := strconv.Atoi(s); err == nil {
+		return strconv.Itoa(n)
+	}
+	return s
+}
+
+// fullYear expande anos de 2 dígitos: até 50 -> 20xx, acima -> 19xx.
+func fullYear(s string) string {
+	if len(s) != 2 {
+		return s
+	}
+	n, _ := strconv.Atoi(s)
+	if n <= 50 {
+		return fmt.Sprintf("20%02d", n)
+	}
+	return fmt.Sprintf("19%02d", n)
+}
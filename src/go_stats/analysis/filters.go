@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/bbalet/stopwords"
+)
+
+// Regexes do pipeline padrão, compiladas uma única vez.
+var (
+	romanRgx   = regexp.MustCompile(`^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+	numberRgx  = regexp.MustCompile(`((\d{1,3}(\.\d{3})*)|\d+)(,\d+)?\b`)
+	decimalRgx = regexp.MustCompile(`((\d{1,3}(,\d{3})*)|\d+)(\.\d+)?\b`)
+	dateRgx    = regexp.MustCompile(`(\d{1,2}[-/]\d{1,2}[-/]\d{2,4}|\d{4}[-/]\d{1,2}[-/]\d{1,2})\b`)
+	urlRgx     = regexp.MustCompile(`https?://([^\s]|\n)+`)
+)
+
+var dashReplacer = strings.NewReplacer("–", " ", "-", " ", "_", " ")
+
+func init() {
+	RegisterCharFilter("lowercase", static[CharFilter](CharFilterFunc(strings.ToLower)))
+	RegisterCharFilter("remove_urls", static(regexRemover(urlRgx)))
+	RegisterCharFilter("remove_roman", static(regexRemover(romanRgx)))
+	RegisterCharFilter("remove_numbers", static[CharFilter](CharFilterFunc(func(s string) string {
+		return decimalRgx.ReplaceAllString(numberRgx.ReplaceAllString(s, ""), "")
+	})))
+	RegisterCharFilter("remove_dates", static(regexRemover(dateRgx)))
+	RegisterCharFilter("dashes_to_space", static[CharFilter](CharFilterFunc(dashReplacer.Replace)))
+	RegisterCharFilter("strip_punct", static[CharFilter](CharFilterFunc(stripPunct)))
+	RegisterCharFilter("fold_accents", func(cfg Config) (CharFilter, error) {
+		path := cfg.AccentsFile
+		if path == "" {
+			path = DefaultAccentsFile
+		}
+		return NewAccentFolder(path)
+	})
+
+	RegisterTokenizer("whitespace", static[Tokenizer](WhitespaceTokenizer{}))
+
+	RegisterTokenFilter("stopwords_pt", static[TokenFilter](TokenFilterFunc(func(tokens []string) []string {
+		return strings.Fields(stopwords.CleanString(strings.Join(tokens, " "), "pt", false))
+	})))
+	RegisterTokenFilter("stopwords_custom", func(cfg Config) (TokenFilter, error) {
+		words := append([]string(nil), cfg.Stopwords...)
+		for _, path := range cfg.StopwordFiles {
+			loaded, err := LoadStopwords(path)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, loaded...)
+		}
+		return NewStopwordFilter(words), nil
+	})
+	RegisterTokenFilter("stem_rslp", static[TokenFilter](RSLPStemmer{}))
+	RegisterTokenFilter("lemmatize", func(cfg Config) (TokenFilter, error) {
+		path := cfg.LemmaFile
+		if path == "" {
+			path = DefaultLemmaFile
+		}
+		lemmas, err := LoadLemmas(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewLemmatizer(lemmas), nil
+	})
+}
+
+// static adapta um componente sem parâmetros a uma fábrica do registro.
+func static[T any](v T) func(Config) (T, error) {
+	return func(Config) (T, error) {
+		return v, nil
+	}
+}
+
+func regexRemover(rgx *regexp.Regexp) CharFilter {
+	return CharFilterFunc(func(s string) string {
+		return rgx.ReplaceAllString(s, "")
+	})
+}
+
+// stripPunct remove pontuações e símbolos unicode.
+func stripPunct(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// WhitespaceTokenizer separa tokens por espaços em branco.
+type WhitespaceTokenizer struct{}
+
+func (WhitespaceTokenizer) Tokenize(text string) []string {
+	return strings.Fields(text)
+}
+
+// LoadAccents lê o mapa de substituições (caractere-base -> variantes acentuadas) e o
+// devolve como pares para strings.NewReplacer.
+func LoadAccents(path string) ([]string, error) {
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string][]string
+	err = json.Unmarshal(out, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for key, all := range m {
+		for _, token := range all {
+			ret = append(ret, token, key)
+		}
+	}
+
+	return ret, nil
+}
+
+// NewAccentFolder carrega o mapa de acentos uma única vez e retorna um char filter que
+// substitui os caracteres acentuados pelos seus equivalentes sem acento.
+func NewAccentFolder(path string) (CharFilter, error) {
+	pairs, err := LoadAccents(path)
+	if err != nil {
+		return nil, err
+	}
+	return CharFilterFunc(strings.NewReplacer(pairs...).Replace), nil
+}
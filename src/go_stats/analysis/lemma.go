@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultLemmaFile é o caminho padrão (relativo à raiz do repositório) do dicionário de lemas.
+const DefaultLemmaFile = "misc/lemmas_pt.tsv"
+
+// Lemmatizer substitui cada token pelo lema encontrado no dicionário. Tokens ausentes
+// do dicionário são mantidos.
+type Lemmatizer struct {
+	lemmas map[string]string
+}
+
+// NewLemmatizer cria um Lemmatizer a partir de um mapa forma -> lema. Formas e lemas são
+// convertidos para minúsculas e sem acento, como os tokens do pipeline padrão.
+func NewLemmatizer(lemmas map[string]string) *Lemmatizer {
+	ret := &Lemmatizer{lemmas: make(map[string]string, len(lemmas))}
+	for form, lemma := range lemmas {
+		ret.lemmas[Fold(strings.ToLower(form))] = Fold(strings.ToLower(lemma))
+	}
+	return ret
+}
+
+// LoadLemmas lê um dicionário no formato "forma<TAB>lema" (ou separado por espaços), uma
+// entrada por linha. Linhas vazias e iniciadas por '#' são ignoradas.
+func LoadLemmas(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ret := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"form<TAB>lemma\", got %q", path, line, text)
+		}
+		ret[fields[0]] = fields[1]
+	}
+	return ret, scanner.Err()
+}
+
+func (l *Lemmatizer) Filter(tokens []string) []string {
+	for i, t := range tokens {
+		if lemma, ok := l.lemmas[t]; ok {
+			tokens[i] = lemma
+		}
+	}
+	return tokens
+}
+
+// Stemmers mapeia os nomes aceitos por Config.WithStemmer para os token filters adicionados
+// ao fim do pipeline.
+var Stemmers = map[string][]string{
+	"none":       nil,
+	"rslp":       {"stem_rslp"},
+	"lemma":      {"lemmatize"},
+	"lemma+rslp": {"lemmatize", "stem_rslp"},
+}
+
+// WithStemmer retorna uma cópia da configuração com os filtros de normalização morfológica
+// de name (ver Stemmers) adicionados ao fim.
+func (c Config) WithStemmer(name string) (Config, error) {
+	filters, ok := Stemmers[name]
+	if !ok {
+		return c, fmt.Errorf("unknown stemmer %q (available: %s)", name, keys(Stemmers))
+	}
+	c.TokenFilters = append(append([]string(nil), c.TokenFilters...), filters...)
+	return c, nil
+}
+
+// Stemmer retorna o nome (ver Stemmers) da normalização morfológica da configuração, ou
+// "none" se nenhuma for usada.
+func (c Config) Stemmer() string {
+	var found []string
+	for _, f := range c.TokenFilters {
+		if f == "lemmatize" {
+			found = append(found, "lemma")
+		} else if f == "stem_rslp" {
+			found = append(found, "rslp")
+		}
+	}
+	if len(found) == 0 {
+		return "none"
+	}
+	return strings.Join(found, "+")
+}
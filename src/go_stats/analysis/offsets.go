@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// AnalyzeSpans executa o pipeline palavra a palavra e retorna cada token com o trecho (em
+// bytes) do texto original que o gerou, permitindo voltar dos tokens limpos ao texto bruto.
+// Uma palavra pode gerar vários tokens (ex.: "ex-servidor"), que compartilham o mesmo trecho.
+//
+// Como cada palavra é analisada isoladamente, filtros que atravessam palavras (ex.: URLs
+// seguidas de quebra de linha) podem gerar tokens um pouco diferentes dos de Analyze; use
+// AlignSpans para levar os spans aos tokens de Analyze.
+func (a *Analyzer) AnalyzeSpans(text string) []Span {
+	var ret []Span
+	last := 0
+	for _, s := range a.Spans(text) {
+		ret = a.analyzeWords(ret, text, last, s.Start)
+		ret = append(ret, s)
+		last = s.End
+	}
+	return a.analyzeWords(ret, text, last, len(text))
+}
+
+// analyzeWords analisa as palavras (separadas por espaços) de text[from:to] e as acrescenta a ret.
+func (a *Analyzer) analyzeWords(ret []Span, text string, from, to int) []Span {
+	start := -1
+	for i := from; i <= to; {
+		r, size := rune(' '), 1
+		if i < to {
+			r, size = utf8.DecodeRuneInString(text[i:to])
+		}
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				for _, t := range a.analyze(text[start:i]) {
+					ret = append(ret, Span{Start: start, End: i, Token: t})
+				}
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+		i += size
+	}
+	return ret
+}
+
+// alignWindow é quantos spans à frente AlignSpans procura o próximo token.
+const alignWindow = 32
+
+// AlignSpans associa a cada token de tokens (a saída de Analyze para o mesmo texto) o trecho
+// do span de AnalyzeSpans com o mesmo token, percorrendo os dois em ordem. Tokens sem span
+// correspondente recebem um trecho vazio no fim do trecho anterior (ou no início do texto),
+// para não roubar o trecho do token seguinte; quem marca trechos deve ignorá-los. O resultado
+// tem sempre len(tokens) elementos, com os tokens de tokens.
+func AlignSpans(tokens []string, spans []Span) []Span {
+	ret := make([]Span, len(tokens))
+	next, end := 0, 0
+	for i, tok := range tokens {
+		match := -1
+		for k := next; k < len(spans) && k < next+alignWindow; k++ {
+			if spans[k].Token == tok {
+				match = k
+				break
+			}
+		}
+		if match < 0 {
+			ret[i] = Span{Start: end, End: end, Token: tok}
+			continue
+		}
+		ret[i] = Span{Start: spans[match].Start, End: spans[match].End, Token: tok}
+		next, end = match+1, spans[match].End
+	}
+	return ret
+}
@@ -0,0 +1,311 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+)
+
+// Implementação do RSLP (Removedor de Sufixos da Língua Portuguesa, Orengo & Huyck, 2001).
+// As regras são escritas com acentos, como no artigo, e dobradas na inicialização: o
+// stemmer remove os acentos da palavra antes de aplicá-las, então funciona tanto antes
+// quanto depois do char filter "fold_accents".
+
+type rslpRule struct {
+	suffix      string
+	minStem     int
+	replacement string
+	exceptions  map[string]bool
+}
+
+type rslpStep []rslpRule
+
+// rule constrói uma regra: sufixo, tamanho mínimo do radical, substituição e exceções.
+func rule(suffix string, minStem int, replacement string, exceptions ...string) rslpRule {
+	ret := rslpRule{
+		suffix:      Fold(suffix),
+		minStem:     minStem,
+		replacement: Fold(replacement),
+		exceptions:  make(map[string]bool, len(exceptions)),
+	}
+	for _, e := range exceptions {
+		ret.exceptions[Fold(e)] = true
+	}
+	return ret
+}
+
+// apply aplica a primeira regra aplicável do passo e informa se houve remoção.
+func (s rslpStep) apply(word string) (string, bool) {
+	for _, r := range s {
+		if !strings.HasSuffix(word, r.suffix) || len(word)-len(r.suffix) < r.minStem || r.exceptions[word] {
+			continue
+		}
+		return word[:len(word)-len(r.suffix)] + r.replacement, true
+	}
+	return word, false
+}
+
+// sorted ordena as regras do sufixo mais longo para o mais curto, preservando a ordem
+// do artigo entre sufixos de mesmo tamanho (regras com sufixos dobrados iguais, como
+// "ês"/"es", mantêm a ordem original).
+func sorted(rules ...rslpRule) rslpStep {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].suffix) > len(rules[j].suffix)
+	})
+	return rules
+}
+
+var ptFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Fold remove os acentos do português sem depender do arquivo de substituições.
+func Fold(s string) string {
+	return ptFolder.Replace(s)
+}
+
+var (
+	rslpPlural = sorted(
+		rule("ns", 1, "m"),
+		rule("ões", 3, "ão"),
+		rule("ães", 1, "ão", "mães"),
+		rule("ais", 1, "al", "cais", "mais"),
+		rule("éis", 2, "el"),
+		rule("eis", 2, "el"),
+		rule("óis", 2, "ol"),
+		rule("is", 2, "il", "lápis", "cais", "mais", "crúcis", "biquínis", "pois", "depois", "dois", "leis"),
+		rule("les", 3, "l"),
+		rule("res", 3, "r"),
+		rule("s", 2, "", "aliás", "pires", "lápis", "cais", "mais", "mas", "menos", "férias", "fezes", "pêsames",
+			"crúcis", "gás", "atrás", "moisés", "através", "convés", "ês", "país", "após", "ambas", "ambos", "messias"),
+	)
+
+	rslpFeminine = sorted(
+		rule("ona", 3, "ão", "abandona", "lona", "iona", "cortisona", "monótona", "maratona", "acetona", "detona", "carona"),
+		rule("ora", 3, "or"),
+		rule("na", 4, "no", "carona", "abandona", "lona", "iona", "cortisona", "monótona", "maratona", "acetona",
+			"detona", "guiana", "campana", "grana", "caravana", "banana", "paisana"),
+		rule("inha", 3, "inho", "rainha", "linha", "minha"),
+		rule("esa", 3, "ês", "mesa", "obesa", "princesa", "turquesa", "ilesa", "pesa", "presa"),
+		rule("osa", 3, "oso", "mucosa", "prosa"),
+		rule("íaca", 3, "íaco"),
+		rule("ica", 3, "ico", "dica"),
+		rule("ada", 2, "ado", "pitada"),
+		rule("ida", 3, "ido", "vida"),
+		rule("ída", 3, "ido", "recaída", "saída", "dúvida"),
+		rule("ima", 3, "imo", "vítima"),
+		rule("iva", 3, "ivo", "saliva", "oliva"),
+		rule("eira", 3, "eiro", "beira", "cadeira", "frigideira", "bandeira", "feira", "capoeira", "barreira",
+			"fronteira", "besteira", "poeira"),
+	)
+
+	rslpAdverb = sorted(
+		rule("mente", 4, "", "experimente"),
+	)
+
+	rslpAugmentative = sorted(
+		rule("díssimo", 5, ""),
+		rule("abilíssimo", 5, ""),
+		rule("íssimo", 3, ""),
+		rule("ésimo", 3, ""),
+		rule("érrimo", 4, ""),
+		rule("zinho", 2, ""),
+		rule("quinho", 4, "c"),
+		rule("uinho", 4, ""),
+		rule("adinho", 3, ""),
+		rule("inho", 3, "", "caminho", "cominho"),
+		rule("alhão", 4, ""),
+		rule("uça", 4, ""),
+		rule("aço", 4, "", "antebraço"),
+		rule("aça", 4, ""),
+		rule("adão", 4, ""),
+		rule("idão", 4, ""),
+		rule("ázio", 3, "", "topázio"),
+		rule("arraz", 4, ""),
+		rule("zarrão", 3, ""),
+		rule("arrão", 4, ""),
+		rule("zão", 2, "", "coalizão"),
+		rule("ão", 3, "", "camarão", "chimarrão", "canção", "coração", "embrião", "grotão", "glutão", "ficção",
+			"fogão", "feição", "furacão", "gamão", "lampião", "leão", "macacão", "nação", "órfão", "orgão",
+			"patrão", "portão", "quinhão", "rincão", "tração", "falcão", "espião", "mamão", "folião", "cordão",
+			"aptidão", "campeão", "colchão", "limão", "leilão", "melão", "barão", "milhão", "bilhão", "fusão",
+			"cristão", "ilusão", "capitão", "estação", "senão"),
+	)
+
+	rslpNoun = sorted(
+		rule("encialista", 4, ""),
+		rule("alista", 5, ""),
+		rule("agem", 3, "", "coragem", "chantagem", "vantagem", "carruagem"),
+		rule("iamento", 4, ""),
+		rule("amento", 3, "", "firmamento", "fundamento", "departamento"),
+		rule("imento", 3, ""),
+		rule("mento", 6, "", "firmamento", "elemento", "complemento", "instrumento", "departamento"),
+		rule("alizado", 4, ""),
+		rule("atizado", 4, ""),
+		rule("tizado", 4, "", "alfabetizado"),
+		rule("izado", 5, "", "organizado", "pulverizado"),
+		rule("ativo", 4, "", "pejorativo", "relativo"),
+		rule("tivo", 4, "", "relativo"),
+		rule("ivo", 4, "", "passivo", "possessivo", "pejorativo", "positivo"),
+		rule("ado", 2, "", "grado"),
+		rule("ido", 3, "", "cândido", "consolido", "rápido", "decido", "tímido", "duvido", "marido"),
+		rule("ador", 3, ""),
+		rule("edor", 3, ""),
+		rule("idor", 4, "", "ouvidor"),
+		rule("dor", 4, "", "ouvidor"),
+		rule("sor", 4, "", "assessor"),
+		rule("atoria", 5, ""),
+		rule("tor", 3, "", "benfeitor", "leitor", "editor", "pastor", "produtor", "promotor", "consultor"),
+		rule("or", 2, "", "motor", "melhor", "redor", "rigor", "sensor", "tambor", "tumor", "assessor",
+			"benfeitor", "pastor", "terior", "favor", "autor"),
+		rule("abilidade", 5, ""),
+		rule("icionista", 4, ""),
+		rule("cionista", 5, ""),
+		rule("ionista", 5, ""),
+		rule("ionar", 5, ""),
+		rule("ional", 4, ""),
+		rule("ência", 3, ""),
+		rule("ância", 4, "", "ambulância"),
+		rule("edouro", 3, ""),
+		rule("queiro", 3, "c"),
+		rule("adeiro", 4, "", "desfiladeiro"),
+		rule("eiro", 3, "", "desfiladeiro", "pioneiro", "mosteiro"),
+		rule("uoso", 3, ""),
+		rule("oso", 3, "", "precioso"),
+		rule("alizaç", 5, ""),
+		rule("atizaç", 5, ""),
+		rule("tizaç", 5, ""),
+		rule("izaç", 5, "", "organizaç"),
+		rule("aç", 3, "", "equaç", "relaç"),
+		rule("iç", 3, "", "eleiç"),
+		rule("ário", 3, "", "voluntário", "salário", "aniversário", "diário", "lionário", "armário"),
+		rule("atório", 3, ""),
+		rule("rio", 5, "", "voluntário", "salário", "aniversário", "diário", "compulsório", "lionário",
+			"próprio", "stério", "armário"),
+		rule("ério", 6, ""),
+		rule("ês", 4, ""),
+		rule("eza", 3, ""),
+		rule("ez", 4, ""),
+		rule("esco", 4, ""),
+		rule("ante", 2, "", "gigante", "elefante", "adiante", "possante", "instante", "restaurante"),
+		rule("ástico", 4, "", "eclesiástico"),
+		rule("alístico", 3, ""),
+		rule("áutico", 4, ""),
+		rule("êutico", 4, ""),
+		rule("tico", 3, "", "político", "eclesiástico", "diagnóstico", "prático", "doméstico", "idêntico",
+			"alopático", "artístico", "autêntico", "eclético", "crítico"),
+		rule("ico", 4, "", "tico", "público", "explico"),
+		rule("ividade", 5, ""),
+		rule("idade", 4, "", "autoridade", "comunidade"),
+		rule("oria", 4, "", "categoria"),
+		rule("encial", 5, ""),
+		rule("ista", 4, ""),
+		rule("auta", 5, ""),
+		rule("quice", 4, "c"),
+		rule("ice", 4, "", "cúmplice"),
+		rule("íaco", 3, ""),
+		rule("ente", 4, "", "freqüente", "alimente", "acrescente", "permanente", "oriente", "aparente"),
+		rule("ense", 5, ""),
+		rule("inal", 3, ""),
+		rule("ano", 4, ""),
+		rule("ável", 2, "", "afável", "razoável", "potável", "vulnerável"),
+		rule("ível", 3, "", "possível"),
+		rule("vel", 5, "", "possível", "vulnerável", "solúvel"),
+		rule("bil", 3, "vel"),
+		rule("ura", 4, "", "imatura", "acupuntura", "costura"),
+		rule("ural", 4, ""),
+		rule("ual", 3, "", "bissexual", "virtual", "visual", "pontual"),
+		rule("ial", 3, ""),
+		rule("al", 4, "", "afinal", "animal", "estatal", "bissexual", "desleal", "fiscal", "formal", "pessoal",
+			"liberal", "postal", "virtual", "visual", "pontual", "sideral", "sucursal"),
+		rule("alismo", 4, ""),
+		rule("ivismo", 4, ""),
+		rule("ismo", 3, "", "cinismo"),
+	)
+
+	rslpVerb = sorted(
+		rule("aríamo", 2, ""), rule("ássemo", 2, ""), rule("eríamo", 2, ""), rule("êssemo", 2, ""),
+		rule("iríamo", 3, ""), rule("íssemo", 3, ""), rule("áramo", 2, ""), rule("árei", 2, ""),
+		rule("aremo", 2, ""), rule("ariam", 2, ""), rule("aríei", 2, ""), rule("ássei", 2, ""),
+		rule("assem", 2, ""), rule("ávamo", 2, ""), rule("êramo", 3, ""), rule("eremo", 3, ""),
+		rule("eriam", 3, ""), rule("eríei", 3, ""), rule("êssei", 3, ""), rule("essem", 3, ""),
+		rule("íramo", 3, ""), rule("iremo", 3, ""), rule("iriam", 3, ""), rule("iríei", 3, ""),
+		rule("íssei", 3, ""), rule("issem", 3, ""),
+		rule("ando", 2, ""), rule("endo", 3, ""), rule("indo", 3, ""), rule("ondo", 3, ""),
+		rule("aram", 2, ""), rule("arão", 2, ""), rule("arde", 2, ""), rule("arei", 2, ""),
+		rule("arem", 2, ""), rule("aria", 2, ""), rule("armo", 2, ""), rule("asse", 2, ""),
+		rule("aste", 2, ""), rule("avam", 2, "", "agravam"), rule("ávei", 2, ""), rule("eram", 3, ""),
+		rule("erão", 3, ""), rule("erde", 3, ""), rule("erei", 3, ""), rule("êrei", 3, ""),
+		rule("erem", 3, ""), rule("eria", 3, ""), rule("ermo", 3, ""), rule("esse", 3, ""),
+		rule("este", 3, "", "faroeste", "agreste"), rule("íamo", 3, ""), rule("iram", 3, ""),
+		rule("íram", 3, ""), rule("irão", 2, ""), rule("irde", 2, ""), rule("irei", 3, "", "admirei"),
+		rule("irem", 3, "", "adquirem"), rule("iria", 3, ""), rule("irmo", 3, ""), rule("isse", 3, ""),
+		rule("iste", 4, ""), rule("iava", 4, "", "ampliava"), rule("amo", 2, ""), rule("iona", 3, ""),
+		rule("ara", 2, "", "arara", "prepara"), rule("ará", 2, "", "alvará"), rule("are", 2, "", "prepare"),
+		rule("ava", 2, "", "agrava"), rule("emo", 2, ""), rule("era", 3, "", "acelera", "espera"),
+		rule("erá", 3, ""), rule("ere", 3, "", "espere"),
+		rule("iam", 3, "", "enfiam", "ampliam", "elogiam", "ensaiam"), rule("íei", 3, ""),
+		rule("imo", 3, "", "reprimo", "intimo", "íntimo", "nimo", "queimo", "ximo"),
+		rule("ira", 3, "", "fronteira", "sátira"), rule("ído", 3, ""), rule("irá", 3, ""),
+		rule("tizar", 4, "", "alfabetizar"), rule("izar", 5, "", "organizar"),
+		rule("itar", 5, "", "acreditar", "explicitar", "estreitar"), rule("ire", 3, "", "adquire"),
+		rule("omo", 3, ""), rule("ai", 2, ""), rule("am", 2, ""), rule("ear", 4, "", "alardear", "nuclear"),
+		rule("ar", 2, "", "azar", "bazaar", "patamar"), rule("uei", 3, ""), rule("uía", 5, "u"),
+		rule("ei", 3, ""), rule("guem", 3, "g"), rule("em", 2, "", "alem", "virgem"),
+		rule("er", 2, "", "éter", "pier"), rule("eu", 3, "", "chapeu"),
+		rule("ia", 3, "", "estória", "fatia", "acia", "praia", "elogia", "mania", "lábia", "aprecia",
+			"polícia", "arredia", "cheia", "ásia"),
+		rule("ir", 3, "", "freir"), rule("iu", 3, ""), rule("eou", 5, ""), rule("ou", 3, ""), rule("i", 3, ""),
+	)
+
+	rslpVowel = sorted(
+		rule("bil", 2, "vel"),
+		rule("gue", 2, "g", "gangue", "jegue"),
+		rule("á", 3, ""),
+		rule("ê", 3, "", "bebê"),
+		rule("a", 3, "", "ásia"),
+		rule("e", 3, ""),
+		rule("o", 3, "", "ão"),
+	)
+)
+
+// StemRSLP reduz uma palavra ao seu radical seguindo os passos do RSLP: plural, feminino,
+// advérbio, aumentativo/diminutivo, sufixos nominais, sufixos verbais (se nenhum nominal
+// for removido) e vogal temática (se nenhum dos dois for removido). O resultado não tem acentos.
+func StemRSLP(word string) string {
+	word = Fold(strings.ToLower(word))
+	if len(word) < 3 {
+		return word
+	}
+
+	if strings.HasSuffix(word, "s") {
+		word, _ = rslpPlural.apply(word)
+	}
+	if strings.HasSuffix(word, "a") {
+		word, _ = rslpFeminine.apply(word)
+	}
+	word, _ = rslpAdverb.apply(word)
+	word, _ = rslpAugmentative.apply(word)
+
+	var removed bool
+	if word, removed = rslpNoun.apply(word); !removed {
+		if word, removed = rslpVerb.apply(word); !removed {
+			word, _ = rslpVowel.apply(word)
+		}
+	}
+	return word
+}
+
+// RSLPStemmer é o token filter que aplica StemRSLP a cada token.
+type RSLPStemmer struct{}
+
+func (RSLPStemmer) Filter(tokens []string) []string {
+	for i, t := range tokens {
+		tokens[i] = StemRSLP(t)
+	}
+	return tokens
+}
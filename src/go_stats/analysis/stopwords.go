@@ -0,0 +1,182 @@
+package analysis
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// StopwordFilter remove os tokens presentes em um conjunto de stopwords definido pelo
+// usuário, complementando a lista fixa de "stopwords_pt".
+type StopwordFilter struct {
+	words map[string]bool
+}
+
+// NewStopwordFilter cria o filtro. As palavras são convertidas para minúsculas e sem
+// acento, como os tokens do pipeline padrão.
+func NewStopwordFilter(words []string) *StopwordFilter {
+	ret := &StopwordFilter{words: make(map[string]bool, len(words))}
+	for _, w := range words {
+		ret.words[Fold(strings.ToLower(w))] = true
+	}
+	return ret
+}
+
+func (f *StopwordFilter) Filter(tokens []string) []string {
+	ret := tokens[:0]
+	for _, t := range tokens {
+		if !f.words[t] {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// LoadStopwords lê um arquivo de stopwords com uma palavra por linha. Linhas vazias e
+// iniciadas por '#' são ignoradas.
+func LoadStopwords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ret []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		ret = append(ret, text)
+	}
+	return ret, scanner.Err()
+}
+
+// WithStopwords retorna uma cópia da configuração que também remove as palavras dos
+// arquivos em files e as palavras em words. O filtro entra logo após "stopwords_pt",
+// antes de stemmers e lematizadores adicionados depois com WithStemmer.
+func (c Config) WithStopwords(files []string, words []string) Config {
+	if len(files) == 0 && len(words) == 0 {
+		return c
+	}
+	c.StopwordFiles = append(append([]string(nil), c.StopwordFiles...), files...)
+	c.Stopwords = append(append([]string(nil), c.Stopwords...), words...)
+	sort.Strings(c.Stopwords)
+
+	for _, f := range c.TokenFilters {
+		if f == "stopwords_custom" {
+			return c
+		}
+	}
+	c.TokenFilters = append(append([]string(nil), c.TokenFilters...), "stopwords_custom")
+	return c
+}
+
+// StopwordList retorna, em ordem alfabética e sem repetição, as stopwords de
+// "stopwords_custom" (Stopwords e o conteúdo de StopwordFiles), na forma normalizada usada
+// pelo filtro. A lista fixa de "stopwords_pt" não é incluída.
+func (c Config) StopwordList() ([]string, error) {
+	words := append([]string(nil), c.Stopwords...)
+	for _, path := range c.StopwordFiles {
+		loaded, err := LoadStopwords(path)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, loaded...)
+	}
+	for i, w := range words {
+		words[i] = Fold(strings.ToLower(w))
+	}
+	sort.Strings(words)
+	return slices.Compact(words), nil
+}
+
+// builtinStopwordsModule é o módulo que fornece a lista fixa de "stopwords_pt".
+const builtinStopwordsModule = "github.com/bbalet/stopwords"
+
+// builtinStopwords identifica a lista fixa de "stopwords_pt" pela versão do módulo que a
+// fornece (ex.: "github.com/bbalet/stopwords@v1.0.0"), já que a lista não é exportada.
+func builtinStopwords() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path != builtinStopwordsModule {
+				continue
+			}
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			return dep.Path + "@" + dep.Version
+		}
+	}
+	return builtinStopwordsModule
+}
+
+// StopwordsDigest retorna o SHA-256 (hex) do conjunto efetivo de stopwords da configuração:
+// a lista fixa de "stopwords_pt", se o filtro estiver ativo (ver builtinStopwords), e as
+// palavras de StopwordList, se "stopwords_custom" estiver ativo.
+func (c Config) StopwordsDigest() (string, error) {
+	h := sha256.New()
+	for _, f := range c.TokenFilters {
+		switch f {
+		case "stopwords_pt":
+			fmt.Fprintf(h, "builtin %s\n", builtinStopwords())
+		case "stopwords_custom":
+			words, err := c.StopwordList()
+			if err != nil {
+				return "", err
+			}
+			for _, w := range words {
+				fmt.Fprintf(h, "word %s\n", w)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DocFreq acumula a frequência de documentos (DF) de cada token.
+type DocFreq struct {
+	Docs   int
+	Counts map[string]int
+}
+
+func NewDocFreq() *DocFreq {
+	return &DocFreq{Counts: make(map[string]int)}
+}
+
+// Add contabiliza os tokens de um documento (cada token conta uma vez por documento).
+func (d *DocFreq) Add(tokens []string) {
+	d.Docs++
+	seen := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			d.Counts[t]++
+		}
+	}
+}
+
+// Stopwords retorna, em ordem alfabética, os tokens que aparecem em pelo menos a fração
+// threshold (0 < threshold <= 1) dos documentos. Citações legais nunca são retornadas.
+func (d *DocFreq) Stopwords(threshold float64) []string {
+	if d.Docs == 0 || threshold <= 0 {
+		return nil
+	}
+	var ret []string
+	for t, n := range d.Counts {
+		if _, ok := IsCitation(t); ok {
+			continue
+		}
+		if float64(n)/float64(d.Docs) >= threshold {
+			ret = append(ret, t)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
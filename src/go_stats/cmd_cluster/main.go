@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/tcc2-davi-arthur/corpus"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// exportedTopic is a topic as written by -format json.
+type exportedTopic struct {
+	ID     uint16   `json:"id"`
+	Method string   `json:"method"`
+	Label  string   `json:"label"`
+	Terms  []string `json:"terms"`
+	Docs   []string `json:"docs"`
+}
+
+// main builds the unigram index, groups the corpus into topics, records the assignments in
+// the base database (CLUSTER and Document.Cluster) and exports the topics.
+//
+//	go run ./cmd_cluster -k 30 -format csv -o clusters.csv
+//	go run ./cmd_cluster -method hierarchical -embeddings ../../misc/corpus/embeddings.json
+func main() {
+	def := corpus.DefaultClusterOptions()
+	opts := def
+	flag.IntVar(&opts.K, "k", def.K, "number of topics")
+	flag.StringVar(&opts.Method, "method", def.Method, "clustering method: "+corpus.ClusterKMeans+" or "+corpus.ClusterHierarchical)
+	flag.IntVar(&opts.MaxIter, "iter", def.MaxIter, "maximum k-means iterations")
+	flag.Uint64Var(&opts.Seed, "seed", def.Seed, "k-means++ seed")
+	flag.IntVar(&opts.Terms, "terms", def.Terms, "top TF-IDF terms kept per topic")
+	embeddings := flag.String("embeddings", "", "cluster the document embeddings in this file (written by cmd_embed) instead of the TF-IDF vectors")
+	format := flag.String("format", "text", "export format: text, csv or json")
+	out := flag.String("o", "", "output file (default stdout)")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "csv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "invalid -format %q\n", *format)
+		os.Exit(2)
+	}
+	if opts.Method != corpus.ClusterKMeans && opts.Method != corpus.ClusterHierarchical {
+		fmt.Fprintf(os.Stderr, "invalid -method %q\n", opts.Method)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbName, db, err := corpus.CreateDatabaseCaches(ctx, time.Now().UnixNano(), false, 1, 0, corpus.DefaultAnalyzerConfig())
+	if dbName != "" {
+		defer os.Remove(dbName)
+	}
+	if err != nil {
+		utils.Fatal("failed to load index", "err", err)
+	}
+	defer func() {
+		if sqlDB, e := db.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+
+	if *embeddings != "" {
+		if opts.Embeddings, err = corpus.LoadEmbeddings(*embeddings); err != nil {
+			utils.Fatal("failed to load embeddings", "file", *embeddings, "err", err)
+		}
+	}
+
+	start := time.Now()
+	topics, err := corpus.ClusterDocs(ctx, opts)
+	if err != nil {
+		utils.Fatal("clustering failed", "err", err)
+	}
+	// As in cmd_dedup, the topics go to the base database rather than the per-run copy.
+	base, err := utils.OpenDB(corpus.DbFile)
+	if err != nil {
+		utils.Fatal("failed to open database", "file", corpus.DbFile, "err", err)
+	}
+	err = corpus.SaveClusters(base, topics)
+	if sqlDB, e := base.DB(); e == nil {
+		_ = sqlDB.Close()
+	}
+	if err != nil {
+		utils.Fatal("failed to record clusters", "file", corpus.DbFile, "err", err)
+	}
+	slog.Info("clustering finished", "method", opts.Method, "topics", len(topics), "elapsed", time.Since(start))
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			utils.Fatal("failed to create output", "file", *out, "err", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "csv":
+		err = writeCSV(w, topics)
+	case "json":
+		err = writeJSON(w, topics)
+	default:
+		writeText(w, topics)
+	}
+	if err != nil {
+		utils.Fatal("failed to export clusters", "err", err)
+	}
+}
+
+// writeText prints each topic with its terms and documents.
+func writeText(w io.Writer, topics []corpus.Topic) {
+	for _, t := range topics {
+		fmt.Fprintf(w, "%3d  %4d docs  %s\n", t.Cluster.ID, t.Cluster.Size, t.Cluster.Label)
+		fmt.Fprintf(w, "     terms: %s\n", t.Cluster.Terms)
+		for _, doc := range t.Docs {
+			fmt.Fprintf(w, "     %s", doc.Name)
+			if doc.Ementa != "" {
+				fmt.Fprintf(w, "  %s", doc.Ementa)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeCSV writes one row per document with its topic.
+func writeCSV(w io.Writer, topics []corpus.Topic) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"Cluster", "Label", "Document", "Type", "Year", "DupOf"})
+	for _, t := range topics {
+		for _, doc := range t.Docs {
+			year := ""
+			if y := doc.ProposalYear(); y != 0 {
+				year = strconv.Itoa(y)
+			}
+			_ = cw.Write([]string{strconv.Itoa(int(t.Cluster.ID)), t.Cluster.Label, doc.Name, doc.ProposalType(), year, strconv.Itoa(int(doc.DupOf))})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes the topics with their terms and document names.
+func writeJSON(w io.Writer, topics []corpus.Topic) error {
+	ret := make([]exportedTopic, 0, len(topics))
+	for _, t := range topics {
+		e := exportedTopic{ID: t.Cluster.ID, Method: t.Cluster.Method, Label: t.Cluster.Label, Terms: t.Cluster.TermList()}
+		for _, doc := range t.Docs {
+			e.Docs = append(e.Docs, doc.Name)
+		}
+		ret = append(ret, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ret)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/tcc2-davi-arthur/corpus"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// main builds the unigram index, runs the MinHash/LSH near-duplicate detection with the
+// requested parameters, records the groups on the documents of the base database (DupOf)
+// and prints a cluster report.
+//
+//	go run ./cmd_dedup -threshold 0.9 -format csv > duplicates.csv
+func main() {
+	def := corpus.DefaultDedupOptions()
+	opts := def
+	flag.IntVar(&opts.ShingleSize, "shingle", def.ShingleSize, "words per shingle")
+	flag.IntVar(&opts.Hashes, "hashes", def.Hashes, "MinHash signature size")
+	flag.IntVar(&opts.Bands, "bands", def.Bands, "LSH bands (must divide -hashes)")
+	flag.Float64Var(&opts.Threshold, "threshold", def.Threshold, "minimum estimated Jaccard similarity")
+	flag.Uint64Var(&opts.Seed, "seed", def.Seed, "hash seed")
+	format := flag.String("format", "text", "report format: text or csv")
+	logLevel := flag.String("log-level", "warn", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "invalid -format %q\n", *format)
+		os.Exit(2)
+	}
+	if opts.ShingleSize < 1 || opts.ShingleSize > 3 {
+		fmt.Fprintln(os.Stderr, "-shingle must be between 1 and 3")
+		os.Exit(2)
+	}
+	if opts.Bands < 1 || opts.Hashes%opts.Bands != 0 {
+		fmt.Fprintln(os.Stderr, "-bands must divide -hashes")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbName, db, err := corpus.CreateDatabaseCaches(ctx, time.Now().UnixNano(), false, 1, 0, corpus.DefaultAnalyzerConfig())
+	if dbName != "" {
+		defer os.Remove(dbName)
+	}
+	if err != nil {
+		utils.Fatal("failed to load index", "err", err)
+	}
+	defer func() {
+		if sqlDB, e := db.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+
+	start := time.Now()
+	clusters, err := corpus.FindDuplicates(ctx, opts)
+	if err != nil {
+		utils.Fatal("near-duplicate detection failed", "err", err)
+	}
+	// The index runs on a per-run copy of the database; the groups go to the base file so
+	// that later runs (cmd_search -like, cmd_cluster) see them.
+	base, err := utils.OpenDB(corpus.DbFile)
+	if err != nil {
+		utils.Fatal("failed to open database", "file", corpus.DbFile, "err", err)
+	}
+	err = corpus.MarkDuplicates(base, clusters)
+	if sqlDB, e := base.DB(); e == nil {
+		_ = sqlDB.Close()
+	}
+	if err != nil {
+		utils.Fatal("failed to record near-duplicates", "file", corpus.DbFile, "err", err)
+	}
+	slog.Info("near-duplicate detection finished", "clusters", len(clusters), "elapsed", time.Since(start))
+
+	if *format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"Cluster", "Canonical", "Document", "Similarity"})
+		for i, c := range clusters {
+			for _, m := range c.Duplicates {
+				_ = w.Write([]string{strconv.Itoa(i + 1), c.Canonical.Name, m.Doc.Name, strconv.FormatFloat(m.Similarity, 'f', 4, 64)})
+			}
+		}
+		w.Flush()
+		if err = w.Error(); err != nil {
+			utils.Fatal("failed to write report", "err", err)
+		}
+		return
+	}
+
+	dups := 0
+	for i, c := range clusters {
+		fmt.Printf("%3d  %s\n", i+1, c.Canonical.Name)
+		for _, m := range c.Duplicates {
+			fmt.Printf("       %.4f  %s\n", m.Similarity, m.Doc.Name)
+		}
+		dups += len(c.Duplicates)
+	}
+	fmt.Printf("\n%d clusters, %d near-duplicates of %d documents\n", len(clusters), dups, len(corpus.CacheDocs))
+}
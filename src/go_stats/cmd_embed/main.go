@@ -2,13 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
 
+	"github.com/tcc2-davi-arthur/corpus"
 	"github.com/tcc2-davi-arthur/utils"
 )
 
@@ -36,12 +38,15 @@ type (
 	}
 )
 
-func search(queryEmb []float32, docEmbeddings [][]float32) ([]int, int64) {
+func search(queryEmb []float32, docEmbeddings [][]float32) ([]int, int64, error) {
 	start := time.Now()
 	results := make([]Result, len(docEmbeddings))
 
 	for i, docEmb := range docEmbeddings {
-		score := utils.CosineSimVecs(queryEmb, docEmb)
+		score, err := utils.CosineSimVecs(queryEmb, docEmb)
+		if err != nil {
+			return nil, 0, fmt.Errorf("doc %d: %w", i, err)
+		}
 		results[i] = Result{Index: i, Score: score}
 	}
 
@@ -53,7 +58,7 @@ func search(queryEmb []float32, docEmbeddings [][]float32) ([]int, int64) {
 	for i, res := range results {
 		finalIndices[i] = res.Index + 1
 	}
-	return finalIndices, time.Since(start).Microseconds()
+	return finalIndices, time.Since(start).Microseconds(), nil
 }
 
 func loadTexts(folder string) ([]string, []string, error) {
@@ -77,28 +82,38 @@ func loadTexts(folder string) ([]string, []string, error) {
 }
 
 func main() {
-	log.Println("Inicializando ONNX Runtime...")
+	embeddingsOut := flag.String("embeddings-out", "", "also write the document embeddings to this file, for cmd_search -like -embeddings (e.g. "+corpus.EmbeddingsFile+")")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	slog.Info("inicializando ONNX Runtime")
 	if err := utils.InitONNX(DylibPath); err != nil {
-		log.Fatal("Falha no InitONNX:", err)
+		utils.Fatal("falha no InitONNX", "err", err)
 	}
 	defer utils.DestroyONNX()
 
-	log.Println("Carregando BERT (Static Tensors)...")
+	slog.Info("carregando BERT (tensores estáticos)")
 	bert, err := utils.LoadBert(OnnxPath, TokenizerPath)
 	if err != nil {
-		log.Fatal("Falha ao carregar BERT:", err)
+		utils.Fatal("falha ao carregar BERT", "err", err)
 	}
 	defer bert.Close()
 
-	log.Println("Lendo arquivos...")
+	slog.Info("lendo arquivos", "dir", LawsFolder)
 	files, texts, err := loadTexts(LawsFolder)
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal("falha ao ler documentos", "err", err)
 	}
 	nDocs := len(files)
 
-	log.Println("Gerando embeddings dos documentos...")
+	slog.Info("gerando embeddings dos documentos", "docs", nDocs)
 	docEmbeddings := make([][]float32, nDocs)
+	progress := utils.NewProgress("embeddings de documentos", nDocs)
 
 	var totalDocsTime time.Duration // Acumulador de tempo
 
@@ -114,23 +129,33 @@ func main() {
 		totalDocsTime += duration
 
 		if err != nil {
-			log.Fatalf("Erro no doc %d: %v", i, err)
+			utils.Fatal("erro gerando embedding", "doc", files[i], "err", err)
 		}
 		docEmbeddings[i] = emb
+		progress.Add(1)
+	}
+	progress.Finish()
 
-		fmt.Printf("Processados %d/%d...\r", i, nDocs)
+	if *embeddingsOut != "" {
+		byName := make(map[string][]float32, nDocs)
+		for i, name := range files {
+			byName[name] = docEmbeddings[i]
+		}
+		if err = corpus.SaveEmbeddings(*embeddingsOut, byName); err != nil {
+			utils.Fatal("falha ao gravar embeddings", "file", *embeddingsOut, "err", err)
+		}
+		slog.Info("embeddings gravados", "file", *embeddingsOut, "docs", nDocs)
 	}
-	fmt.Println("\nEmbeddings concluídos.")
-	log.Println("Lendo inputs...")
+	slog.Info("lendo inputs", "file", InputsPath)
 
 	jsonBytes, err := os.ReadFile(InputsPath)
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal("falha ao ler inputs", "err", err)
 	}
 
 	var inputs SearchInputs
 	if err = json.Unmarshal(jsonBytes, &inputs); err != nil {
-		log.Fatal(err)
+		utils.Fatal("falha ao parsear inputs", "err", err)
 	}
 
 	finalOutput := make(SearchInputs)
@@ -141,8 +166,9 @@ func main() {
 	groupCounts := make(map[string]int)
 
 	for _, group := range groups {
-		log.Printf("Processando grupo %s...", group)
 		samples := inputs[group]
+		slog.Info("processando grupo", "group", group, "inputs", len(samples))
+		groupProgress := utils.NewProgress("consultas "+group, len(samples))
 		processedSamples := make([]InputSample, 0, len(samples))
 
 		var groupTotalTime time.Duration // Acumulador do grupo atual
@@ -158,17 +184,23 @@ func main() {
 			duration := time.Since(start)
 			groupTotalTime += duration
 
+			groupProgress.Add(1)
 			if err != nil {
-				log.Printf("Erro input: %v", err)
+				slog.Warn("erro gerando embedding da consulta", "group", group, "err", err)
 				continue
 			}
 
-			ordered, t := search(qEmb, docEmbeddings)
+			ordered, t, err := search(qEmb, docEmbeddings)
+			if err != nil {
+				slog.Warn("erro na busca", "group", group, "err", err)
+				continue
+			}
 			sample.Bert = ordered
 			sample.BertT = t
 			processedSamples = append(processedSamples, sample)
 		}
 		finalOutput[group] = processedSamples
+		groupProgress.Finish()
 
 		groupTimes[group] = groupTotalTime
 		groupCounts[group] = len(samples)
@@ -179,30 +211,23 @@ func main() {
 		return
 	}
 
-	// --- 6. LOG DE ESTATÍSTICAS FINAIS ---
-	log.Println("--- RELATÓRIO DE PERFORMANCE (INFERÊNCIA BERT) ---")
+	// --- 6. LOG DE ESTATÍSTICAS FINAIS (INFERÊNCIA BERT) ---
 
 	// Stats Docs
 	if nDocs > 0 {
 		avgDoc := totalDocsTime / time.Duration(nDocs)
-		log.Printf("[Documentos] Total Docs: %d", nDocs)
-		log.Printf("[Documentos] Tempo Total: %v", totalDocsTime)
-		log.Printf("[Documentos] Média por Doc: %v", avgDoc)
+		slog.Info("performance documentos", "docs", nDocs, "total", totalDocsTime, "avg_per_doc", avgDoc)
 	}
 
-	log.Println("--------------------------------------------------")
-
 	// Stats Inputs por Grupo
 	for _, group := range groups {
 		count := groupCounts[group]
 		if count > 0 {
 			total := groupTimes[group]
 			avg := total / time.Duration(count)
-			log.Printf("[%s] Tempo Total: %v", group, total)
-			log.Printf("[%s] Média por Frase: %v", group, avg)
-			log.Println("-")
+			slog.Info("performance consultas", "group", group, "total", total, "avg_per_phrase", avg)
 		}
 	}
 
-	log.Println("Finalizado!")
+	slog.Info("finalizado", "output", OutputPath)
 }
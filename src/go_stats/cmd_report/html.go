@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>Relatório de benchmark</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 1100px; color: #222; }
+h1, h2 { border-bottom: 1px solid #ddd; padding-bottom: .2em; }
+table { border-collapse: collapse; font-size: 12px; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 3px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f4f4f4; }
+.meta { color: #666; font-size: 12px; }
+figure { margin: 1em 0; }
+</style>
+</head>
+<body>
+<h1>Relatório de benchmark</h1>
+<p class="meta">Gerado em {{.Generated}} a partir de: {{range $i, $s := .Sources}}{{if $i}}, {{end}}{{$s}}{{end}} ({{len .Rows}} configurações)</p>
+{{if .Snapshots}}<p class="meta">Snapshot do corpus: {{range $i, $s := .Snapshots}}{{if $i}}, {{end}}{{$s}}{{end}}{{if gt (len .Snapshots) 1}} (resultados de corpora diferentes não são diretamente comparáveis){{end}}</p>{{end}}
+
+<h2>Correlação por tamanho de n-grama e salto</h2>
+{{range .CorrelationCharts}}<figure>{{.}}</figure>
+{{end}}
+
+<h2>Latência por configuração</h2>
+{{range .LatencyCharts}}<figure>{{.}}</figure>
+{{end}}
+
+<h2>Uso de memória por configuração</h2>
+{{range .MemoryCharts}}<figure>{{.}}</figure>
+{{end}}
+
+<h2>Tabela de resultados</h2>
+<table>
+<tr><th>#</th><th>Configuração</th><th>Docs</th><th>Tempo total (ms)</th>
+{{range $.PhraseLengths}}<th>&rho; {{.}}</th><th>µs {{.}}</th>{{end}}
+{{range $.MemoryColumns}}<th>{{.}} (MB)</th>{{end}}</tr>
+{{range .Table}}<tr><td>{{.TestID}}</td><td>{{.Label}}</td><td>{{.TotalDocs}}</td><td>{{printf "%.0f" .TotalTime}}</td>
+{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+type (
+	htmlRow struct {
+		TestID    int
+		Label     string
+		TotalDocs int
+		TotalTime float64
+		Cells     []string
+	}
+
+	htmlReport struct {
+		Generated         string
+		Sources           []string
+		Snapshots         []string
+		Rows              []*ResultRow
+		PhraseLengths     []int
+		MemoryColumns     []string
+		CorrelationCharts []template.HTML
+		LatencyCharts     []template.HTML
+		MemoryCharts      []template.HTML
+		Table             []htmlRow
+	}
+)
+
+// now is the clock used for the report timestamp (fixed by the golden test).
+var now = time.Now
+
+// WriteHTML renders a self-contained HTML report (inline CSS and SVG, no
+// external assets) for rows into w.
+func WriteHTML(w io.Writer, rows []*ResultRow, sources []string) error {
+	tpl, err := template.New("report").Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	report := htmlReport{
+		Generated:     now().Format("2006-01-02 15:04"),
+		Sources:       sources,
+		Snapshots:     Snapshots(rows),
+		Rows:          rows,
+		PhraseLengths: PhraseLengths,
+	}
+	for _, col := range MemoryColumns {
+		for _, r := range rows {
+			if _, ok := r.ExtraFloat(col); ok {
+				report.MemoryColumns = append(report.MemoryColumns, col)
+				break
+			}
+		}
+	}
+
+	for _, n := range PhraseLengths {
+		series, jumps := CorrelationSeries(rows, n)
+		title := fmt.Sprintf("Spearman médio x salto (frases de %d palavras)", n)
+		report.CorrelationCharts = append(report.CorrelationCharts, template.HTML(LineChart(title, "salto", "ρ médio", series, jumps)))
+	}
+
+	for _, n := range PhraseLengths {
+		ranges := make([]Range, 0, len(rows))
+		for _, r := range rows {
+			p := r.Phrases[n]
+			ranges = append(ranges, Range{Label: r.Label(), Min: p.MinTime, Avg: p.AvgTime, Max: p.MaxTime})
+		}
+		title := fmt.Sprintf("Latência min/média/máx (frases de %d palavras)", n)
+		report.LatencyCharts = append(report.LatencyCharts, template.HTML(RangeChart(title, "µs", ranges)))
+	}
+
+	for _, col := range report.MemoryColumns {
+		bars := make([]Bar, 0, len(rows))
+		for _, r := range rows {
+			if v, ok := r.ExtraFloat(col); ok {
+				bars = append(bars, Bar{Label: r.Label(), Value: toMB(v)})
+			}
+		}
+		report.MemoryCharts = append(report.MemoryCharts, template.HTML(BarChart(col, "MB", bars)))
+	}
+	if len(report.MemoryCharts) == 0 {
+		report.MemoryCharts = append(report.MemoryCharts, template.HTML(emptyChart("Memória")))
+	}
+
+	for _, r := range rows {
+		row := htmlRow{TestID: r.TestID, Label: r.Label(), TotalDocs: r.TotalDocs, TotalTime: r.TotalTime}
+		for _, n := range PhraseLengths {
+			p := r.Phrases[n]
+			row.Cells = append(row.Cells, fmt.Sprintf("%.4f", p.AvgSpearman), fmt.Sprintf("%.0f", p.AvgTime))
+		}
+		for _, col := range report.MemoryColumns {
+			v, _ := r.ExtraFloat(col)
+			row.Cells = append(row.Cells, fmt.Sprintf("%.2f", toMB(v)))
+		}
+		report.Table = append(report.Table, row)
+	}
+
+	return tpl.Execute(w, report)
+}
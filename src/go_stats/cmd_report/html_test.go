@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const goldenCSV = `TestID,Algorithm,Pre-Indexed,Normalized jumps,Grams size,Jumps size,Parallel,TotalDocs,TotalTime,AvgSpearmanSim10,MinSpearmanSim10,MaxSpearmanSim10,AvgTime10,MinTime10,MaxTime10,AvgSpearmanSim20,AvgTime20,AvgSpearmanSim40,AvgTime40,Stemmer,Snapshot,PeakHeapInuse,PeakRSS
+1,tdIdf,true,false,1,0,false,1648,5200,0.61,0.20,0.90,310,120,900,0.65,400,0.70,650,none,0af39b03ea42,52428800,104857600
+2,bm25,true,false,1,0,false,1648,4800,0.66,0.25,0.92,280,100,850,0.69,380,0.74,610,none,0af39b03ea42,54525952,106954752
+3,bm25,true,false,2,1,true,1648,9100,0.58,0.10,0.88,520,200,1600,0.62,700,0.67,1200,rslp,0af39b03ea42,83886080,157286400
+`
+
+func TestWriteHTMLGolden(t *testing.T) {
+	rows, err := ParseResults(strings.NewReader(goldenCSV), "results.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	if err = WriteHTML(&buf, rows, []string{"results.csv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "report.golden.html")
+	if *update {
+		if err = os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("report differs from %s (run with -update after checking the change)", golden)
+	}
+}
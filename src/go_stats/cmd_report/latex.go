@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// latexEscape escapes the characters that have special meaning in LaTeX.
+func latexEscape(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`,
+		"_", `\_`, "{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+	)
+	return r.Replace(s)
+}
+
+// LatexSummaryTable returns a table with the average Spearman correlation and
+// average latency per configuration and phrase length, in the same layout
+// used by the tables in latex/main.tex.
+func LatexSummaryTable(rows []*ResultRow) string {
+	sb := &strings.Builder{}
+	sb.WriteString("\\begin{table}[H]\n")
+	sb.WriteString("    \\centering\n")
+	sb.WriteString("    \\caption{Correlação de Spearman média e tempo médio de busca por configuração}\n")
+	sb.WriteString("    \\label{tab:resultados_configuracoes}\n")
+	sb.WriteString("    \\begin{tabular}{l" + strings.Repeat("cc", len(PhraseLengths)) + "}\n")
+	sb.WriteString("    \\hline\n")
+	sb.WriteString("    \\textbf{Configuração}")
+	for _, n := range PhraseLengths {
+		fmt.Fprintf(sb, " & \\textbf{$\\rho$ (%d)} & \\textbf{µs (%d)}", n, n)
+	}
+	sb.WriteString(" \\\\\n    \\hline\n")
+	for _, r := range rows {
+		fmt.Fprintf(sb, "    %s", latexEscape(r.Label()))
+		for _, n := range PhraseLengths {
+			p := r.Phrases[n]
+			fmt.Fprintf(sb, " & %.4f & %.0f", p.AvgSpearman, p.AvgTime)
+		}
+		sb.WriteString(" \\\\\n")
+	}
+	sb.WriteString("    \\hline\n")
+	sb.WriteString("    \\end{tabular}\n")
+	sb.WriteString("\\end{table}\n")
+	return sb.String()
+}
+
+// LatexMemoryTable returns a table with the memory columns of each
+// configuration, or an empty string when the results carry no memory data.
+func LatexMemoryTable(rows []*ResultRow, columns []string) string {
+	var present []string
+	for _, col := range columns {
+		for _, r := range rows {
+			if _, ok := r.ExtraFloat(col); ok {
+				present = append(present, col)
+				break
+			}
+		}
+	}
+	if len(present) == 0 {
+		return ""
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("\\begin{table}[H]\n")
+	sb.WriteString("    \\centering\n")
+	sb.WriteString("    \\caption{Uso de memória por configuração (MB)}\n")
+	sb.WriteString("    \\label{tab:memoria_configuracoes}\n")
+	sb.WriteString("    \\begin{tabular}{l" + strings.Repeat("c", len(present)) + "}\n")
+	sb.WriteString("    \\hline\n")
+	sb.WriteString("    \\textbf{Configuração}")
+	for _, col := range present {
+		fmt.Fprintf(sb, " & \\textbf{%s}", latexEscape(col))
+	}
+	sb.WriteString(" \\\\\n    \\hline\n")
+	for _, r := range rows {
+		fmt.Fprintf(sb, "    %s", latexEscape(r.Label()))
+		for _, col := range present {
+			v, _ := r.ExtraFloat(col)
+			fmt.Fprintf(sb, " & %.2f", toMB(v))
+		}
+		sb.WriteString(" \\\\\n")
+	}
+	sb.WriteString("    \\hline\n")
+	sb.WriteString("    \\end{tabular}\n")
+	sb.WriteString("\\end{table}\n")
+	return sb.String()
+}
+
+// LatexCorrelationPlot returns a pgfplots figure of the correlation against
+// the jump size. It requires \usepackage{pgfplots} in the preamble.
+func LatexCorrelationPlot(rows []*ResultRow, phraseLen int) string {
+	series, jumps := CorrelationSeries(rows, phraseLen)
+
+	sb := &strings.Builder{}
+	sb.WriteString("% Requer \\usepackage{pgfplots} no preâmbulo.\n")
+	sb.WriteString("\\begin{figure}[H]\n")
+	sb.WriteString("    \\centering\n")
+	fmt.Fprintf(sb, "    \\caption{Correlação de Spearman média por salto (frases de %d palavras)}\n", phraseLen)
+	fmt.Fprintf(sb, "    \\label{fig:correlacao_saltos_%d}\n", phraseLen)
+	sb.WriteString("    \\begin{tikzpicture}\n")
+	sb.WriteString("    \\begin{axis}[xlabel={Salto}, ylabel={$\\rho$ médio}, legend pos=outer north east, xtick={")
+	for i, j := range jumps {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(sb, "%d", j)
+	}
+	sb.WriteString("}]\n")
+	for _, s := range series {
+		sb.WriteString("        \\addplot coordinates {")
+		for _, pt := range s.Points {
+			fmt.Fprintf(sb, " (%.0f,%.4f)", pt.X, pt.Y)
+		}
+		sb.WriteString(" };\n")
+		fmt.Fprintf(sb, "        \\addlegendentry{%s}\n", latexEscape(s.Name))
+	}
+	sb.WriteString("    \\end{axis}\n")
+	sb.WriteString("    \\end{tikzpicture}\n")
+	sb.WriteString("    \\legend{Fonte: Elaboração própria.}\n")
+	sb.WriteString("\\end{figure}\n")
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// DefaultInput is the results file written by cmd_stats.
+const DefaultInput = "./../../misc/resultsT.csv"
+
+// DefaultOutput is the directory where the report files are written.
+const DefaultOutput = "./../../misc/report"
+
+// MemoryColumns are the optional result columns (in bytes) plotted as memory usage.
+var MemoryColumns = []string{"PeakHeapInuse", "PeakRSS"}
+
+// Converte bytes para MegaBytes (MB)
+func toMB(b float64) float64 {
+	return b / 1024 / 1024
+}
+
+// main reads one or more results CSVs and writes an HTML report plus LaTeX
+// snippets (tables and pgfplots figures) ready to be \input in latex/main.tex.
+func main() {
+	in := flag.String("in", DefaultInput, "comma separated list of results CSV files")
+	out := flag.String("out", DefaultOutput, "output directory")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var paths []string
+	for _, p := range strings.Split(*in, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		utils.Fatal("no input files")
+	}
+
+	rows, err := LoadResults(paths)
+	if err != nil {
+		utils.Fatal("error loading results", "err", err)
+	}
+	if len(rows) == 0 {
+		utils.Fatal("no results found in input files", "files", paths)
+	}
+
+	if snaps := Snapshots(rows); len(snaps) > 1 {
+		slog.Warn("results come from different corpus snapshots", "snapshots", snaps)
+	}
+
+	if err = os.MkdirAll(*out, 0755); err != nil {
+		utils.Fatal("error creating output directory", "dir", *out, "err", err)
+	}
+
+	sources := make([]string, len(paths))
+	for i, p := range paths {
+		sources[i] = filepath.Base(p)
+	}
+
+	htmlPath := filepath.Join(*out, "report.html")
+	f, err := os.Create(htmlPath)
+	if err != nil {
+		utils.Fatal("error creating report", "file", htmlPath, "err", err)
+	}
+	if err = WriteHTML(f, rows, sources); err != nil {
+		_ = f.Close()
+		utils.Fatal("error writing report", "file", htmlPath, "err", err)
+	}
+	if err = f.Close(); err != nil {
+		utils.Fatal("error closing report", "file", htmlPath, "err", err)
+	}
+
+	files := map[string]string{
+		"tabela_resultados.tex": LatexSummaryTable(rows),
+	}
+	if mem := LatexMemoryTable(rows, MemoryColumns); mem != "" {
+		files["tabela_memoria.tex"] = mem
+	}
+	for _, n := range PhraseLengths {
+		files[fmt.Sprintf("grafico_correlacao_%d.tex", n)] = LatexCorrelationPlot(rows, n)
+	}
+	for name, content := range files {
+		if err = os.WriteFile(filepath.Join(*out, name), []byte(content), 0644); err != nil {
+			utils.Fatal("error writing LaTeX snippet", "file", name, "err", err)
+		}
+	}
+
+	slog.Info("relatório gerado", "dir", *out, "configs", len(rows), "latex_files", len(files))
+}
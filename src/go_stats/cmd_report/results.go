@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PhraseLengths lists the query sizes (in words) measured by cmd_stats.
+var PhraseLengths = []int{10, 20, 40}
+
+type (
+	// PhraseStats holds the Spearman and latency statistics for one phrase length.
+	PhraseStats struct {
+		AvgSpearman float64
+		MinSpearman float64
+		MaxSpearman float64
+		AvgTime     float64 // microseconds
+		MinTime     float64 // microseconds
+		MaxTime     float64 // microseconds
+	}
+
+	// ResultRow is one line of a results CSV produced by cmd_stats.
+	ResultRow struct {
+		Source         string
+		TestID         int
+		Algorithm      string
+		PreIndexed     bool
+		NormalizeJumps bool
+		GramsSize      int
+		JumpsSize      int
+		Parallel       bool
+		TotalDocs      int
+		TotalTime      float64 // milliseconds
+		Phrases        map[int]PhraseStats
+
+		// Extra keeps every column that is not part of the fixed layout above
+		// (e.g. memory columns), indexed by header name.
+		Extra map[string]string
+	}
+)
+
+// Label returns a short human readable identifier for the configuration of the row.
+func (r *ResultRow) Label() string {
+	var flags []string
+	if r.NormalizeJumps {
+		flags = append(flags, "norm")
+	}
+	if r.Parallel {
+		flags = append(flags, "par")
+	}
+	if r.PreIndexed {
+		flags = append(flags, "pre")
+	}
+	label := fmt.Sprintf("%s n%d j%d", r.Algorithm, r.GramsSize, r.JumpsSize)
+	if stemmer := r.Extra["Stemmer"]; stemmer != "" && stemmer != "none" {
+		label += " " + stemmer
+	}
+	if len(flags) > 0 {
+		label += " " + strings.Join(flags, "+")
+	}
+	return label
+}
+
+// Snapshots returns the distinct corpus snapshot IDs the rows ran against, sorted.
+// Rows written before snapshots were recorded are ignored.
+func Snapshots(rows []*ResultRow) []string {
+	seen := map[string]bool{}
+	var ret []string
+	for _, r := range rows {
+		if id := r.Extra["Snapshot"]; id != "" && !seen[id] {
+			seen[id] = true
+			ret = append(ret, id)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ExtraFloat returns the numeric value of an extra column, or false when the
+// column is missing or not numeric.
+func (r *ResultRow) ExtraFloat(column string) (float64, bool) {
+	raw, ok := r.Extra[column]
+	if !ok || raw == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// LoadResults reads and concatenates every CSV in paths.
+func LoadResults(paths []string) ([]*ResultRow, error) {
+	var ret []*ResultRow
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s: %v", path, err)
+		}
+		rows, err := ParseResults(f, filepath.Base(path))
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		ret = append(ret, rows...)
+	}
+	return ret, nil
+}
+
+// ParseResults parses a results CSV. Columns are matched by header name, so
+// files with additional or reordered columns are accepted.
+func ParseResults(r io.Reader, source string) ([]*ResultRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %v", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.TrimSpace(h)] = i
+	}
+
+	known := map[string]bool{
+		"TestID": true, "Algorithm": true, "Pre-Indexed": true, "Normalized jumps": true,
+		"Grams size": true, "Jumps size": true, "Parallel": true, "TotalDocs": true, "TotalTime": true,
+	}
+	for _, n := range PhraseLengths {
+		for _, col := range phraseColumns(n) {
+			known[col] = true
+		}
+	}
+
+	var ret []*ResultRow
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+
+		get := func(col string) string {
+			i, ok := index[col]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := &ResultRow{
+			Source:         source,
+			TestID:         atoi(get("TestID")),
+			Algorithm:      get("Algorithm"),
+			PreIndexed:     get("Pre-Indexed") == "true",
+			NormalizeJumps: get("Normalized jumps") == "true",
+			GramsSize:      atoi(get("Grams size")),
+			JumpsSize:      atoi(get("Jumps size")),
+			Parallel:       get("Parallel") == "true",
+			TotalDocs:      atoi(get("TotalDocs")),
+			TotalTime:      atof(get("TotalTime")),
+			Phrases:        make(map[int]PhraseStats, len(PhraseLengths)),
+			Extra:          make(map[string]string),
+		}
+		for _, n := range PhraseLengths {
+			cols := phraseColumns(n)
+			row.Phrases[n] = PhraseStats{
+				AvgSpearman: atof(get(cols[0])),
+				MinSpearman: atof(get(cols[1])),
+				MaxSpearman: atof(get(cols[2])),
+				AvgTime:     atof(get(cols[3])),
+				MinTime:     atof(get(cols[4])),
+				MaxTime:     atof(get(cols[5])),
+			}
+		}
+		for col, i := range index {
+			if !known[col] && i < len(record) {
+				row.Extra[col] = strings.TrimSpace(record[i])
+			}
+		}
+		ret = append(ret, row)
+	}
+	return ret, nil
+}
+
+func phraseColumns(n int) []string {
+	return []string{
+		fmt.Sprintf("AvgSpearmanSim%d", n),
+		fmt.Sprintf("MinSpearmanSim%d", n),
+		fmt.Sprintf("MaxSpearmanSim%d", n),
+		fmt.Sprintf("AvgTime%d", n),
+		fmt.Sprintf("MinTime%d", n),
+		fmt.Sprintf("MaxTime%d", n),
+	}
+}
+
+// CorrelationSeries groups rows by algorithm, n-gram size, stemmer and jump
+// normalization and returns, for each group, the average Spearman correlation per
+// jump size for a phrase length. Rows that only differ by parallelism (which does
+// not change the ranking) are averaged together.
+func CorrelationSeries(rows []*ResultRow, phraseLen int) ([]Series, []int) {
+	type key struct {
+		algo      string
+		size      int
+		stemmer   string
+		normalize bool
+	}
+	sums := make(map[key]map[int]float64)
+	counts := make(map[key]map[int]int)
+	jumpSet := make(map[int]bool)
+
+	for _, r := range rows {
+		stemmer := r.Extra["Stemmer"]
+		if stemmer == "none" {
+			stemmer = ""
+		}
+		k := key{r.Algorithm, r.GramsSize, stemmer, r.NormalizeJumps}
+		if sums[k] == nil {
+			sums[k] = make(map[int]float64)
+			counts[k] = make(map[int]int)
+		}
+		sums[k][r.JumpsSize] += r.Phrases[phraseLen].AvgSpearman
+		counts[k][r.JumpsSize]++
+		jumpSet[r.JumpsSize] = true
+	}
+
+	jumps := make([]int, 0, len(jumpSet))
+	for j := range jumpSet {
+		jumps = append(jumps, j)
+	}
+	sort.Ints(jumps)
+
+	keys := make([]key, 0, len(sums))
+	for k := range sums {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case a.algo != b.algo:
+			return a.algo < b.algo
+		case a.size != b.size:
+			return a.size < b.size
+		case a.stemmer != b.stemmer:
+			return a.stemmer < b.stemmer
+		default:
+			return !a.normalize && b.normalize
+		}
+	})
+
+	var ret []Series
+	for _, k := range keys {
+		s := Series{Name: fmt.Sprintf("%s n=%d", k.algo, k.size)}
+		if k.stemmer != "" {
+			s.Name += " " + k.stemmer
+		}
+		if k.normalize {
+			s.Name += " norm"
+		}
+		for _, j := range jumps {
+			c := counts[k][j]
+			if c == 0 {
+				continue
+			}
+			s.Points = append(s.Points, Point{X: float64(j), Y: sums[k][j] / float64(c)})
+		}
+		ret = append(ret, s)
+	}
+	return ret, jumps
+}
+
+func atoi(s string) int {
+	v, _ := strconv.Atoi(s)
+	return v
+}
+
+func atof(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseResults(t *testing.T) {
+	csv := `Algorithm, TestID, Grams size, Jumps size, Parallel, TotalTime, AvgSpearmanSim10, AvgTime10, Stemmer, PeakRSS
+
+bm25, 2, 2, 1, true, 1500.5, 0.75, 120, rslp, 1048576
+   
+tdIdf, 3, 1, 0, false, 900, 0.5, 80
+`
+	rows, err := ParseResults(strings.NewReader(csv), "r.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows (blank lines skipped), got %d", len(rows))
+	}
+
+	r := rows[0]
+	if r.Source != "r.csv" || r.TestID != 2 || r.Algorithm != "bm25" || r.GramsSize != 2 || r.JumpsSize != 1 || !r.Parallel || r.TotalTime != 1500.5 {
+		t.Errorf("unexpected row %+v", r)
+	}
+	if p := r.Phrases[10]; p.AvgSpearman != 0.75 || p.AvgTime != 120 {
+		t.Errorf("unexpected phrase stats %+v", p)
+	}
+	if r.Extra["Stemmer"] != "rslp" || len(r.Extra) != 2 {
+		t.Errorf("unexpected extra columns %v", r.Extra)
+	}
+	if v, ok := r.ExtraFloat("PeakRSS"); !ok || v != 1048576 {
+		t.Errorf("PeakRSS = %v, %v", v, ok)
+	}
+	if r.Label() != "bm25 n2 j1 rslp par" {
+		t.Errorf("Label = %q", r.Label())
+	}
+
+	// Rows shorter than the header leave the missing columns out
+	if _, ok := rows[1].ExtraFloat("PeakRSS"); ok || rows[1].Extra["Stemmer"] != "" {
+		t.Errorf("unexpected extra columns in short row %v", rows[1].Extra)
+	}
+
+	if _, err = ParseResults(strings.NewReader(""), "empty.csv"); err == nil {
+		t.Error("expected error for missing header")
+	}
+}
+
+func TestBarChartBaseline(t *testing.T) {
+	svg := BarChart("mem", "MB", []Bar{{"a", 100}, {"b", 101}})
+	var heights []float64
+	for _, m := range regexp.MustCompile(`<rect x="[^"]+" y="[^"]+" width="[^"]+" height="([^"]+)"`).FindAllStringSubmatch(svg, -1) {
+		h, _ := strconv.ParseFloat(m[1], 64)
+		heights = append(heights, h)
+	}
+	if len(heights) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(heights))
+	}
+	// Bars start at zero, so their heights keep the ratio between the values
+	if ratio := heights[0] / heights[1]; ratio < 0.98 || ratio > 1 {
+		t.Errorf("bar heights %v not proportional to values", heights)
+	}
+}
+
+func TestRangeChartAverageOnly(t *testing.T) {
+	// Without min/max columns only the average is drawn, inside the plot area
+	svg := RangeChart("lat", "µs", []Range{{Label: "a", Avg: 400}, {Label: "b", Min: 100, Avg: 300, Max: 900}})
+	circles := regexp.MustCompile(`<circle cx="[^"]+" cy="([^"]+)"`).FindAllStringSubmatch(svg, -1)
+	if len(circles) != 2 {
+		t.Fatalf("expected 2 averages, got %d", len(circles))
+	}
+	for _, m := range circles {
+		if y, _ := strconv.ParseFloat(m[1], 64); y < 0 || y > chartHeight {
+			t.Errorf("average drawn outside the chart at y=%v", y)
+		}
+	}
+	if strings.Count(svg, "<title>a: avg 400</title>") != 1 || strings.Contains(svg, "a: min") {
+		t.Errorf("unexpected whiskers in %s", svg)
+	}
+}
+
+func TestCorrelationSeries(t *testing.T) {
+	csv := `Algorithm,Grams size,Jumps size,Normalized jumps,Parallel,AvgSpearmanSim10,Stemmer
+bm25,1,0,false,false,0.5,none
+bm25,1,0,false,true,0.7,none
+bm25,1,0,false,false,0.9,rslp
+bm25,1,0,true,false,0.3,none
+bm25,1,1,false,false,0.4,
+`
+	rows, err := ParseResults(strings.NewReader(csv), "r.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, jumps := CorrelationSeries(rows, 10)
+	if len(jumps) != 2 || len(series) != 3 {
+		t.Fatalf("unexpected series %+v (jumps %v)", series, jumps)
+	}
+	want := map[string][]Point{
+		"bm25 n=1":      {{0, 0.6}, {1, 0.4}},
+		"bm25 n=1 rslp": {{0, 0.9}},
+		"bm25 n=1 norm": {{0, 0.3}},
+	}
+	for _, s := range series {
+		w, ok := want[s.Name]
+		if !ok || len(s.Points) != len(w) {
+			t.Errorf("unexpected series %+v", s)
+			continue
+		}
+		for i, p := range s.Points {
+			if p.X != w[i].X || p.Y < w[i].Y-1e-9 || p.Y > w[i].Y+1e-9 {
+				t.Errorf("%s: point %d = %v, want %v", s.Name, i, p, w[i])
+			}
+		}
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	rows := []*ResultRow{
+		{Extra: map[string]string{"Snapshot": "bbbbbbbbbbbb"}},
+		{Extra: map[string]string{"Snapshot": "aaaaaaaaaaaa"}},
+		{Extra: map[string]string{"Snapshot": ""}},
+		{},
+		{Extra: map[string]string{"Snapshot": "bbbbbbbbbbbb"}},
+	}
+	got := Snapshots(rows)
+	if len(got) != 2 || got[0] != "aaaaaaaaaaaa" || got[1] != "bbbbbbbbbbbb" {
+		t.Errorf("expected sorted unique snapshot IDs, got %v", got)
+	}
+	if got := Snapshots(nil); len(got) != 0 {
+		t.Errorf("expected no snapshots, got %v", got)
+	}
+}
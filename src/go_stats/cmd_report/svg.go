@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Palette used by every chart, in series order.
+var palette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+const (
+	chartWidth  = 720
+	chartHeight = 360
+	marginLeft  = 70
+	marginRight = 170
+	marginTop   = 30
+	marginBot   = 50
+)
+
+type (
+	Point struct {
+		X, Y float64
+	}
+
+	Series struct {
+		Name   string
+		Points []Point
+	}
+
+	// Range is a min/avg/max triple shown as a whisker in RangeChart. Min and Max are both
+	// zero when the results file has no min/max columns.
+	Range struct {
+		Label         string
+		Min, Avg, Max float64
+	}
+
+	// Bar is a single labelled value shown in BarChart.
+	Bar struct {
+		Label string
+		Value float64
+	}
+)
+
+type plotArea struct {
+	minY, maxY float64
+	w, h       float64
+}
+
+// newPlotArea pads the y range by 5% on each side, except that a zero minimum is kept as
+// the baseline so bars and ranges starting at zero are drawn to scale.
+func newPlotArea(minY, maxY float64) plotArea {
+	switch {
+	case minY == 0 && maxY == 0:
+		maxY = 1
+	case minY == maxY:
+		minY, maxY = minY-1, maxY+1
+	}
+	pad := (maxY - minY) * 0.05
+	if minY != 0 {
+		minY -= pad
+	}
+	return plotArea{
+		minY: minY,
+		maxY: maxY + pad,
+		w:    chartWidth - marginLeft - marginRight,
+		h:    chartHeight - marginTop - marginBot,
+	}
+}
+
+func (p plotArea) y(v float64) float64 {
+	return marginTop + p.h - (v-p.minY)/(p.maxY-p.minY)*p.h
+}
+
+func (p plotArea) axes(sb *strings.Builder, title, yLabel string) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(sb, `<text x="%d" y="18" font-size="14" font-weight="bold">%s</text>`, marginLeft, html.EscapeString(title))
+	fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%.1f" stroke="#333"/>`, marginLeft, marginTop, marginLeft, marginTop+p.h)
+	fmt.Fprintf(sb, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"/>`, marginLeft, marginTop+p.h, marginLeft+p.w, marginTop+p.h)
+	for i := 0; i <= 5; i++ {
+		v := p.minY + (p.maxY-p.minY)*float64(i)/5
+		y := p.y(v)
+		fmt.Fprintf(sb, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, marginLeft, y, marginLeft+p.w, y)
+		fmt.Fprintf(sb, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, marginLeft-6, y+4, formatTick(v))
+	}
+	fmt.Fprintf(sb, `<text transform="translate(16 %.1f) rotate(-90)" text-anchor="middle">%s</text>`,
+		marginTop+p.h/2, html.EscapeString(yLabel))
+}
+
+func legend(sb *strings.Builder, names []string) {
+	x := chartWidth - marginRight + 15
+	for i, name := range names {
+		y := marginTop + 10 + i*16
+		fmt.Fprintf(sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, x, y-9, palette[i%len(palette)])
+		fmt.Fprintf(sb, `<text x="%d" y="%d">%s</text>`, x+15, y, html.EscapeString(name))
+	}
+}
+
+// LineChart renders series over discrete x values as an inline SVG.
+func LineChart(title, xLabel, yLabel string, series []Series, xs []int) string {
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	for _, s := range series {
+		for _, pt := range s.Points {
+			minY = math.Min(minY, pt.Y)
+			maxY = math.Max(maxY, pt.Y)
+		}
+	}
+	if len(series) == 0 || minY > maxY {
+		return emptyChart(title)
+	}
+
+	p := newPlotArea(minY, maxY)
+	sb := &strings.Builder{}
+	p.axes(sb, title, yLabel)
+
+	xPos := make(map[float64]float64, len(xs))
+	for i, x := range xs {
+		pos := marginLeft + p.w*(float64(i)+0.5)/float64(len(xs))
+		xPos[float64(x)] = pos
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle">%d</text>`, pos, marginTop+p.h+16, x)
+	}
+	fmt.Fprintf(sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, marginLeft+p.w/2, chartHeight-10, html.EscapeString(xLabel))
+
+	names := make([]string, 0, len(series))
+	for i, s := range series {
+		color := palette[i%len(palette)]
+		var path []string
+		for _, pt := range s.Points {
+			path = append(path, fmt.Sprintf("%.1f,%.1f", xPos[pt.X], p.y(pt.Y)))
+			fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %.4f</title></circle>`,
+				xPos[pt.X], p.y(pt.Y), color, html.EscapeString(s.Name), pt.Y)
+		}
+		fmt.Fprintf(sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(path, " "), color)
+		names = append(names, s.Name)
+	}
+	legend(sb, names)
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+// RangeChart renders one min/avg/max whisker per entry as an inline SVG. Entries without
+// min/max (see Range) are drawn as the average alone.
+func RangeChart(title, yLabel string, ranges []Range) string {
+	if len(ranges) == 0 {
+		return emptyChart(title)
+	}
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	for _, r := range ranges {
+		minY = math.Min(minY, r.Avg)
+		maxY = math.Max(maxY, r.Avg)
+		if r.hasMinMax() {
+			minY = math.Min(minY, r.Min)
+			maxY = math.Max(maxY, r.Max)
+		}
+	}
+
+	p := newPlotArea(math.Min(0, minY), maxY)
+	sb := &strings.Builder{}
+	p.axes(sb, title, yLabel)
+
+	step := p.w / float64(len(ranges))
+	for i, r := range ranges {
+		x := marginLeft + step*(float64(i)+0.5)
+		color := palette[0]
+		if r.hasMinMax() {
+			fmt.Fprintf(sb, `<g><title>%s: min %.0f / avg %.0f / max %.0f</title>`, html.EscapeString(r.Label), r.Min, r.Avg, r.Max)
+			fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`, x, p.y(r.Min), x, p.y(r.Max), color)
+			fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`, x-4, p.y(r.Min), x+4, p.y(r.Min), color)
+			fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`, x-4, p.y(r.Max), x+4, p.y(r.Max), color)
+		} else {
+			fmt.Fprintf(sb, `<g><title>%s: avg %.0f</title>`, html.EscapeString(r.Label), r.Avg)
+		}
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/></g>`, x, p.y(r.Avg), palette[1])
+		if len(ranges) <= 40 {
+			fmt.Fprintf(sb, `<text transform="translate(%.1f %.1f) rotate(45)" font-size="9">%s</text>`,
+				x, marginTop+p.h+8, html.EscapeString(r.Label))
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+// hasMinMax reports whether the entry has min/max values to draw as a whisker.
+func (r Range) hasMinMax() bool {
+	return r.Min != 0 || r.Max != 0
+}
+
+// BarChart renders one bar per entry as an inline SVG.
+func BarChart(title, yLabel string, bars []Bar) string {
+	if len(bars) == 0 {
+		return emptyChart(title)
+	}
+	maxY := 0.0
+	for _, b := range bars {
+		maxY = math.Max(maxY, b.Value)
+	}
+
+	p := newPlotArea(0, maxY)
+	sb := &strings.Builder{}
+	p.axes(sb, title, yLabel)
+
+	step := p.w / float64(len(bars))
+	for i, b := range bars {
+		x := marginLeft + step*float64(i)
+		y := p.y(b.Value)
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %s</title></rect>`,
+			x+step*0.15, y, step*0.7, p.y(p.minY)-y, palette[0], html.EscapeString(b.Label), formatTick(b.Value))
+		if len(bars) <= 40 {
+			fmt.Fprintf(sb, `<text transform="translate(%.1f %.1f) rotate(45)" font-size="9">%s</text>`,
+				x+step/2, marginTop+p.h+8, html.EscapeString(b.Label))
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+func emptyChart(title string) string {
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="60" font-family="sans-serif">`+
+		`<text x="10" y="20" font-size="14" font-weight="bold">%s</text>`+
+		`<text x="10" y="45" font-size="12" fill="#888">sem dados</text></svg>`, chartWidth, html.EscapeString(title))
+}
+
+func formatTick(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1000:
+		return fmt.Sprintf("%.0f", v)
+	case abs >= 10:
+		return fmt.Sprintf("%.1f", v)
+	default:
+		return fmt.Sprintf("%.3f", v)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tcc2-davi-arthur/corpus"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// main downloads proposals from the legislative portals into misc/corpus, resuming any
+// previous crawl (see corpus.StartScrapping).
+//
+//	go run ./cmd_scrape -source senado -q educação -types PL,PEC -years 2019..2023 -max-docs 200
+func main() {
+	sources := flag.String("source", corpus.DefaultSource, "comma-separated sources: "+strings.Join(corpus.SourceNames(), ", "))
+	keyword := flag.String("q", "", "keyword searched by the source (empty = everything)")
+	types := flag.String("types", "", "comma-separated proposal types, e.g. PL,PEC (empty = source defaults)")
+	years := flag.String("years", "", "submission year or closed range, e.g. 2019 or 2015..2019")
+	theme := flag.String("theme", "", "keep proposals with a theme containing this text")
+	author := flag.String("author", "", "keep proposals with an author containing this text")
+	maxPages := flag.Int("max-pages", 5000, "stop when the source's PDFs add up to this many pages (0 = no limit)")
+	maxDocs := flag.Int("max-docs", 0, "stop at this many documents per source (0 = no limit)")
+	maxBytes := flag.Int64("max-bytes", 0, "stop when the source's PDFs add up to this many bytes (0 = no limit)")
+	workers := flag.Int("workers", 25, "concurrent downloads")
+	delay := flag.Duration("delay", 500*time.Millisecond, "minimum interval between requests to the same host")
+	root := flag.String("root", "./../..", "repository root; files are written under <root>/misc/corpus")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	opts := corpus.ScrapeOptions{
+		Query: corpus.ScrapeQuery{
+			Keyword: strings.TrimSpace(*keyword),
+			Theme:   strings.TrimSpace(*theme),
+			Author:  strings.TrimSpace(*author),
+		},
+		MaxPages: *maxPages,
+		MaxDocs:  *maxDocs,
+		MaxBytes: *maxBytes,
+		Workers:  *workers,
+		Delay:    *delay,
+	}
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			opts.Query.Types = append(opts.Query.Types, strings.ToUpper(t))
+		}
+	}
+	var err error
+	if opts.Query.FromYear, opts.Query.ToYear, err = parseYears(*years); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var srcs []corpus.Source
+	for _, name := range strings.Split(*sources, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		src, err := corpus.NewSource(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		srcs = append(srcs, src)
+	}
+
+	// The scraper paths are relative to the repository root.
+	if err := os.Chdir(*root); err != nil {
+		utils.Fatal("invalid repository root", "root", *root, "err", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, src := range srcs {
+		if err := corpus.StartScrapping(ctx, src, opts); err != nil {
+			utils.Fatal("scraping failed", "source", src.Name(), "err", err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+}
+
+// parseYears parses "2019" or "2015..2019" (either side may be empty); "" means no limit.
+func parseYears(s string) (int, int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	lo, hi, isRange := strings.Cut(s, "..")
+	if !isRange {
+		hi = lo
+	}
+	var from, to int
+	var err error
+	if lo != "" {
+		if from, err = strconv.Atoi(lo); err != nil {
+			return 0, 0, fmt.Errorf("invalid -years %q", s)
+		}
+	}
+	if hi != "" {
+		if to, err = strconv.Atoi(hi); err != nil {
+			return 0, 0, fmt.Errorf("invalid -years %q", s)
+		}
+	}
+	if from != 0 && to != 0 && from > to {
+		return 0, 0, fmt.Errorf("invalid -years %q: start after end", s)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestParseYears(t *testing.T) {
+	for _, c := range []struct {
+		in       string
+		from, to int
+	}{
+		{"", 0, 0},
+		{" 2019 ", 2019, 2019},
+		{"2015..2019", 2015, 2019},
+		{"..2010", 0, 2010},
+		{"2020..", 2020, 0},
+		{"2019..2019", 2019, 2019},
+	} {
+		from, to, err := parseYears(c.in)
+		if err != nil || from != c.from || to != c.to {
+			t.Errorf("parseYears(%q) = %d, %d, %v; want %d, %d", c.in, from, to, err, c.from, c.to)
+		}
+	}
+	for _, in := range []string{"abc", "2019..x", "2020..2015", "2019-2020"} {
+		if _, _, err := parseYears(in); err == nil {
+			t.Errorf("parseYears(%q): expected error", in)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tcc2-davi-arthur/corpus"
+	"github.com/tcc2-davi-arthur/models/support"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// main builds (or reuses) the index for the requested configuration and runs a single query.
+//
+//	go run ./cmd_search -q '"licitação pública" servidor NEAR/5 estabilidade' -algo bm25 -n 1
+//	go run ./cmd_search -like doc_0042_clean.txt -k 5
+func main() {
+	q := flag.String("q", "", "query: words, \"exact phrases\", a NEAR/k b, AND/OR/NOT, (groups), +required/-excluded terms and field filters (kind:pdf, fonte:senado, tipo:PEC, ano:2015..2019, numero:45, autor:joao_silva, tema:educacao, status:arquivada, apresentacao:2019-01-01..2019-06-30)")
+	algo := flag.String("algo", string(support.Bm25), "ranking algorithm: tdIdf or bm25")
+	size := flag.Int("n", 1, "n-gram size (1 to 3)")
+	jumps := flag.Int("j", 0, "maximum jumps between n-gram words")
+	normalize := flag.Bool("normalize-jumps", false, "ignore jump distance when comparing n-grams")
+	limit := flag.Int("k", 10, "maximum number of hits (0 = all)")
+	facets := flag.String("facets", strings.Join(corpus.DefaultFacets, ","), "comma-separated facets counted over all matches (kind, fonte, tipo, ano, autor, tema, status; empty = none)")
+	facetLimit := flag.Int("facet-limit", 10, "maximum values printed per facet (0 = all)")
+	snippets := flag.Int("snippets", 2, "highlighted passages printed per hit (0 = none)")
+	window := flag.Int("snippet-size", 25, "passage length in tokens")
+	like := flag.String("like", "", "document name: list the documents most similar to it instead of running -q (near-duplicates recorded by cmd_dedup or detected by -collapse excluded)")
+	embeddings := flag.String("embeddings", "", "with -like, compare the document embeddings in this file (written by cmd_embed) instead of the -algo vectors")
+	collapse := flag.String("collapse", "none", "near-duplicate handling: none, query (group duplicates under the best hit) or index (drop duplicates from the index)")
+	stemmer := flag.String("stemmer", "none", "stemmer: none, rslp, lemma or lemma+rslp")
+	logLevel := flag.String("log-level", "warn", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *q == "" && *like == "" {
+		fmt.Fprintln(os.Stderr, "missing -q or -like")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *collapse != "none" && *collapse != "query" && *collapse != "index" {
+		fmt.Fprintf(os.Stderr, "invalid -collapse %q\n", *collapse)
+		os.Exit(2)
+	}
+	if *embeddings != "" && *like == "" {
+		fmt.Fprintln(os.Stderr, "-embeddings requires -like")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := corpus.DefaultAnalyzerConfig().WithStemmer(*stemmer)
+	if err != nil {
+		utils.Fatal("invalid analyzer", "err", err)
+	}
+
+	dbName, db, err := corpus.CreateDatabaseCaches(ctx, time.Now().UnixNano(), false, *size, *jumps, cfg)
+	if dbName != "" {
+		defer os.Remove(dbName)
+	}
+	if err != nil {
+		utils.Fatal("failed to load index", "err", err)
+	}
+	defer func() {
+		if sqlDB, e := db.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+
+	if *collapse != "none" {
+		clusters, err := corpus.FindDuplicates(ctx, corpus.DefaultDedupOptions())
+		if err != nil {
+			utils.Fatal("near-duplicate detection failed", "err", err)
+		}
+		if err = corpus.MarkDuplicates(db, clusters); err != nil {
+			utils.Fatal("failed to record near-duplicates", "err", err)
+		}
+		slog.Info("near-duplicates detected", "clusters", len(clusters))
+	}
+	if *collapse == "index" {
+		n, err := corpus.CollapseDuplicates(db)
+		if err != nil {
+			utils.Fatal("failed to collapse near-duplicates", "err", err)
+		}
+		slog.Info("near-duplicates removed from the index", "docs", n)
+	}
+
+	if *like != "" {
+		similar(ctx, *like, *embeddings, *limit, corpus.SimilarOptions{Algo: support.Algo(*algo), NormalizeJumps: *normalize})
+		return
+	}
+
+	facetNames := []string{}
+	for _, f := range strings.Split(*facets, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			facetNames = append(facetNames, f)
+		}
+	}
+
+	res, err := corpus.SearchFaceted(ctx, *q, corpus.SearchOptions{
+		Algo:           support.NewAlgo(*algo),
+		GramsSize:      *size,
+		Jumps:          *jumps,
+		NormalizeJumps: *normalize,
+		Limit:          *limit,
+		Facets:         facetNames,
+		FacetLimit:     *facetLimit,
+
+		CollapseDuplicates: *collapse == "query",
+	})
+	if err != nil {
+		utils.Fatal("search failed", "query", *q, "err", err)
+	}
+
+	for i, h := range res.Hits {
+		fmt.Printf("%3d  %.4f  %s", i+1, h.Score, h.Doc.Name)
+		if len(h.Duplicates) > 0 {
+			fmt.Printf("  (+%d near-duplicates)", len(h.Duplicates))
+		}
+		fmt.Println()
+		if *snippets <= 0 {
+			continue
+		}
+		passages, err := corpus.Snippets(db, h.Doc, *q, corpus.HighlightOptions{Fragments: *snippets, Window: *window})
+		if err != nil {
+			slog.Warn("no snippets", "doc", h.Doc.Name, "err", err)
+			continue
+		}
+		for _, p := range passages {
+			fmt.Printf("       p.%d  %s\n", p.Page, p.Text)
+		}
+	}
+	if res.Total == 0 {
+		fmt.Println("no documents found")
+		return
+	}
+	fmt.Printf("\n%d documents matched\n", res.Total)
+	printFacets(res.Facets)
+}
+
+// similar prints the k documents closest to the named document.
+func similar(ctx context.Context, name, embeddingsFile string, k int, opts corpus.SimilarOptions) {
+	doc, ok := corpus.CacheDocs[name]
+	if !ok {
+		utils.Fatal("document not indexed", "doc", name)
+	}
+	if embeddingsFile != "" {
+		vecs, err := corpus.LoadEmbeddings(embeddingsFile)
+		if err != nil {
+			utils.Fatal("failed to load embeddings", "file", embeddingsFile, "err", err)
+		}
+		opts.Embeddings = vecs
+	}
+
+	hits, err := corpus.Similar(ctx, doc.ID, k, opts)
+	if err != nil {
+		utils.Fatal("similarity search failed", "doc", name, "err", err)
+	}
+	for i, h := range hits {
+		fmt.Printf("%3d  %.4f  %s\n", i+1, h.Score, h.Doc.Name)
+	}
+	if len(hits) == 0 {
+		fmt.Println("no similar documents found")
+	}
+}
+
+// printFacets prints each facet's counts, with the values written as "name:value" filters
+// (see corpus.FilterValue) so they can be pasted back into the query.
+func printFacets(facets []corpus.Facet) {
+	for _, f := range facets {
+		fmt.Printf("\n%s:\n", f.Name)
+		for _, v := range f.Values {
+			fmt.Printf("  %5d  %s\n", v.Count, corpus.FilterValue(v.Value))
+		}
+		if f.Missing > 0 {
+			fmt.Printf("  %5d  (no value)\n", f.Missing)
+		}
+	}
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	mgu "github.com/artking28/myGoUtils"
+	"github.com/tcc2-davi-arthur/analysis"
 	"github.com/tcc2-davi-arthur/corpus"
+	"github.com/tcc2-davi-arthur/models"
 	"github.com/tcc2-davi-arthur/models/support"
 	"github.com/tcc2-davi-arthur/utils"
 	"gorm.io/gorm"
@@ -31,8 +39,23 @@ const (
 
 var csvHeader string
 
+// profileOpts configures the memory profiling (and optional pprof output) of each test.
+var profileOpts utils.ProfileOptions
+
+// queryTimeout limits the time spent on a single search phrase (0 disables the limit).
+var queryTimeout time.Duration
+
+// stemmers are the morphological normalisations benchmarked (see analysis.Stemmers).
+var stemmers []string
+
+// stopwordFiles are extra stopword lists applied to every index.
+var stopwordFiles []string
+
+// stopwordsDF is the document frequency ratio above which corpus terms become stopwords (0 disables).
+var stopwordsDF float64
+
 func init() {
-	csvHeader = strings.Join([]string{
+	csvHeader = strings.Join(append([]string{
 		"TestID",
 		"Algorithm",
 		"Pre-Indexed",
@@ -40,12 +63,15 @@ func init() {
 		"Grams size",
 		"Jumps size",
 		"Parallel",
+		"Stemmer",
+		"Snapshot",
 		"TotalDocs",
 		"TotalTime",
 		"AvgSpearmanSim10", "MinSpearmanSim10", "MaxSpearmanSim10", "AvgTime10", "MinTime10", "MaxTime10",
 		"AvgSpearmanSim20", "MinSpearmanSim20", "MaxSpearmanSim20", "AvgTime20", "MinTime20", "MaxTime20",
 		"AvgSpearmanSim40", "MinSpearmanSim40", "MaxSpearmanSim40", "AvgTime40", "MinTime40", "MaxTime40",
-	}, ",") + "\n"
+		"TimedOut",
+	}, utils.ProfileCSVHeader...), ",") + "\n"
 }
 
 // Converte bytes para MegaBytes (MB)
@@ -53,15 +79,55 @@ func toMB(b uint64) float64 {
 	return float64(b) / 1024 / 1024
 }
 
-// main iterates over all parameter combinations and saves results to a JSON file.
+// main iterates over all parameter combinations and saves results to a CSV file.
 func main() {
-	mn, mx, avg := utils.MeasureMemory(mainShift)
-	fmt.Printf("Memory usage: \n\tmin: %d\n\tmax: %d\n\tavg: %d\n", mn, mx, avg)
+	flag.StringVar(&profileOpts.Dir, "pprof-dir", "", "directory where pprof profiles of each test are written (disabled if empty)")
+	flag.BoolVar(&profileOpts.CPU, "pprof-cpu", true, "write a CPU profile per test when -pprof-dir is set")
+	flag.BoolVar(&profileOpts.Heap, "pprof-heap", true, "write a heap profile per test when -pprof-dir is set")
+	flag.DurationVar(&profileOpts.Interval, "mem-interval", utils.DefaultSampleInterval, "memory sampling interval")
+	flag.DurationVar(&queryTimeout, "query-timeout", 0, "maximum time per search phrase (0 = no limit)")
+	stopwordList := flag.String("stopwords-file", "", "comma separated stopword files (one word per line), e.g. "+corpus.LegislativeStopwordsFile)
+	flag.Float64Var(&stopwordsDF, "stopwords-df", 0, "also treat terms present in at least this fraction of documents as stopwords (0 = disabled)")
+	stemmerList := flag.String("stemmers", "none", "comma separated stemmers to benchmark: none, rslp, lemma or lemma+rslp")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.Parse()
+
+	if err := utils.SetupLogger(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for _, name := range strings.Split(*stemmerList, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := analysis.Stemmers[name]; !ok {
+			fmt.Fprintf(os.Stderr, "unknown stemmer %q\n", name)
+			os.Exit(2)
+		}
+		stemmers = append(stemmers, name)
+	}
+	for _, path := range strings.Split(*stopwordList, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			stopwordFiles = append(stopwordFiles, path)
+		}
+	}
+
+	// Ctrl-C / SIGTERM cancels the context: running tests stop and partial results are saved.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	prof, err := utils.Profile(utils.ProfileOptions{Interval: profileOpts.Interval}, func() { mainShift(ctx) })
+	if err != nil {
+		slog.Warn("erro no perfil de memória", "err", err)
+	}
+	slog.Info("uso de memória total",
+		"peak_heap_mb", toMB(prof.PeakHeapInuse), "avg_heap_mb", toMB(prof.AvgHeapInuse),
+		"total_alloc_mb", toMB(prof.TotalAlloc), "gc", prof.NumGC, "peak_rss_mb", toMB(prof.PeakRSS))
 }
 
-func mainShift() {
-	//corpus.StartScrapping(5000, 25, 500*time.Millisecond)
-	//corpus.TextProcessor(25)
+func mainShift(ctx context.Context) {
+	//corpus.StartScrapping(ctx, nil, corpus.ScrapeOptions{MaxPages: 5000, Workers: 25, Delay: 500 * time.Millisecond}) // or go run ./cmd_scrape
+	//corpus.TextProcessor(ctx, 25, nil) // or extract.New("pdftotext"), extract.New("pdfcpu+tesseract") for scanned PDFs
 
 	var id int64 = 1 // Unique counter to identify each test.
 
@@ -75,59 +141,121 @@ func mainShift() {
 	strB := strings.Builder{}
 	strB.WriteString(csvHeader)
 
-	// Main loop: iterates through each configured n-gram type (Unigram, Bigram, Trigram).
-	for _, s := range sizes {
-		size, maxJumps := s.Left, s.Right // Unpacks n-gram size and jump limit.
-
-		mn, mx, avg := utils.MeasureMemory(func() {
-
-			// For each n-gram size, test all possible jump levels (from 0 to maxJumps).
-			for jump := 0; jump <= maxJumps; jump++ {
-
-				// --- OTIMIZAÇÃO: CRIA O AMBIENTE (DB + CACHE) UMA VEZ POR CONFIGURAÇÃO DE GRAM ---
-				fmt.Printf(">>> Inicializando Ambiente para Size: %d, Jump: %d\n", size, jump)
-
-				// Limpa o cache de memória antes de criar o novo cenário
-				corpus.ResetCache()
-
-				// Cria o banco de dados físico apenas UMA vez para este grupo de testes
-				// Usamos o ID atual para nomear o arquivo, mas ele será reusado pelos próximos IDs
-				dbName, dbConn := corpus.CreateDatabaseCaches(id, false, size, jump)
+	// runTest appends the result of a test, even a partial one, and reports whether to keep going.
+	runTest := func(testId int64, db *gorm.DB, algo support.Algo, parallel, normalize bool, size, jump int) bool {
+		row, err := BaseTest(ctx, testId, db, algo, parallel, false, normalize, size, jump)
+		strB.WriteString(row)
+		if err != nil {
+			if ctx.Err() == nil {
+				utils.Fatal("teste falhou", "test", testId, "err", err)
+			}
+			slog.Warn("teste interrompido", "test", testId, "err", err)
+			return false
+		}
+		return true
+	}
 
-				// Loop interno para variações que NÃO exigem recriar o índice/banco
-				for _, normalize := range []bool{false, true} {
-					for _, parallel := range []bool{false, true} {
+	// Stopwords are shared by every index of the run; corpus-derived ones are computed once.
+	baseCfg := corpus.DefaultAnalyzerConfig().WithStopwords(stopwordFiles, nil)
+	if stopwordsDF > 0 {
+		derived, err := corpus.DeriveStopwords(ctx, baseCfg, stopwordsDF)
+		if err != nil {
+			utils.Fatal("failed to derive corpus stopwords", "threshold", stopwordsDF, "err", err)
+		}
+		slog.Info("stopwords do corpus", "threshold", stopwordsDF, "count", len(derived), "words", derived)
+		baseCfg = baseCfg.WithStopwords(nil, derived)
+	}
 
-						// Execute TF-IDF reusing the DB
-						strB.WriteString(BaseTest(id, dbConn, support.TdIdf, parallel, false, normalize, size, jump))
-						id++
+	// Main loop: iterates through each stemmer and each configured n-gram type (Unigram, Bigram, Trigram).
+	for _, stemmer := range stemmers {
+		if ctx.Err() != nil {
+			break
+		}
+		analyzerCfg, err := baseCfg.WithStemmer(stemmer)
+		if err != nil {
+			utils.Fatal("invalid analyzer", "stemmer", stemmer, "err", err)
+		}
+
+		for _, s := range sizes {
+			if ctx.Err() != nil {
+				break
+			}
+			size, maxJumps := s.Left, s.Right // Unpacks n-gram size and jump limit.
+
+			prof, err := utils.Profile(utils.ProfileOptions{Interval: profileOpts.Interval}, func() {
+
+				// For each n-gram size, test all possible jump levels (from 0 to maxJumps).
+				for jump := 0; jump <= maxJumps && ctx.Err() == nil; jump++ {
+
+					// --- OTIMIZAÇÃO: CRIA O AMBIENTE (DB + CACHE) UMA VEZ POR CONFIGURAÇÃO DE GRAM ---
+					slog.Info("inicializando ambiente", "stemmer", stemmer, "size", size, "jump", jump)
+
+					// Limpa o cache de memória antes de criar o novo cenário
+					corpus.ResetCache()
+
+					// Cria o banco de dados físico apenas UMA vez para este grupo de testes
+					// Usamos o ID atual para nomear o arquivo, mas ele será reusado pelos próximos IDs
+					dbName, dbConn, err := corpus.CreateDatabaseCaches(ctx, id, false, size, jump, analyzerCfg)
+					if err != nil {
+						if dbName != "" {
+							_ = os.Remove(dbName)
+						}
+						switch {
+						case ctx.Err() != nil:
+							slog.Warn("indexação interrompida", "err", err)
+							return
+						case errors.Is(err, utils.ErrEmptyCorpus):
+							slog.Warn("nada indexado", "size", size, "jump", jump, "err", err)
+							continue
+						default:
+							utils.Fatal("falha ao criar ambiente", "size", size, "jump", jump, "err", err)
+						}
+					}
 
-						// Execute BM25 reusing the DB
-						strB.WriteString(BaseTest(id, dbConn, support.Bm25, parallel, false, normalize, size, jump))
-						id++
+					// Loop interno para variações que NÃO exigem recriar o índice/banco
+				variations:
+					for _, normalize := range []bool{false, true} {
+						for _, parallel := range []bool{false, true} {
+
+							// Execute TF-IDF reusing the DB
+							ok := runTest(id, dbConn, support.TdIdf, parallel, normalize, size, jump)
+							id++
+							if !ok {
+								break variations
+							}
+
+							// Execute BM25 reusing the DB
+							ok = runTest(id, dbConn, support.Bm25, parallel, normalize, size, jump)
+							id++
+							if !ok {
+								break variations
+							}
+						}
 					}
-				}
 
-				// --- CLEANUP: Desmonta o ambiente antes de mudar o tamanho do Gram/Jump ---
+					// --- CLEANUP: Desmonta o ambiente antes de mudar o tamanho do Gram/Jump ---
 
-				// É bom fechar a conexão SQL antes de tentar deletar o arquivo,
-				// principalmente em Windows (Lock de arquivo).
-				sqlDB, err := dbConn.DB()
-				if err == nil {
-					sqlDB.Close()
-				}
+					// É bom fechar a conexão SQL antes de tentar deletar o arquivo,
+					// principalmente em Windows (Lock de arquivo).
+					sqlDB, err := dbConn.DB()
+					if err == nil {
+						sqlDB.Close()
+					}
 
-				// Remove o arquivo físico do banco de dados criado para este grupo
-				if err = os.Remove(dbName); err != nil {
-					log.Printf("aviso: erro removendo arquivo de corpus %s: %v", dbName, err)
+					// Remove o arquivo físico do banco de dados criado para este grupo
+					if err = os.Remove(dbName); err != nil {
+						slog.Warn("erro removendo arquivo de corpus", "file", dbName, "err", err)
+					}
+					slog.Info("ambiente finalizado e limpo", "file", dbName)
 				}
-				fmt.Printf("<<< Ambiente finalizado e limpo: %s\n", dbName)
+			})
+			if err != nil {
+				slog.Warn("erro no perfil de memória", "err", err)
 			}
-		})
-		fmt.Println("\n=========================================================================")
-		fmt.Printf("RELATÓRIO DE USO DE MEMÓRIA PARA N-GRAM SIZE: %d\n", size)
-		fmt.Printf("Mínima registrada: %.2f MB | Média: %.2f MB | Máxima registrada: %.2f MB\n", toMB(mn), toMB(avg), toMB(mx))
-		fmt.Println("=========================================================================")
+			slog.Info("relatório de uso de memória", "stemmer", stemmer, "size", size,
+				"avg_heap_mb", toMB(prof.AvgHeapInuse), "peak_heap_mb", toMB(prof.PeakHeapInuse),
+				"total_alloc_mb", toMB(prof.TotalAlloc), "gc", prof.NumGC, "peak_rss_mb", toMB(prof.PeakRSS))
+		}
 	}
 
 	// --- Saving Results ---
@@ -136,25 +264,43 @@ func mainShift() {
 	fmt.Println()
 	fmt.Println(strB.String())
 
+	if ctx.Err() != nil {
+		slog.Warn("execução interrompida, salvando resultados parciais")
+	}
 	err := os.WriteFile(ResultsOutput, []byte(strB.String()), 0644)
 	if err != nil {
-		log.Fatalf("error saving results.json: %v", err)
+		utils.Fatal("error saving results", "file", ResultsOutput, "err", err)
 	}
+	slog.Info("resultados salvos", "file", ResultsOutput)
 }
 
 // BaseTest executes a full benchmark and validation cycle using an EXISTING database connection.
 // It no longer creates or deletes the database, only runs the algo logic.
-func BaseTest(testId int64, db *gorm.DB, algo support.Algo, parallel, preIndexed, normalizeJumps bool, size, jumps int) string {
+// If ctx is cancelled midway, the partial result row is returned together with the error.
+func BaseTest(ctx context.Context, testId int64, db *gorm.DB, algo support.Algo, parallel, preIndexed, normalizeJumps bool, size, jumps int) (string, error) {
 
 	// Nota: Não chamamos ResetCache() aqui para aproveitar o "aquecimento" do cache entre execuções parecidas
 	// Nota: Não chamamos CreateDatabaseCaches() aqui, usamos o 'db' recebido
 
 	legalInputs := "./../../misc/searchLegalInputs.json"
 
-	// Passamos o DB já aberto
-	res, err := corpus.ApplyLegalInputsDir(db, legalInputs, algo, preIndexed, normalizeJumps, parallel, size, jumps)
-	if err != nil {
-		log.Fatalf(err.Error())
+	// Passamos o DB já aberto, medindo a memória apenas deste teste
+	opts := profileOpts
+	opts.Name = fmt.Sprintf("test_%03d_%s_n%d_j%d", testId, algo, size, jumps)
+
+	var res *models.TestConfigResult
+	var err error
+	prof, profErr := utils.Profile(opts, func() {
+		res, err = corpus.ApplyLegalInputsDir(ctx, db, legalInputs, algo, preIndexed, normalizeJumps, parallel, size, jumps, queryTimeout)
+	})
+	if res == nil {
+		if err == nil {
+			err = errors.New("no result")
+		}
+		return "", err
+	}
+	if profErr != nil {
+		slog.Warn("erro no perfil do teste", "test", testId, "err", profErr)
 	}
 
 	// limpa o toString pra virar 1 linha
@@ -162,9 +308,9 @@ func BaseTest(testId int64, db *gorm.DB, algo support.Algo, parallel, preIndexed
 	clean = strings.ReplaceAll(clean, "\t", "")
 
 	csv := fmt.Sprintf(
-		"%d,%s,%v,%v,%d,%d,%v,%s\n",
-		testId, algo, preIndexed, normalizeJumps, size, jumps, parallel, clean,
+		"%d,%s,%v,%v,%d,%d,%v,%s,%s,%s,%s\n",
+		testId, algo, preIndexed, normalizeJumps, size, jumps, parallel, corpus.Analyzer.Config().Stemmer(), corpus.Snapshot.ID, clean, prof.CSV(),
 	)
 
-	return csv
+	return csv, err
 }
@@ -0,0 +1,200 @@
+package corpus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tcc2-davi-arthur/models"
+)
+
+// Camara coleta proposições da Câmara dos Deputados: a lista vem da API de busca do portal,
+// os metadados da API de Dados Abertos e o PDF do link de inteiro teor da ficha de tramitação.
+type Camara struct {
+	SearchURL string   // API de busca do portal
+	PortalURL string   // Portal (ficha de tramitação e links relativos)
+	DataURL   string   // API de Dados Abertos (/proposicoes)
+	Types     []string // Siglas buscadas
+}
+
+// NewCamara cria a fonte com os endereços oficiais, buscando PEC, PLP, PL e PDL.
+func NewCamara() *Camara {
+	return &Camara{
+		SearchURL: "https://www.camara.leg.br/busca-api/api/v1/busca/proposicoes/_search",
+		PortalURL: "https://www.camara.leg.br",
+		DataURL:   "https://dadosabertos.camara.leg.br/api/v2/proposicoes",
+		Types:     []string{"PEC", "PLP", "PL", "PDL"},
+	}
+}
+
+func (this *Camara) Name() string {
+	return "camara"
+}
+
+func (this *Camara) Prefix() string {
+	return "cd"
+}
+
+type camaraSearchResponse struct {
+	Hits struct {
+		Hits []struct {
+			ID string `json:"_id"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+type camaraSearchBody struct {
+	Order             string `json:"order"`
+	Pagina            int    `json:"pagina"`
+	Q                 string `json:"q"`
+	TiposDeProposicao string `json:"tiposDeProposicao"`
+}
+
+// List usa a busca textual do portal para q.Keyword e q.Types; ano, tema e autor ficam para
+// ScrapeQuery.Match.
+func (this *Camara) List(ctx context.Context, f *Fetcher, q ScrapeQuery, page int) ([]string, error) {
+	keyword := strings.TrimSpace(q.Keyword)
+	if keyword == "" {
+		keyword = "*"
+	}
+	types := this.Types
+	if len(q.Types) > 0 {
+		types = q.Types
+	}
+	data, err := json.Marshal(camaraSearchBody{
+		Order:             "relevancia",
+		Pagina:            page,
+		Q:                 keyword,
+		TiposDeProposicao: strings.ToUpper(strings.Join(types, ",")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := f.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, this.SearchURL, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp camaraSearchResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("invalid search response: %w", err)
+	}
+	ret := make([]string, len(resp.Hits.Hits))
+	for i, h := range resp.Hits.Hits {
+		ret[i] = h.ID
+	}
+	return ret, nil
+}
+
+// camaraProposal é a resposta de /proposicoes/{id} dos Dados Abertos da Câmara.
+type camaraProposal struct {
+	Dados struct {
+		SiglaTipo        string `json:"siglaTipo"`
+		Numero           int    `json:"numero"`
+		Ano              int    `json:"ano"`
+		Ementa           string `json:"ementa"`
+		DataApresentacao string `json:"dataApresentacao"`
+		StatusProposicao struct {
+			DescricaoSituacao string `json:"descricaoSituacao"`
+		} `json:"statusProposicao"`
+	} `json:"dados"`
+}
+
+// camaraThemes é a resposta de /proposicoes/{id}/temas.
+type camaraThemes struct {
+	Dados []struct {
+		Tema string `json:"tema"`
+	} `json:"dados"`
+}
+
+// camaraAuthors é a resposta de /proposicoes/{id}/autores.
+type camaraAuthors struct {
+	Dados []struct {
+		Nome string `json:"nome"`
+	} `json:"dados"`
+}
+
+func (this *Camara) Metadata(ctx context.Context, f *Fetcher, id string) (*models.DocumentMeta, error) {
+	var prop camaraProposal
+	if err := f.GetJSON(ctx, fmt.Sprintf("%s/%s", this.DataURL, id), &prop); err != nil {
+		return nil, err
+	}
+	var authors camaraAuthors
+	if err := f.GetJSON(ctx, fmt.Sprintf("%s/%s/autores", this.DataURL, id), &authors); err != nil {
+		return nil, err
+	}
+	// Os temas são complementares: sem eles a proposição fica apenas sem Themes
+	var themes camaraThemes
+	if err := f.GetJSON(ctx, fmt.Sprintf("%s/%s/temas", this.DataURL, id), &themes); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		slog.Warn("temas não obtidos", "source", this.Name(), "proposal", id, "err", err)
+	}
+
+	ret := &models.DocumentMeta{
+		Source:     this.Name(),
+		ProposalID: id,
+		Type:       strings.ToUpper(prop.Dados.SiglaTipo),
+		Number:     prop.Dados.Numero,
+		Year:       prop.Dados.Ano,
+		Ementa:     strings.TrimSpace(prop.Dados.Ementa),
+		Status:     prop.Dados.StatusProposicao.DescricaoSituacao,
+		SourceURL:  this.detailURL(id),
+	}
+	for _, a := range authors.Dados {
+		if name := strings.TrimSpace(a.Nome); name != "" {
+			ret.Authors = append(ret.Authors, name)
+		}
+	}
+	for _, t := range themes.Dados {
+		if name := strings.TrimSpace(t.Tema); name != "" {
+			ret.Themes = append(ret.Themes, name)
+		}
+	}
+	if t, ok := parseDate(prop.Dados.DataApresentacao); ok {
+		ret.SubmittedAt = &t
+	}
+	return ret, nil
+}
+
+func (this *Camara) Document(ctx context.Context, f *Fetcher, id string) ([]byte, error) {
+	page, err := f.Get(ctx, this.detailURL(id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+
+	link, exists := doc.Find("a.linkDownloadTeor").First().Attr("href")
+	if !exists {
+		return nil, fmt.Errorf("link PDF não encontrado")
+	}
+	if !strings.HasPrefix(link, "http") {
+		link = this.PortalURL + link
+	}
+	link = strings.Split(link, "&filename")[0]
+
+	return f.Get(ctx, link, nil)
+}
+
+// detailURL é a ficha de tramitação da proposição.
+func (this *Camara) detailURL(id string) string {
+	return fmt.Sprintf("%s/proposicoesWeb/fichadetramitacao?idProposicao=%s", this.PortalURL, id)
+}
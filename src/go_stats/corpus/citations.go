@@ -0,0 +1,74 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+// ErrNoCitation indica que a consulta não contém nenhuma citação legal reconhecível.
+var ErrNoCitation = errors.New("no legal citation found in query")
+
+// FindByCitation retorna os documentos que citam todas as referências legais presentes em
+// query (ex.: "Lei 8.666/93 art. 37"), ordenados pelo total de ocorrências. Uma citação sem
+// ano ("Lei 8.666") casa com qualquer ano registrado para aquele número.
+func FindByCitation(ctx context.Context, db *gorm.DB, query string) ([]*models.Document, error) {
+	spans := analysis.ExtractCitations(query)
+	if len(spans) == 0 {
+		return nil, ErrNoCitation
+	}
+	db = db.WithContext(ctx)
+
+	var counts map[uint16]int
+	for _, s := range spans {
+		q := db.Model(&models.Citation{}).Where("value = ?", s.Token)
+		if !strings.Contains(s.Token, "/") {
+			q = db.Model(&models.Citation{}).Where("value = ? OR value LIKE ?", s.Token, s.Token+"/%")
+		}
+
+		var found []models.Citation
+		if err := q.Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("%w: failed to search citations: %v", utils.ErrDatabase, err)
+		}
+
+		next := make(map[uint16]int)
+		for _, c := range found {
+			prev, ok := counts[c.DocID]
+			if counts != nil && !ok {
+				continue
+			}
+			if _, seen := next[c.DocID]; !seen {
+				next[c.DocID] = prev
+			}
+			next[c.DocID] += int(c.Count)
+		}
+		counts = next
+		if len(counts) == 0 {
+			return nil, nil
+		}
+	}
+
+	ids := make([]uint16, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+
+	var ret []*models.Document
+	if err := db.Model(&models.Document{}).Where("id IN ?", ids).Find(&ret).Error; err != nil {
+		return nil, fmt.Errorf("%w: failed to load documents: %v", utils.ErrDatabase, err)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if counts[ret[i].ID] != counts[ret[j].ID] {
+			return counts[ret[i].ID] > counts[ret[j].ID]
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	return ret, nil
+}
@@ -0,0 +1,209 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+// Métodos de agrupamento de ClusterDocs.
+const (
+	ClusterKMeans       = "kmeans"
+	ClusterHierarchical = "hierarchical"
+)
+
+// ClusterOptions configura ClusterDocs.
+type ClusterOptions struct {
+	K              int    // Quantidade de tópicos
+	Method         string // ClusterKMeans ou ClusterHierarchical
+	MaxIter        int    // Iterações máximas do k-means
+	Seed           uint64 // Semente do k-means++
+	Terms          int    // Termos por tópico em Cluster.Terms
+	NormalizeJumps bool
+
+	// Embeddings, se não nil, agrupa os embeddings densos dos documentos (ver LoadEmbeddings)
+	// em vez dos vetores TF-IDF; os termos dos tópicos continuam vindo do TF-IDF.
+	Embeddings map[uint16][]float32
+}
+
+// DefaultClusterOptions agrupa o corpus em 20 tópicos por k-means sobre o TF-IDF.
+func DefaultClusterOptions() ClusterOptions {
+	return ClusterOptions{K: 20, Method: ClusterKMeans, MaxIter: 50, Seed: 1, Terms: 10}
+}
+
+// Topic é um tópico encontrado por ClusterDocs e os seus documentos, em ordem de ID.
+type Topic struct {
+	Cluster *models.Cluster
+	Docs    []*models.Document
+}
+
+// ClusterDocs agrupa os documentos indexados em opts.K tópicos. Apenas os documentos
+// canônicos (Document.DupOf = 0) são agrupados; as quase-duplicatas entram no tópico do seu
+// canônico. Os tópicos são numerados a partir de 1, do maior para o menor, e rotulados pelos
+// termos de maior peso TF-IDF somado entre os seus documentos. Documentos sem n-gramas (ou
+// sem embedding) ficam fora dos tópicos.
+func ClusterDocs(ctx context.Context, opts ClusterOptions) ([]Topic, error) {
+	if CacheDocs == nil || Docs == nil || CacheWords == nil {
+		return nil, ErrIndexNotReady
+	}
+	if opts.Method != ClusterKMeans && opts.Method != ClusterHierarchical {
+		return nil, fmt.Errorf("unknown clustering method %q", opts.Method)
+	}
+
+	byId := make(map[uint16]*models.Document, len(CacheDocs))
+	var ids []uint16
+	for _, doc := range CacheDocs {
+		byId[doc.ID] = doc
+		if doc.DupOf != 0 || len(Docs[doc.ID]) == 0 {
+			continue
+		}
+		if _, ok := opts.Embeddings[doc.ID]; opts.Embeddings != nil && !ok {
+			continue
+		}
+		ids = append(ids, doc.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	sparse := make([]map[string]*float64, len(ids))
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vec, err := utils.ComputeDocPreIndexedTFIDF(ctx, Docs[id], len(CacheDocs), CacheGrams, opts.NormalizeJumps, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute vector of document %d: %w", id, err)
+		}
+		sparse[i] = vec
+	}
+
+	var vecs utils.ClusterVectors = utils.NewSparseVectors(sparse)
+	method := opts.Method + "/tfidf"
+	if opts.Embeddings != nil {
+		dense := make([][]float32, len(ids))
+		for i, id := range ids {
+			dense[i] = opts.Embeddings[id]
+		}
+		var err error
+		if vecs, err = utils.NewDenseVectors(dense); err != nil {
+			return nil, err
+		}
+		method = opts.Method + "/embeddings"
+	}
+
+	var labels []int
+	var err error
+	if opts.Method == ClusterKMeans {
+		labels, err = utils.KMeans(vecs, opts.K, opts.MaxIter, opts.Seed)
+	} else {
+		labels, err = utils.Agglomerative(vecs, opts.K)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	members := make([][]int, opts.K)
+	for i, c := range labels {
+		members[c] = append(members[c], i)
+	}
+	sort.SliceStable(members, func(i, j int) bool { return len(members[i]) > len(members[j]) })
+
+	words := make(map[uint16]string, len(CacheWords))
+	for _, w := range CacheWords {
+		words[w.ID] = w.Value
+	}
+	canonical := make(map[uint16]int, len(ids))
+	ret := make([]Topic, 0, opts.K)
+	for _, group := range members {
+		if len(group) == 0 {
+			continue
+		}
+		groupVecs := make([]map[string]*float64, len(group))
+		for i, m := range group {
+			groupVecs[i] = sparse[m]
+			canonical[ids[m]] = len(ret)
+		}
+		terms := utils.TopTerms(groupVecs, opts.Terms)
+		for i, key := range terms {
+			terms[i] = gramText(key, words)
+		}
+		ret = append(ret, Topic{Cluster: models.NewCluster(uint16(len(ret)+1), method, terms, 0)})
+	}
+
+	for _, doc := range byId {
+		if t, ok := canonical[doc.GroupId()]; ok {
+			ret[t].Docs = append(ret[t].Docs, doc)
+		}
+	}
+	for _, t := range ret {
+		sort.Slice(t.Docs, func(i, j int) bool { return t.Docs[i].ID < t.Docs[j].ID })
+		t.Cluster.Size = len(t.Docs)
+	}
+	return ret, nil
+}
+
+// gramText converte a chave de um n-grama (GetCacheKey: IDs de palavras com 5 dígitos,
+// seguidos ou não dos jumps) nas suas palavras separadas por espaço.
+func gramText(key string, words map[uint16]string) string {
+	var ret []string
+	for _, part := range strings.Split(key, "-") {
+		if len(part) != 5 {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 16)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, words[uint16(id)])
+	}
+	return strings.Join(ret, " ")
+}
+
+// SaveClusters substitui os tópicos gravados (tabela CLUSTER e Document.Cluster) pelos de
+// topics, no banco e em CacheDocs. Como em MarkDuplicates, o banco pode ser outro que não o
+// do índice (ex.: utils.OpenDB(DbFile)), desde que tenha os mesmos documentos.
+func SaveClusters(db *gorm.DB, topics []Topic) error {
+	cluster := make(map[uint16]uint16)
+	for _, t := range topics {
+		for _, doc := range t.Docs {
+			cluster[doc.ID] = t.Cluster.ID
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM CLUSTER").Error; err != nil {
+			return fmt.Errorf("%w: failed to reset clusters: %v", utils.ErrDatabase, err)
+		}
+		if err := tx.Model(&models.Document{}).Where("cluster <> 0").Update("cluster", 0).Error; err != nil {
+			return fmt.Errorf("%w: failed to reset document clusters: %v", utils.ErrDatabase, err)
+		}
+		for _, t := range topics {
+			if err := tx.Create(t.Cluster).Error; err != nil {
+				return fmt.Errorf("%w: failed to save cluster %d: %v", utils.ErrDatabase, t.Cluster.ID, err)
+			}
+			ids := make([]uint16, len(t.Docs))
+			for i, doc := range t.Docs {
+				ids[i] = doc.ID
+			}
+			res := tx.Model(&models.Document{}).Where("id IN ?", ids).Update("cluster", t.Cluster.ID)
+			if res.Error != nil {
+				return fmt.Errorf("%w: failed to assign cluster %d: %v", utils.ErrDatabase, t.Cluster.ID, res.Error)
+			}
+			if res.RowsAffected != int64(len(ids)) {
+				return fmt.Errorf("%w: cluster %d: %d of %d documents not found", utils.ErrDatabase, t.Cluster.ID, int64(len(ids))-res.RowsAffected, len(ids))
+			}
+		}
+		for _, doc := range CacheDocs {
+			doc.Cluster = cluster[doc.ID]
+		}
+		return nil
+	})
+}
@@ -0,0 +1,137 @@
+package corpus
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+func TestGramText(t *testing.T) {
+	words := map[uint16]string{3: "codigo", 12: "penal"}
+	for key, want := range map[string]string{
+		"00003":         "codigo",
+		"00003-00012":   "codigo penal",
+		"00003-00012-1": "codigo penal",
+		"00003-n":       "codigo",
+	} {
+		if got := gramText(key, words); got != want {
+			t.Errorf("gramText(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestClusterDocs(t *testing.T) {
+	// Os documentos de cada tema têm os mesmos termos, para que o k-means++ não sorteie duas
+	// sementes do mesmo tema qualquer que seja a semente.
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "código penal crimes pena",
+		"doc_0002_clean.txt": "pena crimes código penal",
+		"doc_0003_clean.txt": "código penal crimes pena prisão",
+		"doc_0004_clean.txt": "imposto renda tributo fiscal",
+		"doc_0005_clean.txt": "tributo fiscal imposto renda",
+		"doc_0006_clean.txt": "renda imposto fiscal tributo",
+		"doc_0007_clean.txt": "fiscal tributo renda imposto",
+	})
+	base := openIndex(t, 1, true)
+	name := func(n int) string { return "doc_000" + string(rune('0'+n)) + "_clean.txt" }
+	clusters := []DupCluster{{Canonical: CacheDocs[name(1)], Duplicates: []DupMember{{Doc: CacheDocs[name(3)]}}}}
+	if err := MarkDuplicates(base, clusters); err != nil {
+		t.Fatal(err)
+	}
+
+	// Como em cmd_cluster: o índice roda numa cópia e os tópicos vão para DbFile.
+	ResetCache()
+	openIndex(t, 2, false)
+	opts := DefaultClusterOptions()
+	opts.K = 2
+	for _, method := range []string{ClusterKMeans, ClusterHierarchical} {
+		opts.Method = method
+		topics, err := ClusterDocs(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(topics) != 2 {
+			t.Fatalf("%s: expected 2 topics, got %d", method, len(topics))
+		}
+
+		// O tópico 1 é o de mais documentos canônicos; a duplicata entra no tópico do canônico.
+		var got [][]string
+		for i, topic := range topics {
+			if topic.Cluster.ID != uint16(i+1) || topic.Cluster.Size != len(topic.Docs) || topic.Cluster.Method != method+"/tfidf" {
+				t.Errorf("%s: unexpected cluster %s", method, topic.Cluster.ToString())
+			}
+			var names []string
+			for _, doc := range topic.Docs {
+				names = append(names, doc.Name)
+			}
+			got = append(got, names)
+		}
+		want := [][]string{{name(4), name(5), name(6), name(7)}, {name(1), name(2), name(3)}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("%s: got %v, want %v", method, got, want)
+		}
+		if terms := topics[1].Cluster.TermList(); !slices.Contains(terms, "penal") || slices.Contains(terms, "imposto") {
+			t.Errorf("%s: unexpected terms %q", method, terms)
+		}
+	}
+
+	topics, err := ClusterDocs(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := utils.OpenDB(DbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SaveClusters(db, topics)
+	if sqlDB, e := db.DB(); e == nil {
+		_ = sqlDB.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CacheDocs[name(3)].Cluster != 2 || CacheDocs[name(4)].Cluster != 1 {
+		t.Errorf("expected the caches to be updated, got %d and %d", CacheDocs[name(3)].Cluster, CacheDocs[name(4)].Cluster)
+	}
+
+	// Um novo índice recarrega os tópicos gravados.
+	ResetCache()
+	reloaded := openIndex(t, 3, false)
+	for n, want := range map[int]uint16{1: 2, 2: 2, 3: 2, 4: 1, 5: 1, 6: 1, 7: 1} {
+		if got := CacheDocs[name(n)].Cluster; got != want {
+			t.Errorf("%s: expected cluster %d after reload, got %d", name(n), want, got)
+		}
+	}
+	var stored []models.Cluster
+	if err = reloaded.Order("id").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].Size != 4 || stored[1].Size != 3 || !slices.Equal(stored[1].TermList(), topics[1].Cluster.TermList()) {
+		t.Errorf("unexpected stored clusters %+v", stored)
+	}
+
+	// Gravar uma lista vazia desfaz os tópicos.
+	if err = SaveClusters(reloaded, nil); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err = reloaded.Model(&models.Cluster{}).Count(&n).Error; err != nil || n != 0 {
+		t.Errorf("expected no clusters, got %d (%v)", n, err)
+	}
+	if err = reloaded.Model(&models.Document{}).Where("cluster <> 0").Count(&n).Error; err != nil || n != 0 {
+		t.Errorf("expected no assigned documents, got %d (%v)", n, err)
+	}
+	for _, doc := range CacheDocs {
+		if doc.Cluster != 0 {
+			t.Errorf("%s: expected no cluster in the cache, got %d", doc.Name, doc.Cluster)
+		}
+	}
+
+	missing := []Topic{{Cluster: models.NewCluster(1, "kmeans/tfidf", nil, 1), Docs: []*models.Document{{ID: 999}}}}
+	if err = SaveClusters(reloaded, missing); err == nil {
+		t.Error("expected an error for a document missing from the database")
+	}
+}
@@ -1,12 +1,16 @@
 package corpus
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"runtime"
 	"sort"
 	"sync"
+	"time"
 
 	mgu "github.com/artking28/myGoUtils"
 	"github.com/tcc2-davi-arthur/models"
@@ -15,7 +19,14 @@ import (
 	"gorm.io/gorm"
 )
 
-func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, preIndexed, normalizeJumps, parallel bool, size, jumps int) (*models.TestConfigResult, error) {
+// ApplyLegalInputsDir executa todas as frases de legalInputs contra o índice em memória e
+// acumula a correlação de Spearman com o ranking do Bert.
+//
+// Cada frase roda com um contexto derivado de ctx limitado por queryTimeout (sem limite se
+// queryTimeout <= 0); frases que estouram o tempo são descartadas, contadas em TimedOut e não
+// entram em TotalTime.
+// Se ctx for cancelado, retorna o resultado parcial acumulado até ali junto com ctx.Err().
+func ApplyLegalInputsDir(ctx context.Context, db *gorm.DB, legalInputs string, algo support.Algo, preIndexed, normalizeJumps, parallel bool, size, jumps int, queryTimeout time.Duration) (*models.TestConfigResult, error) {
 	inputs, err := os.ReadFile(legalInputs)
 	if err != nil {
 		return nil, fmt.Errorf("error reading legal entries file: %v", err)
@@ -27,7 +38,7 @@ func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, pre
 	}
 
 	var all []*models.Document
-	if err = db.Model(&models.Document{}).Find(&all).Error; err != nil {
+	if err = db.WithContext(ctx).Model(&models.Document{}).Find(&all).Error; err != nil {
 		return nil, fmt.Errorf("error reading documents: %v", err)
 	}
 
@@ -39,11 +50,19 @@ func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, pre
 		var phraseVec map[string]*float64
 		var err error
 
+		qctx, cancel := ctx, context.CancelFunc(func() {})
+		if queryTimeout > 0 {
+			qctx, cancel = context.WithTimeout(ctx, queryTimeout)
+		}
+		defer cancel()
+
 		elapsedPhrase := utils.Stopwatch(func() {
+			// A consulta passa pelo mesmo Analyzer usado na indexação dos documentos.
+			input := Analyzer.AnalyzeString(phrase.Input)
 			if algo == support.TdIdf {
-				phraseVec, err = utils.ComputeStringTFIDF(phrase.Input, size, jumps, len(all), CacheGrams, CacheWords, normalizeJumps, parallel)
+				phraseVec, err = utils.ComputeStringTFIDF(qctx, input, size, jumps, len(all), CacheGrams, CacheWords, normalizeJumps, parallel)
 			} else {
-				phraseVec, err = utils.ComputeStringBM25(phrase.Input, size, jumps, len(all), CountAllNGrams, CacheGrams, CacheWords, normalizeJumps, parallel)
+				phraseVec, err = utils.ComputeStringBM25(qctx, input, size, jumps, len(all), CountAllNGrams, CacheGrams, CacheWords, normalizeJumps, parallel)
 			}
 			_ = phraseVec
 		}).Microseconds()
@@ -55,15 +74,20 @@ func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, pre
 		var mu sync.Mutex
 		wg := sync.WaitGroup{}
 		sem := make(chan struct{}, runtime.NumCPU())
-		progress := make(chan int, len(all))
 
-		ret.TotalTime += utils.Stopwatch(func() {
+		elapsedDocs := utils.Stopwatch(func() {
 			for _, doc := range all {
+				if qctx.Err() != nil {
+					break
+				}
 				wg.Add(1)
 				sem <- struct{}{}
 				go func(doc *models.Document) {
 					defer wg.Done()
 					defer func() { <-sem }()
+					if qctx.Err() != nil {
+						return
+					}
 					var err error
 
 					mu.Lock()
@@ -71,12 +95,14 @@ func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, pre
 					mu.Unlock()
 					if !ok {
 						if algo == support.TdIdf {
-							docVec, err = utils.ComputeDocPreIndexedTFIDF(Docs[doc.ID], len(CacheDocs), CacheGrams, normalizeJumps, parallel)
+							docVec, err = utils.ComputeDocPreIndexedTFIDF(qctx, Docs[doc.ID], len(CacheDocs), CacheGrams, normalizeJumps, parallel)
 						} else {
-							docVec, err = utils.ComputeDocPreIndexedBM25(Docs[doc.ID], len(CacheDocs), CountAllNGrams, CacheGrams, normalizeJumps, parallel)
+							docVec, err = utils.ComputeDocPreIndexedBM25(qctx, Docs[doc.ID], len(CacheDocs), CountAllNGrams, CacheGrams, normalizeJumps, parallel)
 						}
 						if err != nil {
-							fmt.Printf("error computing doc %d: %v\n", doc.ID, err)
+							if qctx.Err() == nil {
+								slog.Warn("erro calculando vetor do documento", "doc", doc.ID, "err", err)
+							}
 							return
 						}
 						mu.Lock()
@@ -88,13 +114,15 @@ func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, pre
 					mu.Lock()
 					docSimAccum[doc.ID] = sim
 					mu.Unlock()
-
-					progress <- 1
 				}(doc)
 			}
 			wg.Wait()
-			close(progress)
 		}).Milliseconds()
+		if err = qctx.Err(); err != nil {
+			return err
+		}
+		// Só frases concluídas entram no tempo total; as que estouram o timeout ficam em TimedOut
+		ret.TotalTime += elapsedDocs
 
 		// ordena top documentos
 		pairs := make([]mgu.Pair[uint16, float64], 0, len(docSimAccum))
@@ -119,24 +147,40 @@ func ApplyLegalInputsDir(db *gorm.DB, legalInputs string, algo support.Algo, pre
 		return nil
 	}
 
-	done := 0
-	for _, phrase := range data.Words10 {
-		if e := processPhrase(phrase, ret.Push10); e != nil {
-			return nil, e
-		}
-		done++
-	}
-	for _, phrase := range data.Words20 {
-		if e := processPhrase(phrase, ret.Push20); e != nil {
-			return nil, e
+	// runGroup processa um grupo de frases. Timeouts individuais são contabilizados e a
+	// frase é descartada; cancelamento do ctx pai interrompe tudo devolvendo o parcial.
+	progress := utils.NewProgress(fmt.Sprintf("consultas %s", algo), len(data.Words10)+len(data.Words20)+len(data.Words40))
+	defer progress.Finish()
+	runGroup := func(phrases []support.Interaction, pushFunc func(float64, int64)) error {
+		for _, phrase := range phrases {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			e := processPhrase(phrase, pushFunc)
+			progress.Add(1)
+			if e != nil {
+				if errors.Is(e, context.DeadlineExceeded) && ctx.Err() == nil {
+					slog.Warn("consulta excedeu o timeout", "timeout", queryTimeout, "input", phrase.Input)
+					ret.TimedOut++
+					continue
+				}
+				return e
+			}
 		}
-		done++
+		return nil
 	}
-	for _, phrase := range data.Words40 {
-		if e := processPhrase(phrase, ret.Push40); e != nil {
-			return nil, e
+
+	for _, group := range []mgu.Pair[[]support.Interaction, func(float64, int64)]{
+		mgu.NewPair(data.Words10, ret.Push10),
+		mgu.NewPair(data.Words20, ret.Push20),
+		mgu.NewPair(data.Words40, ret.Push40),
+	} {
+		if err = runGroup(group.Left, group.Right); err != nil {
+			if ctx.Err() != nil {
+				return &ret, err
+			}
+			return nil, err
 		}
-		done++
 	}
 
 	return &ret, nil
@@ -0,0 +1,336 @@
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrHTTPStatus é retornado (embrulhado) quando o servidor responde com um status de erro que
+// não vale a pena repetir (ex.: 404).
+var ErrHTTPStatus = errors.New("unexpected HTTP status")
+
+// ---- Cliente HTTP ----
+
+// Fetcher faz requisições HTTP com limite de taxa por host e novas tentativas com espera
+// exponencial em erros de rede, 429 e 5xx.
+type Fetcher struct {
+	Client     *http.Client
+	Delay      time.Duration // Intervalo mínimo entre requisições ao mesmo host
+	MaxRetries int           // Tentativas extras após a primeira falha
+	Backoff    time.Duration // Espera antes da primeira nova tentativa (dobra a cada tentativa)
+
+	mu   sync.Mutex
+	next map[string]time.Time // Próximo horário livre de cada host
+}
+
+// NewFetcher cria um Fetcher com timeout de 60s, 4 novas tentativas e espera inicial de 1s.
+func NewFetcher(delay time.Duration) *Fetcher {
+	return &Fetcher{
+		Client:     &http.Client{Timeout: 60 * time.Second},
+		Delay:      delay,
+		MaxRetries: 4,
+		Backoff:    time.Second,
+		next:       make(map[string]time.Time),
+	}
+}
+
+// wait bloqueia até o host poder receber outra requisição.
+func (this *Fetcher) wait(ctx context.Context, host string) error {
+	this.mu.Lock()
+	now := time.Now()
+	at := this.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	this.next[host] = at.Add(this.Delay)
+	this.mu.Unlock()
+
+	if d := time.Until(at); d > 0 && !sleepCtx(ctx, d) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Do executa a requisição criada por newReq (uma nova a cada tentativa) e retorna o corpo
+// de uma resposta 2xx.
+func (this *Fetcher) Do(ctx context.Context, newReq func(ctx context.Context) (*http.Request, error)) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= this.MaxRetries; attempt++ {
+		req, err := newReq(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err = this.wait(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+
+		body, retryAfter, err := this.once(req)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if retryAfter < 0 {
+			return nil, err // Não vale repetir
+		}
+		lastErr = err
+
+		if attempt < this.MaxRetries {
+			wait := max(retryAfter, this.Backoff<<attempt)
+			wait += time.Duration(rand.Int64N(int64(wait)/4 + 1)) // jitter
+			slog.Debug("nova tentativa", "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "err", err)
+			if !sleepCtx(ctx, wait) {
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", this.MaxRetries+1, lastErr)
+}
+
+// Get é Do para um GET simples.
+func (this *Fetcher) Get(ctx context.Context, rawURL string, headers map[string]string) ([]byte, error) {
+	return this.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+}
+
+// GetJSON faz um GET em rawURL e decodifica a resposta JSON em v.
+func (this *Fetcher) GetJSON(ctx context.Context, rawURL string, v any) error {
+	data, err := this.Get(ctx, rawURL, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid JSON from %s: %w", rawURL, err)
+	}
+	return nil
+}
+
+// once faz uma tentativa. retryAfter < 0 indica erro definitivo; >= 0 indica erro temporário
+// e a espera pedida pelo servidor (Retry-After), se houver.
+func (this *Fetcher) once(req *http.Request) ([]byte, time.Duration, error) {
+	resp, err := this.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err != nil {
+			return nil, 0, err
+		}
+		return body, 0, nil
+	}
+
+	err = fmt.Errorf("%w: %s %s: %d", ErrHTTPStatus, req.Method, req.URL, resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, retryAfter(resp.Header.Get("Retry-After")), err
+	}
+	return nil, -1, err
+}
+
+func retryAfter(v string) time.Duration {
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(0, time.Until(t))
+	}
+	return 0
+}
+
+// ---- Estado do crawl ----
+
+// Situações de um item no estado do crawl.
+const (
+	CrawlDone      = "done"      // Documento salvo
+	CrawlDuplicate = "duplicate" // Conteúdo idêntico ao de outro item já salvo
+	CrawlSkipped   = "skipped"   // Fora dos critérios (ex.: sem páginas)
+	CrawlFailed    = "failed"    // Falhou; será tentado de novo na próxima execução
+)
+
+// CrawlEntry é a situação de uma proposição no crawl.
+type CrawlEntry struct {
+	Status    string    `json:"status"`
+	File      string    `json:"file,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"` // SHA-256 do documento baixado
+	Pages     int       `json:"pages,omitempty"`
+	Bytes     int64     `json:"bytes,omitempty"`
+	DupOf     string    `json:"dupOf,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CrawlState é o estado persistido do crawl (DocBaseName da proposição -> situação), que permite
+// retomar uma execução interrompida sem baixar de novo o que já foi salvo.
+type CrawlState struct {
+	path      string
+	mu        sync.Mutex
+	Items     map[string]*CrawlEntry `json:"items"`
+	checksums map[string]string      // checksum -> ID do item salvo
+}
+
+// LoadCrawlState lê o estado gravado em path, ou cria um vazio se o arquivo não existir.
+func LoadCrawlState(path string) (*CrawlState, error) {
+	ret := &CrawlState{path: path, Items: make(map[string]*CrawlEntry), checksums: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, ret); err != nil {
+		return nil, fmt.Errorf("invalid crawl state %s: %w", path, err)
+	}
+	if ret.Items == nil {
+		ret.Items = make(map[string]*CrawlEntry)
+	}
+	if n := ret.migrateKeys(); n > 0 {
+		slog.Info("estado do crawl migrado para chaves com prefixo", "state", path, "items", n)
+	}
+	for id, e := range ret.Items {
+		if e.Status == CrawlDone && e.Checksum != "" {
+			ret.checksums[e.Checksum] = id
+		}
+	}
+	return ret, nil
+}
+
+// legacyCrawlPrefix é o prefixo dado às chaves de estados anteriores às fontes (ver Source),
+// que só coletavam a Câmara e usavam o ID da proposição como chave.
+const legacyCrawlPrefix = "cd_"
+
+// migrateKeys troca as chaves sem prefixo (e as referências em DupOf) por
+// legacyCrawlPrefix + ID, como em DocBaseName. Retorna quantas chaves mudaram.
+func (this *CrawlState) migrateKeys() int {
+	legacy := func(id string) bool {
+		return id != "" && !strings.Contains(id, "_")
+	}
+	n := 0
+	for id, e := range this.Items {
+		if e.DupOf != "" && legacy(e.DupOf) {
+			e.DupOf = legacyCrawlPrefix + e.DupOf
+		}
+		if !legacy(id) {
+			continue
+		}
+		delete(this.Items, id)
+		if _, ok := this.Items[legacyCrawlPrefix+id]; !ok {
+			this.Items[legacyCrawlPrefix+id] = e
+		}
+		n++
+	}
+	return n
+}
+
+// Pending informa se o item ainda precisa ser processado (novo ou com falha anterior).
+func (this *CrawlState) Pending(id string) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	e, ok := this.Items[id]
+	return !ok || e.Status == CrawlFailed
+}
+
+// Get retorna uma cópia da situação do item.
+func (this *CrawlState) Get(id string) (CrawlEntry, bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if e, ok := this.Items[id]; ok {
+		return *e, true
+	}
+	return CrawlEntry{}, false
+}
+
+// Totals soma os documentos, páginas e bytes já salvos cujas chaves começam com prefix.
+func (this *CrawlState) Totals(prefix string) (docs, pages int, size int64) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	for id, e := range this.Items {
+		if e.Status == CrawlDone && strings.HasPrefix(id, prefix) {
+			docs++
+			pages += e.Pages
+			size += e.Bytes
+		}
+	}
+	return docs, pages, size
+}
+
+// Claim registra o checksum do conteúdo de id. Se outro item já salvo tiver o mesmo conteúdo,
+// retorna o seu ID e false.
+func (this *CrawlState) Claim(id, checksum string) (string, bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if other, ok := this.checksums[checksum]; ok && other != id {
+		return other, false
+	}
+	this.checksums[checksum] = id
+	return id, true
+}
+
+// Release desfaz Claim para um item cujo conteúdo não chegou a ser salvo.
+func (this *CrawlState) Release(id, checksum string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.checksums[checksum] == id {
+		delete(this.checksums, checksum)
+	}
+}
+
+// Set atualiza a situação de um item e grava o estado.
+func (this *CrawlState) Set(id string, e CrawlEntry) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	e.UpdatedAt = time.Now()
+	this.Items[id] = &e
+	return this.save()
+}
+
+// save grava o estado em um arquivo temporário e o renomeia, para não corromper o estado
+// anterior se o processo for interrompido no meio da escrita.
+func (this *CrawlState) save() error {
+	if this.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(this, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(this.path), 0755); err != nil {
+		return err
+	}
+	tmp := this.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, this.path)
+}
+
+// Checksum retorna o SHA-256 (hex) de data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetcher(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		case "/down":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(0)
+	f.Backoff = time.Millisecond
+	f.MaxRetries = 3
+	ctx := context.Background()
+
+	body, err := f.Get(ctx, srv.URL+"/flaky", nil)
+	if err != nil || string(body) != "ok" || calls.Load() != 3 {
+		t.Fatalf("flaky: %q %v after %d calls", body, err, calls.Load())
+	}
+	if _, err = f.Get(ctx, srv.URL+"/down", nil); !errors.Is(err, ErrHTTPStatus) {
+		t.Fatalf("down: expected ErrHTTPStatus, got %v", err)
+	}
+
+	// 404 não é repetido
+	start := time.Now()
+	f.Backoff = time.Second
+	if _, err = f.Get(ctx, srv.URL+"/missing", nil); !errors.Is(err, ErrHTTPStatus) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("missing: %v in %v", err, time.Since(start))
+	}
+}
+
+func TestFetcherRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	f := NewFetcher(30 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := f.Get(context.Background(), srv.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("4 requests took %v; expected >= 90ms", d)
+	}
+}
+
+func TestCrawlState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadCrawlState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := Checksum([]byte("pdf"))
+	if _, ok := state.Claim("cd_1", sum); !ok {
+		t.Fatal("first claim rejected")
+	}
+	state.Set("cd_1", CrawlEntry{Status: CrawlDone, File: "cd_1.pdf", Checksum: sum, Pages: 3, Bytes: 1024})
+	state.Set("cd_2", CrawlEntry{Status: CrawlFailed, Error: "timeout"})
+
+	resumed, err := LoadCrawlState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Pending("cd_1") || !resumed.Pending("cd_2") || !resumed.Pending("cd_3") {
+		t.Fatal("wrong pending items after resume")
+	}
+	if docs, pages, size := resumed.Totals(""); docs != 1 || pages != 3 || size != 1024 {
+		t.Fatalf("totals = %d docs, %d pages, %d bytes", docs, pages, size)
+	}
+	if other, ok := resumed.Claim("cd_4", sum); ok || other != "cd_1" {
+		t.Fatalf("duplicate not detected: %q %v", other, ok)
+	}
+}
+
+func TestCrawlStateLegacyKeys(t *testing.T) {
+	// Estado gravado antes das fontes: chaves com o ID da proposição da Câmara
+	path := filepath.Join(t.TempDir(), "state.json")
+	sum := Checksum([]byte("pdf"))
+	legacy := `{"items": {
+		"2196833": {"status": "done", "file": "2196833.pdf", "checksum": "` + sum + `", "pages": 3, "bytes": 1024},
+		"2196834": {"status": "duplicate", "checksum": "` + sum + `", "dupOf": "2196833"},
+		"2196835": {"status": "failed", "error": "timeout"},
+		"sf_150000": {"status": "done", "pages": 1, "bytes": 10}
+	}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := LoadCrawlState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Pending("cd_2196833") || state.Pending("cd_2196834") || !state.Pending("cd_2196835") || state.Pending("sf_150000") {
+		t.Error("legacy items not resumed under their prefixed keys")
+	}
+	if _, ok := state.Get("2196833"); ok {
+		t.Error("legacy key kept")
+	}
+	if e, _ := state.Get("cd_2196834"); e.DupOf != "cd_2196833" {
+		t.Errorf("DupOf = %q, want cd_2196833", e.DupOf)
+	}
+	if docs, pages, size := state.Totals("cd_"); docs != 1 || pages != 3 || size != 1024 {
+		t.Errorf("totals = %d docs, %d pages, %d bytes", docs, pages, size)
+	}
+	if other, ok := state.Claim("cd_9", sum); ok || other != "cd_2196833" {
+		t.Errorf("duplicate not detected: %q %v", other, ok)
+	}
+}
@@ -0,0 +1,246 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+// DedupOptions configura a detecção de quase-duplicatas por MinHash/LSH.
+type DedupOptions struct {
+	ShingleSize int     // Palavras por shingle (n-gramas contíguos de utils.GetGrams)
+	Hashes      int     // Tamanho da assinatura MinHash
+	Bands       int     // Faixas do LSH (Hashes/Bands linhas por faixa)
+	Threshold   float64 // Similaridade de Jaccard estimada mínima
+	Seed        uint64
+}
+
+// DefaultDedupOptions usa shingles de 3 palavras, 128 hashes em 16 faixas (candidatos a
+// partir de ~0,7 de similaridade) e confirma os pares com similaridade estimada >= 0,8.
+func DefaultDedupOptions() DedupOptions {
+	return DedupOptions{ShingleSize: 3, Hashes: 128, Bands: 16, Threshold: 0.8, Seed: 1}
+}
+
+// DupMember é uma quase-duplicata e a sua similaridade estimada com o documento canônico.
+type DupMember struct {
+	Doc        *models.Document
+	Similarity float64
+}
+
+// DupCluster é um grupo de quase-duplicatas. O documento canônico é o de menor ID (o
+// primeiro registrado); Duplicates fica em ordem de similaridade decrescente.
+type DupCluster struct {
+	Canonical  *models.Document
+	Duplicates []DupMember
+}
+
+// FindDuplicates agrupa os documentos indexados (CachePositions) cujos conjuntos de shingles
+// têm similaridade de Jaccard estimada >= opts.Threshold. Pares candidatos vêm do LSH e os
+// grupos são o fecho transitivo dos pares confirmados. Documentos com menos palavras que
+// opts.ShingleSize são ignorados.
+func FindDuplicates(ctx context.Context, opts DedupOptions) ([]DupCluster, error) {
+	if CacheDocs == nil || CachePositions == nil {
+		return nil, ErrIndexNotReady
+	}
+	byId := make(map[uint16]*models.Document, len(CacheDocs))
+	for _, doc := range CacheDocs {
+		byId[doc.ID] = doc
+	}
+
+	hasher := utils.NewMinHasher(opts.Hashes, opts.Seed)
+	sigs := make(map[uint16][]uint64)
+	buckets := make(map[uint64][]uint16)
+	for id, tokens := range docTokens() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		grams := utils.GetGrams(tokens, opts.ShingleSize, 0)
+		if len(grams) == 0 {
+			continue
+		}
+		sig := hasher.Signature(utils.HashShingles(grams, func(t uint16) uint64 { return uint64(t) }))
+		sigs[id] = sig
+		for _, key := range utils.LSHBands(sig, opts.Bands) {
+			buckets[key] = append(buckets[key], id)
+		}
+	}
+
+	// Confirma os candidatos e une os grupos (union-find, raiz = menor ID)
+	parent := make(map[uint16]uint16)
+	var find func(id uint16) uint16
+	find = func(id uint16) uint16 {
+		p, ok := parent[id]
+		if !ok || p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	checked := make(map[[2]uint16]bool)
+	for _, ids := range buckets {
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				a, b := min(ids[i], ids[j]), max(ids[i], ids[j])
+				if checked[[2]uint16{a, b}] {
+					continue
+				}
+				checked[[2]uint16{a, b}] = true
+				sim, err := utils.MinHashSimilarity(sigs[a], sigs[b])
+				if err != nil {
+					return nil, err
+				}
+				if sim >= opts.Threshold {
+					ra, rb := find(a), find(b)
+					if ra != rb {
+						parent[max(ra, rb)] = min(ra, rb)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[uint16][]uint16)
+	for id := range parent {
+		root := find(id)
+		if id != root {
+			groups[root] = append(groups[root], id)
+		}
+	}
+
+	ret := make([]DupCluster, 0, len(groups))
+	for root, members := range groups {
+		c := DupCluster{Canonical: byId[root]}
+		for _, id := range members {
+			sim, _ := utils.MinHashSimilarity(sigs[root], sigs[id])
+			c.Duplicates = append(c.Duplicates, DupMember{Doc: byId[id], Similarity: sim})
+		}
+		sort.Slice(c.Duplicates, func(i, j int) bool {
+			if c.Duplicates[i].Similarity == c.Duplicates[j].Similarity {
+				return c.Duplicates[i].Doc.ID < c.Duplicates[j].Doc.ID
+			}
+			return c.Duplicates[i].Similarity > c.Duplicates[j].Similarity
+		})
+		ret = append(ret, c)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if len(ret[i].Duplicates) == len(ret[j].Duplicates) {
+			return ret[i].Canonical.ID < ret[j].Canonical.ID
+		}
+		return len(ret[i].Duplicates) > len(ret[j].Duplicates)
+	})
+	return ret, nil
+}
+
+// docTokens reconstrói, a partir de CachePositions, a sequência de IDs de palavras de cada
+// documento indexado. Posições sem palavra (tokens fora de CacheWords, que indexPositions
+// ignora) são descartadas, e as palavras vizinhas ficam adjacentes.
+func docTokens() map[uint16][]uint16 {
+	type posWord struct {
+		pos  int
+		word uint16
+	}
+	byDoc := make(map[uint16][]posWord)
+	for wordId, docs := range CachePositions {
+		for docId, positions := range docs {
+			for _, p := range positions {
+				byDoc[docId] = append(byDoc[docId], posWord{p, wordId})
+			}
+		}
+	}
+
+	ret := make(map[uint16][]uint16, len(byDoc))
+	for docId, words := range byDoc {
+		sort.Slice(words, func(i, j int) bool { return words[i].pos < words[j].pos })
+		tokens := make([]uint16, len(words))
+		for i, w := range words {
+			tokens[i] = w.word
+		}
+		ret[docId] = tokens
+	}
+	return ret
+}
+
+// MarkDuplicates grava em Document.DupOf o documento canônico de cada quase-duplicata de
+// clusters (e zera o dos demais documentos), no banco e em CacheDocs. O banco pode ser outro
+// que não o do índice (ex.: utils.OpenDB(DbFile)), desde que tenha os mesmos documentos;
+// uma duplicata ausente dele é um erro.
+func MarkDuplicates(db *gorm.DB, clusters []DupCluster) error {
+	dupOf := make(map[uint16]uint16)
+	for _, c := range clusters {
+		for _, m := range c.Duplicates {
+			dupOf[m.Doc.ID] = c.Canonical.ID
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Document{}).Where("dup_of <> 0").Update("dup_of", 0).Error; err != nil {
+			return fmt.Errorf("%w: failed to reset duplicates: %v", utils.ErrDatabase, err)
+		}
+		for id, canonical := range dupOf {
+			res := tx.Model(&models.Document{}).Where("id = ?", id).Update("dup_of", canonical)
+			if res.Error != nil {
+				return fmt.Errorf("%w: failed to mark duplicate %d: %v", utils.ErrDatabase, id, res.Error)
+			}
+			if res.RowsAffected == 0 {
+				return fmt.Errorf("%w: duplicate %d not found", utils.ErrDatabase, id)
+			}
+		}
+		for _, doc := range CacheDocs {
+			doc.DupOf = dupOf[doc.ID]
+		}
+		return nil
+	})
+}
+
+// CollapseDuplicates remove do índice (caches e tabelas WORD_DOC e WORD_POS) os documentos
+// marcados como quase-duplicatas por MarkDuplicates, mantendo apenas o canônico de cada
+// grupo, para que não inflem a frequência de documentos dos termos nem apareçam nos
+// resultados. Os documentos continuam em DOCUMENT. Retorna quantos foram removidos.
+func CollapseDuplicates(db *gorm.DB) (int, error) {
+	var ids []uint16
+	for name, doc := range CacheDocs {
+		if doc.DupOf == 0 {
+			continue
+		}
+		ids = append(ids, doc.ID)
+		delete(CacheDocs, name)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	for _, id := range ids {
+		for _, g := range Docs[id] {
+			key := g.GetCacheKey(true, false)
+			delete(CacheGrams[key], id)
+			if len(CacheGrams[key]) == 0 {
+				delete(CacheGrams, key)
+			}
+			// IndexDocsGrams soma uma ocorrência a CountAllNGrams a cada incremento, exceto o
+			// da criação do n-grama
+			CountAllNGrams -= g.GetCount() - 1
+		}
+		delete(Docs, id)
+		for wordId, docs := range CachePositions {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(CachePositions, wordId)
+			}
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"WORD_DOC", "WORD_POS"} {
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE docId IN ?", table), ids).Error; err != nil {
+				return fmt.Errorf("%w: failed to collapse duplicates in %s: %v", utils.ErrDatabase, table, err)
+			}
+		}
+		return nil
+	})
+	return len(ids), err
+}
@@ -0,0 +1,204 @@
+package corpus
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// dedupText gera um texto de n palavras distintas a partir de prefix.
+func dedupText(prefix string, n int) []string {
+	ret := make([]string, n)
+	for i := range ret {
+		ret[i] = prefix + string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+	return ret
+}
+
+// dedupCorpus tem um par de quase-duplicatas (doc_0001 e doc_0003, uma palavra trocada) e
+// um documento distinto.
+func dedupCorpus() map[string]string {
+	words := dedupText("termo", 80)
+	edited := append([]string(nil), words...)
+	edited[40] = "alterado"
+	return map[string]string{
+		"doc_0001_clean.txt": strings.Join(words, " "),
+		"doc_0002_clean.txt": strings.Join(dedupText("outro", 80), " "),
+		"doc_0003_clean.txt": strings.Join(edited, " "),
+	}
+}
+
+func TestDocTokens(t *testing.T) {
+	CachePositions = map[uint16]map[uint16][]int{
+		7: {1: {0, 4}},
+		9: {1: {2}, 2: {0}},
+	}
+	t.Cleanup(ResetCache)
+
+	// As lacunas (posições 1 e 3) são descartadas em vez de virarem a palavra 0.
+	want := map[uint16][]uint16{1: {7, 9, 7}, 2: {9}}
+	if got := docTokens(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	setupCorpus(t, dedupCorpus())
+	db := openIndex(t, 1, true)
+
+	for _, doc := range CacheDocs {
+		if doc.DupOf != 0 {
+			t.Fatalf("CreateDatabaseCaches should not detect duplicates, got %+v", doc)
+		}
+	}
+
+	clusters, err := FindDuplicates(context.Background(), DefaultDedupOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || len(clusters[0].Duplicates) != 1 {
+		t.Fatalf("expected one pair, got %+v", clusters)
+	}
+	c := clusters[0]
+	canonical, dup := CacheDocs["doc_0001_clean.txt"], CacheDocs["doc_0003_clean.txt"]
+	if c.Canonical != canonical || c.Duplicates[0].Doc != dup || c.Duplicates[0].Similarity < DefaultDedupOptions().Threshold {
+		t.Errorf("unexpected cluster %+v", c)
+	}
+
+	strict := DefaultDedupOptions()
+	strict.Threshold = 1
+	if clusters, err := FindDuplicates(context.Background(), strict); err != nil || len(clusters) != 0 {
+		t.Errorf("expected no identical documents, got %+v (%v)", clusters, err)
+	}
+
+	// MarkDuplicates grava DupOf no banco e nos caches; uma nova chamada substitui a anterior.
+	if err = MarkDuplicates(db, clusters); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.Document
+	if err = db.First(&stored, dup.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.DupOf != canonical.ID || dup.DupOf != canonical.ID || canonical.DupOf != 0 {
+		t.Errorf("expected %d to be marked as a duplicate of %d, got db %d cache %d", dup.ID, canonical.ID, stored.DupOf, dup.DupOf)
+	}
+	if err = MarkDuplicates(db, nil); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err = db.Model(&models.Document{}).Where("dup_of <> 0").Count(&n).Error; err != nil || n != 0 || dup.DupOf != 0 {
+		t.Errorf("expected marks to be reset, got %d (%v)", n, err)
+	}
+
+	missing := []DupCluster{{Canonical: canonical, Duplicates: []DupMember{{Doc: &models.Document{ID: 999}}}}}
+	if err = MarkDuplicates(db, missing); err == nil {
+		t.Error("expected an error for a duplicate missing from the database")
+	}
+}
+
+func TestMarkDuplicatesPersist(t *testing.T) {
+	setupCorpus(t, dedupCorpus())
+	openIndex(t, 1, true)
+	ResetCache()
+
+	// Como em cmd_dedup: o índice roda numa cópia e as marcas vão para DbFile.
+	openIndex(t, 2, false)
+	clusters, err := FindDuplicates(context.Background(), DefaultDedupOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := utils.OpenDB(DbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = MarkDuplicates(base, clusters)
+	if sqlDB, e := base.DB(); e == nil {
+		_ = sqlDB.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ResetCache()
+	openIndex(t, 3, false)
+	if got, want := CacheDocs["doc_0003_clean.txt"].DupOf, CacheDocs["doc_0001_clean.txt"].ID; got != want {
+		t.Errorf("expected the reloaded index to keep DupOf = %d, got %d", want, got)
+	}
+}
+
+func TestCollapseDuplicates(t *testing.T) {
+	docs := dedupCorpus()
+	distinct := map[string]string{"doc_0001_clean.txt": docs["doc_0001_clean.txt"], "doc_0002_clean.txt": docs["doc_0002_clean.txt"]}
+	var wantCount, wantGrams int
+	t.Run("distinct", func(t *testing.T) {
+		setupCorpus(t, distinct)
+		openIndex(t, 1, true)
+		wantCount, wantGrams = CountAllNGrams, len(CacheGrams)
+	})
+
+	setupCorpus(t, docs)
+	db := openIndex(t, 1, true)
+	clusters, err := FindDuplicates(context.Background(), DefaultDedupOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = MarkDuplicates(db, clusters); err != nil {
+		t.Fatal(err)
+	}
+	dup := CacheDocs["doc_0003_clean.txt"]
+
+	n, err := CollapseDuplicates(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(CacheDocs) != 2 || CacheDocs["doc_0003_clean.txt"] != nil || Docs[dup.ID] != nil {
+		t.Fatalf("expected doc_0003 to leave the caches, got %d removed, %d docs", n, len(CacheDocs))
+	}
+	// O índice fica como se a duplicata nunca tivesse sido indexada.
+	if CountAllNGrams != wantCount || len(CacheGrams) != wantGrams {
+		t.Errorf("expected %d n-grams (%d distinct), got %d (%d)", wantCount, wantGrams, CountAllNGrams, len(CacheGrams))
+	}
+	for _, docs := range CachePositions {
+		if _, ok := docs[dup.ID]; ok {
+			t.Fatal("collapsed document left in CachePositions")
+		}
+	}
+	for _, table := range []string{"WORD_DOC", "WORD_POS"} {
+		var rows int64
+		if err = db.Table(table).Where("docId = ?", dup.ID).Count(&rows).Error; err != nil || rows != 0 {
+			t.Errorf("%s: expected no rows for the duplicate, got %d (%v)", table, rows, err)
+		}
+	}
+	var stored int64
+	if err = db.Model(&models.Document{}).Count(&stored).Error; err != nil || stored != 3 {
+		t.Errorf("expected the document to stay in DOCUMENT, got %d (%v)", stored, err)
+	}
+
+	if n, err = CollapseDuplicates(db); err != nil || n != 0 {
+		t.Errorf("expected nothing left to collapse, got %d (%v)", n, err)
+	}
+}
+
+func TestCollapseHits(t *testing.T) {
+	a := &models.Document{ID: 1}
+	b := &models.Document{ID: 2}
+	dupA := &models.Document{ID: 3, DupOf: 1}
+	dupA2 := &models.Document{ID: 4, DupOf: 1}
+
+	// A duplicata mais bem ranqueada representa o grupo e as demais, incluindo o canônico,
+	// ficam em Duplicates.
+	hits := collapseHits([]Hit{{Doc: dupA, Score: 0.9}, {Doc: b, Score: 0.8}, {Doc: a, Score: 0.7}, {Doc: dupA2, Score: 0.1}})
+	if len(hits) != 2 || hits[0].Doc != dupA || hits[1].Doc != b {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+	if got := hits[0].Duplicates; len(got) != 2 || got[0] != a || got[1] != dupA2 {
+		t.Errorf("unexpected duplicates %+v", got)
+	}
+	if len(hits[1].Duplicates) != 0 {
+		t.Errorf("expected no duplicates for %d, got %+v", b.ID, hits[1].Duplicates)
+	}
+}
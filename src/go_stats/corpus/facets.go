@@ -0,0 +1,114 @@
+package corpus
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/models"
+)
+
+// FacetFunc retorna os valores de um documento para uma faceta (vários para autores).
+type FacetFunc func(doc *models.Document) []string
+
+// FacetFuncs são as facetas aceitas por SearchFaceted. Os nomes coincidem com os filtros de
+// campo, de modo que um valor contado, escrito com FilterValue, pode ser usado diretamente na
+// consulta (tipo:PEC, autor:João_da_Silva).
+var FacetFuncs = map[string]FacetFunc{}
+
+// DefaultFacets são as facetas calculadas quando SearchOptions.Facets não é informado.
+var DefaultFacets = []string{"tipo", "ano", "autor", "status"}
+
+// RegisterFacet registra uma faceta.
+func RegisterFacet(name string, f FacetFunc) {
+	FacetFuncs[strings.ToLower(name)] = f
+}
+
+func init() {
+	RegisterFacet("kind", func(doc *models.Document) []string {
+		return nonEmpty(string(doc.Kind))
+	})
+	RegisterFacet("fonte", func(doc *models.Document) []string {
+		return nonEmpty(doc.Source)
+	})
+	RegisterFacet("tipo", func(doc *models.Document) []string {
+		return nonEmpty(doc.ProposalType())
+	})
+	RegisterFacet("ano", func(doc *models.Document) []string {
+		if y := doc.ProposalYear(); y != 0 {
+			return []string{strconv.Itoa(y)}
+		}
+		return nil
+	})
+	RegisterFacet("autor", func(doc *models.Document) []string {
+		return doc.AuthorList()
+	})
+	RegisterFacet("tema", func(doc *models.Document) []string {
+		return doc.ThemeList()
+	})
+	RegisterFacet("status", func(doc *models.Document) []string {
+		return nonEmpty(doc.Status)
+	})
+}
+
+func nonEmpty(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return []string{v}
+}
+
+// FacetCount é a quantidade de documentos encontrados com um valor de faceta.
+type FacetCount struct {
+	Value string
+	Count int
+}
+
+// Facet agrupa os documentos encontrados pelos valores de uma faceta. Missing conta os
+// documentos sem valor (ex.: sem metadados).
+type Facet struct {
+	Name    string
+	Values  []FacetCount
+	Missing int
+}
+
+// CountFacets conta, para cada faceta em names, os documentos de hits por valor, do mais para
+// o menos frequente (empates em ordem alfabética). limit > 0 mantém apenas os limit primeiros
+// valores de cada faceta.
+func CountFacets(hits []Hit, names []string, limit int) ([]Facet, error) {
+	ret := make([]Facet, 0, len(names))
+	for _, name := range names {
+		f, ok := FacetFuncs[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown facet %q", name)
+		}
+
+		facet := Facet{Name: strings.ToLower(name)}
+		counts := make(map[string]int)
+		for _, h := range hits {
+			values := f(h.Doc)
+			if len(values) == 0 {
+				facet.Missing++
+			}
+			for _, v := range values {
+				counts[v]++
+			}
+		}
+
+		for v, c := range counts {
+			facet.Values = append(facet.Values, FacetCount{Value: v, Count: c})
+		}
+		sort.Slice(facet.Values, func(i, j int) bool {
+			if facet.Values[i].Count == facet.Values[j].Count {
+				return facet.Values[i].Value < facet.Values[j].Value
+			}
+			return facet.Values[i].Count > facet.Values[j].Count
+		})
+		if limit > 0 && len(facet.Values) > limit {
+			facet.Values = facet.Values[:limit]
+		}
+		ret = append(ret, facet)
+	}
+	return ret, nil
+}
@@ -0,0 +1,60 @@
+package corpus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/models"
+)
+
+func TestCountFacets(t *testing.T) {
+	hits := []Hit{
+		{Doc: &models.Document{ID: 1, Name: "doc_0001_clean.txt", Type: "PEC", Year: 2019, Authors: "João da Silva; Maria Souza", Status: "Arquivada"}},
+		{Doc: &models.Document{ID: 2, Name: "doc_0002_clean.txt", Type: "PL", Year: 2019, Authors: "Maria Souza", Status: "Aguardando Parecer do Relator (CCJC)"}},
+		{Doc: &models.Document{ID: 3, Name: "doc_0003_clean.txt", Type: "PL", Year: 2021, Authors: "José Lima"}},
+		{Doc: &models.Document{ID: 4, Name: "doc_0004_clean.txt"}},
+	}
+
+	facets, err := CountFacets(hits, []string{"tipo", "ANO", "autor", "status"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Facet{
+		{Name: "tipo", Values: []FacetCount{{"PL", 2}, {"PEC", 1}}, Missing: 1},
+		{Name: "ano", Values: []FacetCount{{"2019", 2}, {"2021", 1}}, Missing: 1},
+		{Name: "autor", Values: []FacetCount{{"Maria Souza", 2}, {"José Lima", 1}, {"João da Silva", 1}}, Missing: 1},
+		{Name: "status", Values: []FacetCount{{"Aguardando Parecer do Relator (CCJC)", 1}, {"Arquivada", 1}}, Missing: 2},
+	}
+	if !reflect.DeepEqual(facets, want) {
+		t.Errorf("got %+v\nwant %+v", facets, want)
+	}
+
+	if facets, err = CountFacets(hits, []string{"autor"}, 1); err != nil || len(facets[0].Values) != 1 || facets[0].Values[0].Value != "Maria Souza" {
+		t.Errorf("limit: got %+v %v", facets, err)
+	}
+	if _, err = CountFacets(hits, []string{"nope"}, 0); err == nil {
+		t.Error("expected error for unknown facet")
+	}
+
+	// Os valores impressos por FilterValue voltam a casar com os mesmos documentos
+	for _, f := range []Facet{want[2], want[3]} {
+		for _, v := range f.Values {
+			n := 0
+			for _, h := range hits {
+				ok, err := FieldFilters[f.Name](h.Doc, FilterValue(v.Value))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok {
+					n++
+				}
+			}
+			if n != v.Count {
+				t.Errorf("%s:%s matched %d documents, want %d", f.Name, FilterValue(v.Value), n, v.Count)
+			}
+		}
+	}
+	if got := FilterValue("Aguardando Parecer do Relator (CCJC)"); got != "Aguardando_Parecer_do_Relator_CCJC" {
+		t.Errorf("FilterValue: got %q", got)
+	}
+}
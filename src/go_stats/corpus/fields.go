@@ -0,0 +1,136 @@
+package corpus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/query"
+)
+
+// FieldFilter informa se o documento satisfaz o filtro "campo:valor" de uma consulta.
+type FieldFilter func(doc *models.Document, value string) (bool, error)
+
+// FieldFilters são os filtros de campo aceitos por Search.
+var FieldFilters = map[string]FieldFilter{}
+
+// RegisterFieldFilter registra um filtro de campo e o habilita no parser de consultas.
+func RegisterFieldFilter(name string, f FieldFilter) {
+	FieldFilters[strings.ToLower(name)] = f
+	query.RegisterField(name)
+}
+
+func init() {
+	RegisterFieldFilter("kind", func(doc *models.Document, value string) (bool, error) {
+		return strings.EqualFold(string(doc.Kind), value), nil
+	})
+	RegisterFieldFilter("fonte", func(doc *models.Document, value string) (bool, error) {
+		return strings.EqualFold(doc.Source, value), nil
+	})
+	RegisterFieldFilter("tipo", func(doc *models.Document, value string) (bool, error) {
+		return strings.EqualFold(doc.ProposalType(), value), nil
+	})
+	RegisterFieldFilter("ano", func(doc *models.Document, value string) (bool, error) {
+		return matchIntRange(doc.ProposalYear(), value)
+	})
+	RegisterFieldFilter("numero", func(doc *models.Document, value string) (bool, error) {
+		return matchIntRange(doc.Number, value)
+	})
+	RegisterFieldFilter("autor", func(doc *models.Document, value string) (bool, error) {
+		return anyContainsFolded(doc.AuthorList(), value), nil
+	})
+	RegisterFieldFilter("tema", func(doc *models.Document, value string) (bool, error) {
+		return anyContainsFolded(doc.ThemeList(), value), nil
+	})
+	RegisterFieldFilter("status", func(doc *models.Document, value string) (bool, error) {
+		return containsFolded(doc.Status, value), nil
+	})
+	RegisterFieldFilter("apresentacao", func(doc *models.Document, value string) (bool, error) {
+		return matchDateRange(doc.SubmittedAt, value)
+	})
+}
+
+// FilterValue converte um valor de metadado em um valor de filtro que pode ser colado na
+// consulta ("João da Silva" -> autor:João_da_Silva): espaços, aspas e parênteses, que
+// encerrariam o filtro, viram '_'.
+func FilterValue(value string) string {
+	return strings.Join(strings.FieldsFunc(value, isFilterDelim), "_")
+}
+
+func isFilterDelim(r rune) bool {
+	return unicode.IsSpace(r) || r == '_' || r == '"' || r == '(' || r == ')'
+}
+
+// containsFolded informa se s contém value, sem diferenciar maiúsculas e acentos. Nos valores
+// de consulta '_' vale como espaço (autor:joao_silva); aspas e parênteses de s são ignorados,
+// de forma que os valores de FilterValue sempre casam.
+func containsFolded(s, value string) bool {
+	if s == "" {
+		return false
+	}
+	s = strings.Join(strings.FieldsFunc(s, isFilterDelim), " ")
+	value = strings.Join(strings.FieldsFunc(value, isFilterDelim), " ")
+	return strings.Contains(analysis.Fold(strings.ToLower(s)), analysis.Fold(strings.ToLower(value)))
+}
+
+// anyContainsFolded informa se algum item de list contém value (ver containsFolded).
+func anyContainsFolded(list []string, value string) bool {
+	for _, s := range list {
+		if containsFolded(s, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDateRange aceita uma data ("2019-03-12") ou um intervalo fechado como em matchIntRange.
+func matchDateRange(t *time.Time, value string) (bool, error) {
+	if t == nil {
+		return false, nil
+	}
+	lo, hi, isRange := strings.Cut(value, "..")
+	if !isRange {
+		hi = lo
+	}
+
+	day := t.Format(time.DateOnly)
+	for _, s := range []string{lo, hi} {
+		if _, err := time.Parse(time.DateOnly, s); s != "" && err != nil {
+			return false, fmt.Errorf("%w: invalid date %q", query.ErrSyntax, s)
+		}
+	}
+	return (lo == "" || day >= lo) && (hi == "" || day <= hi), nil
+}
+
+// matchIntRange aceita um número ("2019") ou um intervalo fechado ("2015..2019", "..2010",
+// "2020..").
+func matchIntRange(n int, value string) (bool, error) {
+	lo, hi, isRange := strings.Cut(value, "..")
+	if !isRange {
+		hi = lo
+	}
+
+	parse := func(s string, def int) (int, error) {
+		if s == "" {
+			return def, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid number %q", query.ErrSyntax, s)
+		}
+		return v, nil
+	}
+	from, err := parse(lo, 0)
+	if err != nil {
+		return false, err
+	}
+	to, err := parse(hi, int(^uint(0)>>1))
+	if err != nil {
+		return false, err
+	}
+	return n != 0 && n >= from && n <= to, nil
+}
@@ -0,0 +1,116 @@
+package corpus
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/query"
+)
+
+func TestProposalFilters(t *testing.T) {
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "pec n apresentacao altera constituicao federal saneamento basico",
+		"doc_0002_clean.txt": "pl n apresentacao dispoe ausencia justificada servico",
+	})
+	submitted := time.Date(2019, 3, 12, 0, 0, 0, 0, time.UTC)
+	if err := os.MkdirAll(MetaDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := WriteMeta(MetaDir+"/"+MetaFileName("doc_0001_clean.txt"), &models.DocumentMeta{
+		Source: "camara", ProposalID: "2196833", Type: "pec", Number: 45, Year: 2019, SubmittedAt: &submitted,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	openIndex(t, 1, false)
+
+	all := make(map[uint16]*models.Document, len(CacheDocs))
+	for _, doc := range CacheDocs {
+		all[doc.ID] = doc
+	}
+	// Sem metadados, os nomes reais do corpus (doc_NNNN_clean.txt) não informam sigla nem ano
+	// e só casam nomes no formato "PEC 45/2019".
+	legacy := &models.Document{ID: 99, Name: "PL 12/2015"}
+	all[legacy.ID] = legacy
+
+	for q, want := range map[string][]string{
+		"tipo:PEC":                  {"doc_0001_clean.txt"},
+		"tipo:pl":                   {"PL 12/2015"},
+		"ano:2019":                  {"doc_0001_clean.txt"},
+		"ano:2010..2016":            {"PL 12/2015"},
+		"ano:..2030":                {"doc_0001_clean.txt", "PL 12/2015"},
+		"-ano:..2030":               {"doc_0002_clean.txt"},
+		"apresentacao:2019-01-01..": {"doc_0001_clean.txt"},
+	} {
+		n, err := query.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := matchDocs(n, all)
+		if err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d documents, want %v", q, len(got), want)
+			continue
+		}
+		for _, name := range want {
+			doc := CacheDocs[name]
+			if doc == nil {
+				doc = legacy
+			}
+			if !got[doc.ID] {
+				t.Errorf("%s: %s not matched", q, name)
+			}
+		}
+	}
+}
+
+func TestMatchDateRange(t *testing.T) {
+	day := time.Date(2019, 3, 12, 15, 30, 0, 0, time.UTC)
+	for value, want := range map[string]bool{
+		"2019-03-12":             true,
+		"2019-03-11":             false,
+		"2019-03-01..2019-03-31": true,
+		"2019-03-12..2019-03-12": true,
+		"..2019-03-12":           true,
+		"2019-03-13..":           false,
+		"..":                     true,
+	} {
+		got, err := matchDateRange(&day, value)
+		if err != nil || got != want {
+			t.Errorf("matchDateRange(%q) = %v %v, want %v", value, got, err, want)
+		}
+	}
+	if got, err := matchDateRange(nil, "2019-03-12"); got || err != nil {
+		t.Errorf("nil date: got %v %v", got, err)
+	}
+	for _, value := range []string{"2019", "12/03/2019..", "..2019-02-30"} {
+		if _, err := matchDateRange(&day, value); !errors.Is(err, query.ErrSyntax) {
+			t.Errorf("matchDateRange(%q): expected ErrSyntax, got %v", value, err)
+		}
+	}
+}
+
+func TestContainsFolded(t *testing.T) {
+	for _, c := range []struct {
+		s, value string
+		want     bool
+	}{
+		{"João da Silva", "joao", true},
+		{"João da Silva", "JOÃO_DA_SILVA", true},
+		{"João da Silva", "silva_joao", false},
+		{"Aguardando Designação de Relator", "designacao", true},
+		{"", "", false},
+	} {
+		if got := containsFolded(c.s, c.value); got != c.want {
+			t.Errorf("containsFolded(%q, %q) = %v, want %v", c.s, c.value, got, c.want)
+		}
+	}
+	if !anyContainsFolded([]string{"Saúde", "Educação"}, "educacao") || anyContainsFolded(nil, "saude") {
+		t.Error("anyContainsFolded mismatch")
+	}
+}
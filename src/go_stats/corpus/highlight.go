@@ -0,0 +1,254 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/query"
+	"gorm.io/gorm"
+)
+
+// TxtDir guarda o texto original (não limpo) extraído de cada documento por TextProcessor.
+const TxtDir = "./../../misc/corpus/txt"
+
+// Snippet é um trecho do texto original de um documento com os termos da consulta marcados.
+type Snippet struct {
+	Text  string  // Trecho com espaços normalizados e termos entre Pre e Post
+	Start int     // Início do trecho no texto original (bytes)
+	End   int     // Fim do trecho no texto original (bytes)
+	Page  int     // Página do PDF em que o trecho começa
+	Score float64 // Soma dos pesos (IDF) dos termos distintos encontrados no trecho
+}
+
+// HighlightOptions configura Highlight; valores zero usam os padrões indicados.
+type HighlightOptions struct {
+	Fragments int    // Máximo de trechos (3)
+	Window    int    // Tamanho de cada trecho em tokens (25)
+	Pre       string // Marca antes de cada termo («)
+	Post      string // Marca depois de cada termo (»)
+}
+
+func (this HighlightOptions) withDefaults() HighlightOptions {
+	if this.Fragments <= 0 {
+		this.Fragments = 3
+	}
+	if this.Window <= 0 {
+		this.Window = 25
+	}
+	if this.Pre == "" && this.Post == "" {
+		this.Pre, this.Post = "«", "»"
+	}
+	return this
+}
+
+// OriginalText lê o texto original de doc em TxtDir (doc_0001_clean.txt -> doc_0001.txt). Se
+// ele não existir, usa o próprio documento de Dir (ver ReadDocText): o texto extraído de
+// DOCX, ODT e HTML, ou o texto limpo, que ainda permite localizar os termos.
+func OriginalText(doc *models.Document) (string, error) {
+	name := strings.TrimSuffix(MetaFileName(doc.Name), ".json") + ".txt"
+	data, err := os.ReadFile(fmt.Sprintf("%s/%s", TxtDir, name))
+	if os.IsNotExist(err) {
+		return ReadDocText(context.Background(), fmt.Sprintf("%s/%s", Dir, doc.Name), doc.Kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Snippets gera os melhores trechos de doc para a consulta q (ver query.Parse), marcando os
+// termos positivos da consulta no texto original. Se db tiver o mapa de offsets do documento
+// (ver LoadOffsets), os tokens do texto limpo são levados ao original por ele; caso
+// contrário, o texto original é reanalisado.
+func Snippets(db *gorm.DB, doc *models.Document, q string, opts HighlightOptions) ([]Snippet, error) {
+	if Analyzer == nil {
+		return nil, ErrIndexNotReady
+	}
+	tree, err := query.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	text, err := OriginalText(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text of %s: %w", doc.Name, err)
+	}
+	terms := Analyzer.Analyze(query.Text(tree))
+
+	if db != nil {
+		spans, err := mappedSpans(db, doc, len(text))
+		if err != nil {
+			return nil, err
+		}
+		if spans != nil {
+			return highlightSpans(text, spans, terms, opts), nil
+		}
+	}
+	return Highlight(text, terms, opts), nil
+}
+
+// mappedSpans reconstrói os spans do texto original a partir do texto limpo e do mapa de
+// offsets do documento, passando cada token limpo pelo Analyzer do índice (ex.: stemming).
+// Tokens sem trecho no original (vazios, ver analysis.AlignSpans) ficam de fora. Retorna nil
+// se não houver mapa ou se ele não corresponder aos textos atuais.
+func mappedSpans(db *gorm.DB, doc *models.Document, textLen int) ([]analysis.Span, error) {
+	offsets, err := DocumentOffsets(db, doc.ID)
+	if err != nil || offsets == nil {
+		return nil, err
+	}
+	clean, err := os.ReadFile(fmt.Sprintf("%s/%s", Dir, doc.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clean text of %s: %w", doc.Name, err)
+	}
+	tokens := strings.Fields(string(clean))
+	if len(tokens) != len(offsets) || offsets[len(offsets)-1].End > textLen {
+		return nil, nil
+	}
+
+	var ret []analysis.Span
+	for i, tok := range tokens {
+		if offsets[i].Start == offsets[i].End {
+			continue
+		}
+		for _, t := range Analyzer.Analyze(tok) {
+			ret = append(ret, analysis.Span{Start: offsets[i].Start, End: offsets[i].End, Token: t})
+		}
+	}
+	return ret, nil
+}
+
+// Highlight escolhe em text os trechos de opts.Window tokens que cobrem mais termos (já
+// analisados) de terms, ponderados pelo IDF do índice, e os devolve na ordem do texto. Os
+// tokens limpos são levados de volta ao texto original com Analyzer.AnalyzeSpans. Sem
+// nenhum termo no texto, retorna apenas o começo do documento.
+func Highlight(text string, terms []string, opts HighlightOptions) []Snippet {
+	return highlightSpans(text, Analyzer.AnalyzeSpans(text), terms, opts)
+}
+
+func highlightSpans(text string, spans []analysis.Span, terms []string, opts HighlightOptions) []Snippet {
+	opts = opts.withDefaults()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	weights := termWeights(terms)
+	var matches []int
+	for i, s := range spans {
+		if _, ok := weights[s.Token]; ok {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return []Snippet{renderSnippet(text, spans, 0, min(opts.Window, len(spans)), nil, opts)}
+	}
+
+	// Candidatos: uma janela por ocorrência, começando um pouco antes dela para dar contexto.
+	type window struct {
+		start, end int
+		score      float64
+	}
+	var candidates []window
+	for _, m := range matches {
+		start := max(0, m-opts.Window/5)
+		end := min(len(spans), start+opts.Window)
+		seen := make(map[string]bool)
+		score := 0.0
+		for _, s := range spans[start:end] {
+			if w, ok := weights[s.Token]; ok && !seen[s.Token] {
+				seen[s.Token] = true
+				score += w
+			}
+		}
+		candidates = append(candidates, window{start: start, end: end, score: score})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var chosen []window
+	for _, c := range candidates {
+		overlaps := false
+		for _, o := range chosen {
+			if c.start < o.end && o.start < c.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			chosen = append(chosen, c)
+			if len(chosen) == opts.Fragments {
+				break
+			}
+		}
+	}
+	sort.Slice(chosen, func(i, j int) bool {
+		return chosen[i].start < chosen[j].start
+	})
+
+	ret := make([]Snippet, len(chosen))
+	for i, c := range chosen {
+		ret[i] = renderSnippet(text, spans, c.start, c.end, weights, opts)
+		ret[i].Score = c.score
+	}
+	return ret
+}
+
+// termWeights dá a cada termo o seu IDF no índice carregado (1 sem índice).
+func termWeights(terms []string) map[string]float64 {
+	ret := make(map[string]float64, len(terms))
+	for _, t := range terms {
+		w := 1.0
+		if word, ok := CacheWords[t]; ok && len(CacheDocs) > 0 {
+			if df := len(CachePositions[word.ID]); df > 0 {
+				w = math.Log(1 + float64(len(CacheDocs))/float64(df))
+			}
+		}
+		ret[t] = w
+	}
+	return ret
+}
+
+// renderSnippet monta o texto de spans[start:end], marcando as palavras que geraram termos
+// da consulta (sem a pontuação em volta) e normalizando os espaços.
+func renderSnippet(text string, spans []analysis.Span, start, end int, weights map[string]float64, opts HighlightOptions) Snippet {
+	from, to := spans[start].Start, spans[end-1].End
+
+	var sb strings.Builder
+	last := from
+	for _, s := range spans[start:end] {
+		if _, ok := weights[s.Token]; !ok || s.Start < last {
+			continue
+		}
+		word := text[s.Start:s.End]
+		lo := s.Start + len(word) - len(strings.TrimLeftFunc(word, isPunct))
+		hi := s.Start + len(strings.TrimRightFunc(word, isPunct))
+		if lo >= hi {
+			lo, hi = s.Start, s.End
+		}
+		sb.WriteString(text[last:lo])
+		sb.WriteString(opts.Pre)
+		sb.WriteString(text[lo:hi])
+		sb.WriteString(opts.Post)
+		last = hi
+	}
+	sb.WriteString(text[last:to])
+
+	ret := strings.Join(strings.Fields(sb.String()), " ")
+	if from > 0 {
+		ret = "…" + ret
+	}
+	if to < len(text) {
+		ret += "…"
+	}
+	return Snippet{Text: ret, Start: from, End: to, Page: PageAt(text, from)}
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,131 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/models"
+	"gorm.io/gorm"
+)
+
+// highlightText é o texto original de doc_0001, em três páginas; os termos buscados aparecem
+// uma vez na primeira e três vezes na última.
+const highlightText = "Projeto de Lei nº 1, de 2024.\nDispõe sobre a licitação pública.\f" +
+	"Texto intermediário sem os termos buscados neste ponto do documento.\f" +
+	"Altera os contratos (licitação) e outros contratos administrativos da União. Fim."
+
+// setupHighlight indexa doc_0001 (texto limpo de highlightText) e doc_0002, grava o texto
+// original de doc_0001 em TxtDir e retorna o banco e o documento.
+func setupHighlight(t *testing.T) (*gorm.DB, *models.Document) {
+	t.Helper()
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "projeto lei:1 dispoe licitacao publica texto intermediario termos buscados documento altera contratos licitacao contratos administrativos uniao",
+		"doc_0002_clean.txt": "institui a política nacional de leitura",
+	})
+	if err := os.MkdirAll(TxtDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, TxtDir, map[string]string{"doc_0001.txt": highlightText})
+	db := openIndex(t, 1, true)
+	return db, CacheDocs["doc_0001_clean.txt"]
+}
+
+// saveOffsets substitui o mapa de offsets de doc.
+func saveOffsets(t *testing.T, db *gorm.DB, doc *models.Document, offsets []models.TokenOffset) {
+	t.Helper()
+	if err := db.Where("docId = ?", doc.ID).Delete(&models.DocOffsets{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(models.NewDocOffsets(doc.ID, models.EncodeOffsets(offsets))).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	db, doc := setupHighlight(t)
+	spans := Analyzer.AnalyzeSpans(highlightText)
+	clean, err := os.ReadFile(filepath.Join(Dir, doc.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != len(strings.Fields(string(clean))) {
+		t.Fatalf("fixture out of sync with the analyzer: %v", spans)
+	}
+	offsets := BuildOffsets(highlightText, spans)
+	opts := HighlightOptions{Fragments: 3, Window: 5, Pre: "[", Post: "]"}
+
+	// A janela da última página cobre os dois termos e vence as outras duas que a sobrepõem;
+	// a da primeira página entra em seguida, e os trechos saem na ordem do texto. As marcas
+	// deixam de fora a pontuação em volta da palavra.
+	want := []Snippet{
+		{Text: "…Dispõe sobre a [licitação] pública. Texto intermediário…", Start: strings.Index(highlightText, "Dispõe"), Page: 1},
+		{Text: "…Altera os [contratos] ([licitação]) e outros [contratos] administrativos…", Start: strings.Index(highlightText, "Altera"), Page: 3},
+	}
+	check := func(name string, got []Snippet) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: expected %d snippets, got %+v", name, len(want), got)
+		}
+		for i, s := range got {
+			if s.Text != want[i].Text || s.Start != want[i].Start || s.Page != want[i].Page {
+				t.Errorf("%s: snippet %d: got %q at %d (page %d), want %q at %d (page %d)", name, i, s.Text, s.Start, s.Page, want[i].Text, want[i].Start, want[i].Page)
+			}
+		}
+		if got[0].Score <= 0 || got[1].Score != 2*got[0].Score {
+			t.Errorf("%s: expected the second snippet to score twice the first, got %v and %v", name, got[0].Score, got[1].Score)
+		}
+	}
+
+	// Sem mapa de offsets, o texto original é reanalisado
+	got, err := Snippets(db, doc, "licitação contratos", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("reanalyzed", got)
+
+	saveOffsets(t, db, doc, offsets)
+	mapped, err := mappedSpans(db, doc, len(highlightText))
+	if err != nil || !reflect.DeepEqual(mapped, spans) {
+		t.Fatalf("mappedSpans: got %v %v, want %v", mapped, err, spans)
+	}
+	if got, err = Snippets(db, doc, "licitação contratos", opts); err != nil {
+		t.Fatal(err)
+	}
+	check("mapped", got)
+
+	// Sem nenhum termo no texto, só o começo do documento
+	got, err = Snippets(db, doc, "inexistente", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Text != "Projeto de Lei nº 1, de 2024. Dispõe sobre a licitação pública.…" || got[0].Start != 0 || got[0].Page != 1 || got[0].Score != 0 {
+		t.Errorf("unexpected snippets without matches %+v", got)
+	}
+
+	// Um mapa que não corresponde ao texto (um token a menos, ou além do fim) é ignorado
+	for name, bad := range map[string][]models.TokenOffset{
+		"short": offsets[:len(offsets)-1],
+		"past end": append(append([]models.TokenOffset(nil), offsets[:len(offsets)-1]...),
+			models.TokenOffset{Start: len(highlightText), End: len(highlightText) + 5, Page: 3}),
+	} {
+		saveOffsets(t, db, doc, bad)
+		if mapped, err = mappedSpans(db, doc, len(highlightText)); err != nil || mapped != nil {
+			t.Errorf("%s: expected no spans, got %v %v", name, mapped, err)
+		}
+		if got, err = Snippets(db, doc, "licitação contratos", opts); err != nil {
+			t.Fatal(err)
+		}
+		check(name, got)
+	}
+
+	// Tokens sem trecho no original (vazios) não entram nos spans
+	unmapped := append([]models.TokenOffset(nil), offsets...)
+	unmapped[4] = models.TokenOffset{Start: offsets[3].End, End: offsets[3].End, Page: 1}
+	saveOffsets(t, db, doc, unmapped)
+	if mapped, err = mappedSpans(db, doc, len(highlightText)); err != nil || len(mapped) != len(spans)-1 || mapped[4].Token != "texto" {
+		t.Errorf("expected the empty span to be skipped, got %v %v", mapped, err)
+	}
+}
@@ -1,13 +1,15 @@
 package corpus
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
 	mgu "github.com/artking28/myGoUtils" // Biblioteca customizada para utilitários, como Set
+	"github.com/tcc2-davi-arthur/analysis"
 	"github.com/tcc2-davi-arthur/models" // Models do projeto: Document, Word, InverseTrigram
 	"github.com/tcc2-davi-arthur/models/interfaces"
 	"github.com/tcc2-davi-arthur/utils"
@@ -16,8 +18,10 @@ import (
 
 // Diretório onde os arquivos de texto serão lidos
 const (
-	DbFile = "./../data/data.db"
-	Dir    = "./../../misc/corpus/clean"
+	DbFile      = "./../data/data.db"
+	Dir         = "./../../misc/corpus/clean"
+	AccentsFile = "./../../misc/replaces.json"
+	LemmaFile   = "./../../misc/lemmas_pt.tsv"
 )
 
 // Variáveis globais
@@ -27,70 +31,182 @@ var (
 	CacheDocs      map[string]*models.Document            // CacheD em memória de n-gramas
 	CacheGrams     map[string]map[uint16]interfaces.IGram // CacheN em memória de n-gramas
 	Docs           map[uint16][]interfaces.IGram
+	CachePositions map[uint16]map[uint16][]int // Índice posicional em memória: palavra -> documento -> posições
+	Analyzer       *analysis.Analyzer          // Pipeline de análise do índice atual, usado em documentos e consultas
 )
 
+// DefaultAnalyzerConfig retorna analysis.DefaultConfig com o mapa de acentos e o dicionário
+// de lemas relativos a este diretório.
+func DefaultAnalyzerConfig() analysis.Config {
+	cfg := analysis.DefaultConfig()
+	cfg.AccentsFile = AccentsFile
+	cfg.LemmaFile = LemmaFile
+	return cfg
+}
+
 func ResetCache() {
 	CountAllNGrams = 0
 	CacheWords = nil
 	CacheDocs = nil
 	CacheGrams = nil
 	Docs = nil
+	CachePositions = nil
+	Analyzer = nil
+	Snapshot = nil
 }
 
-func CreateDatabaseCaches(id int64, fromScratch bool, gramsSize int, jumpSize int) (string, *gorm.DB) {
+// CreateDatabaseCaches prepara o banco e os caches para uma configuração de n-gramas.
+// O índice é construído (ou refeito, se necessário) com analyzerCfg, registrado em
+// INDEX_META (ver LoadAnalyzer), e o manifesto do corpus indexado fica em Snapshot. As
+// quase-duplicatas não são detectadas aqui: Document.DupOf vem do banco (ver cmd_dedup) ou
+// de FindDuplicates e MarkDuplicates. Em caso de erro o banco é fechado e targetFile (se já
+// criado) é retornado para que o chamador possa removê-lo. Retorna utils.ErrEmptyCorpus se o índice ficar sem n-gramas.
+func CreateDatabaseCaches(ctx context.Context, id int64, fromScratch bool, gramsSize int, jumpSize int, analyzerCfg analysis.Config) (string, *gorm.DB, error) {
+
+	targetFile, db, err := utils.InitDB(id, max(1, gramsSize%4), DbFile, fromScratch)
+	if err != nil {
+		return targetFile, nil, err
+	}
+	slog.Info("banco inicializado", "file", targetFile)
+
+	fail := func(err error) (string, *gorm.DB, error) {
+		if sqlDB, e := db.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+		return targetFile, nil, err
+	}
 
-	targetFile, db := utils.InitDB(id, max(1, gramsSize%4), DbFile, fromScratch)
-	log.Printf("[INFO] Banco inicializado: %s", targetFile)
+	if err = LoadAnalyzer(db, analyzerCfg); err != nil {
+		return fail(err)
+	}
 
 	var n int64
-	err := db.Model(&models.Document{}).Count(&n).Error
+	err = db.Model(&models.Document{}).Count(&n).Error
 	if err != nil {
-		log.Fatalf("[ERRO] Falha ao contar documentos: %v", err)
+		return fail(fmt.Errorf("%w: failed to count documents: %v", utils.ErrDatabase, err))
 	}
-	log.Printf("[INFO] Documentos existentes no banco: %d", n)
+	slog.Info("documentos existentes no banco", "count", n)
 
 	if n <= 0 {
-		if err = RegisterDocs(db); err != nil {
-			log.Fatalf("[ERRO] Falha ao registrar documentos: %v", err)
+		if err = RegisterDocs(ctx, db); err != nil {
+			return fail(fmt.Errorf("failed to register documents: %w", err))
 		}
-		log.Println("[INFO] Documentos registrados com sucesso.")
+		slog.Info("documentos registrados")
+	}
+
+	if err = loadSnapshot(db, analyzerCfg, n <= 0); err != nil {
+		return fail(err)
+	}
+	slog.Info("snapshot do corpus", "id", Snapshot.ID, "files", len(Snapshot.Files))
+
+	updated, err := LoadMetadata(db, MetaDir)
+	if err != nil {
+		return fail(fmt.Errorf("failed to load document metadata: %w", err))
+	}
+	if updated > 0 {
+		slog.Info("metadados carregados", "docs", updated)
+	}
+
+	updated, err = LoadOffsets(db, OffsetsDir)
+	if err != nil {
+		return fail(fmt.Errorf("failed to load offset maps: %w", err))
+	}
+	if updated > 0 {
+		slog.Info("mapas de offsets carregados", "docs", updated)
 	}
 
-	DefineCaches(db)
-	log.Println("[INFO] Caches definidos.")
+	if err = DefineCaches(db); err != nil {
+		return fail(err)
+	}
+	slog.Info("caches definidos", "words", len(CacheWords), "docs", len(CacheDocs))
 
-	inserted, err := IndexDocsGrams(db, gramsSize, jumpSize)
+	rows, err := IndexDocsGrams(ctx, db, gramsSize, jumpSize)
 	if err != nil {
-		log.Fatalf("[ERRO] Falha ao indexar documentos: %v", err)
+		return fail(fmt.Errorf("failed to index documents: %w", err))
 	}
-	log.Printf("[INFO] Indexação concluída. Inseridos %d registros.", inserted)
+	slog.Info("indexação concluída", "rows", rows)
 
-	if inserted <= 0 {
-		log.Println("[INFO] Finalizado. Banco vazio.")
-		os.Exit(0)
+	if rows <= 0 {
+		return fail(fmt.Errorf("%w: no n-grams indexed", utils.ErrEmptyCorpus))
 	}
 
-	log.Println("[INFO] Tarefas de banco finalizadas.")
-	return targetFile, db
+	slog.Info("tarefas de banco finalizadas", "file", targetFile)
+	return targetFile, db, nil
 }
 
-// RegisterDocs Lê todos os arquivos de texto do diretório, cria documentos e palavras, e insere no banco
-func RegisterDocs(db *gorm.DB) error {
+// LoadAnalyzer define Analyzer a partir de cfg e garante que o índice foi construído com ele.
+// Índices sem INDEX_META mas com documentos são considerados construídos com
+// DefaultAnalyzerConfig. Se a configuração registrada for diferente de cfg, o índice
+// (documentos, palavras e n-gramas) é apagado para ser refeito com o novo Analyzer.
+func LoadAnalyzer(db *gorm.DB, cfg analysis.Config) error {
+	a, err := analysis.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	var meta models.IndexMeta
+	res := db.Model(&models.IndexMeta{}).Order("id desc").Limit(1).Find(&meta)
+	if res.Error != nil {
+		return fmt.Errorf("%w: failed to load index metadata: %v", utils.ErrDatabase, res.Error)
+	}
+
+	stored := meta.Analyzer
+	if res.RowsAffected == 0 {
+		var n int64
+		if err = db.Model(&models.Document{}).Count(&n).Error; err != nil {
+			return fmt.Errorf("%w: failed to count documents: %v", utils.ErrDatabase, err)
+		}
+		if n > 0 {
+			stored = DefaultAnalyzerConfig().Signature()
+		}
+	}
+
+	if stored != cfg.Signature() {
+		if stored != "" {
+			slog.Info("índice construído com outro analyzer, reindexando", "stored", stored, "stemmer", cfg.Stemmer())
+			if err = resetIndex(db); err != nil {
+				return err
+			}
+		}
+		if err = db.Create(models.NewIndexMeta(cfg.Signature(), cfg.Stemmer())).Error; err != nil {
+			return fmt.Errorf("%w: failed to save index metadata: %v", utils.ErrDatabase, err)
+		}
+	}
+
+	Analyzer = a
+	return nil
+}
+
+// resetIndex apaga documentos, palavras, n-gramas, posições, offsets, citações, snapshots e metadados do índice.
+func resetIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"WORD_DOC", "WORD_POS", "DOC_OFFSETS", "WORD", "DOCUMENT", "CITATION", "SNAPSHOT", "CLUSTER", "INDEX_META"} {
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s", table)).Error; err != nil {
+				return fmt.Errorf("%w: failed to reset %s: %v", utils.ErrDatabase, table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RegisterDocs Lê todos os arquivos suportados do diretório (ver ReadDocText), cria documentos, palavras e citações, e insere no banco
+func RegisterDocs(ctx context.Context, db *gorm.DB) error {
 
 	files, err := os.ReadDir(Dir) // Lista os arquivos no diretório
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Transaction garante que tudo seja inserido de forma atômica
 	return db.Transaction(func(tx *gorm.DB) error {
 
 		var vec []*models.Document
-		wordSet := mgu.NewSet[string]() // Conjunto para armazenar palavras únicas
+		wordSet := mgu.NewSet[string]()                           // Conjunto para armazenar palavras únicas
+		citations := make(map[*models.Document]map[string]uint16) // Citações legais de cada documento
 
 		for _, f := range files {
-			ext := filepath.Ext(f.Name())[1:] // Pega a extensão do arquivo sem o ponto
-			if f.IsDir() || ext != "txt" {    // Pula diretórios e arquivos não-txt
+			kind := models.ParseDocKind(strings.TrimPrefix(filepath.Ext(f.Name()), ".")) // Tipo pela extensão
+			if f.IsDir() || kind == models.DocKindNone {                                 // Pula diretórios e tipos não suportados
 				continue
 			}
 
@@ -103,16 +219,25 @@ func RegisterDocs(db *gorm.DB) error {
 			doc := models.Document{
 				Name: info.Name(),
 				Size: uint16(info.Size()),
-				Kind: models.ParseDocKind(ext),
+				Kind: kind,
 			}
 			vec = append(vec, &doc)
 
 			// Lê o conteúdo do arquivo e adiciona palavras ao conjunto
-			content, e := os.ReadFile(fmt.Sprintf("%s/%s", Dir, f.Name()))
+			content, e := ReadDocText(ctx, fmt.Sprintf("%s/%s", Dir, f.Name()), kind)
 			if e != nil {
 				return e
 			}
-			wordSet.Add(strings.Fields(string(content))...)
+			tokens := Analyzer.Analyze(content)
+			wordSet.Add(tokens...)
+			for _, t := range tokens {
+				if _, ok := analysis.IsCitation(t); ok {
+					if citations[&doc] == nil {
+						citations[&doc] = make(map[string]uint16)
+					}
+					citations[&doc][t]++
+				}
+			}
 		}
 
 		if vec == nil || len(vec) == 0 {
@@ -125,6 +250,20 @@ func RegisterDocs(db *gorm.DB) error {
 			return err
 		}
 
+		// Registra as citações com os IDs gerados para os documentos
+		var cits []*models.Citation
+		for doc, counts := range citations {
+			for value, count := range counts {
+				kind, _ := analysis.IsCitation(value)
+				cits = append(cits, models.NewCitation(doc.ID, kind, value, count))
+			}
+		}
+		if len(cits) > 0 {
+			if err = tx.CreateInBatches(cits, 1000).Error; err != nil {
+				return err
+			}
+		}
+
 		// Converte o conjunto de palavras em slice de modelos Word
 		words := mgu.VecMap(wordSet.AsArray(), func(t string) *models.Word {
 			return &models.Word{Value: t}
@@ -138,7 +277,8 @@ func RegisterDocs(db *gorm.DB) error {
 	})
 }
 
-func DefineCaches(db *gorm.DB) {
+// DefineCaches carrega palavras e documentos do banco para os caches em memória.
+func DefineCaches(db *gorm.DB) error {
 	// Inicializa Cache em memória com todas as palavras
 	if len(CacheWords) == 0 {
 		if CacheWords == nil {
@@ -147,7 +287,7 @@ func DefineCaches(db *gorm.DB) {
 
 		var vec []*models.Word
 		if err := db.Model(&models.Word{}).Find(&vec).Error; err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("%w: failed to load words: %v", utils.ErrDatabase, err)
 		}
 
 		// Preenche o cache
@@ -164,7 +304,7 @@ func DefineCaches(db *gorm.DB) {
 
 		var vec []*models.Document
 		if err := db.Model(&models.Document{}).Find(&vec).Error; err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("%w: failed to load documents: %v", utils.ErrDatabase, err)
 		}
 
 		// Preenche o cache
@@ -173,10 +313,16 @@ func DefineCaches(db *gorm.DB) {
 		}
 	}
 
+	return nil
 }
 
-func IndexDocsGrams(db *gorm.DB, gramsSize, jumpSize int) (int, error) {
+// IndexDocsGrams gera os n-gramas de todos os documentos do diretório, preenche os caches em
+// memória e persiste o índice invertido. Interrompe entre documentos (e durante a gravação em
+// lote) se ctx for cancelado, retornando ctx.Err(). Retorna a quantidade de linhas em
+// WORD_DOC: as inseridas ou, se o índice já estava gravado, as existentes.
+func IndexDocsGrams(ctx context.Context, db *gorm.DB, gramsSize, jumpSize int) (int, error) {
 	gramsSize = max(1, gramsSize%4)
+	db = db.WithContext(ctx)
 
 	if CacheGrams == nil {
 		CacheGrams = make(map[string]map[uint16]interfaces.IGram)
@@ -184,25 +330,38 @@ func IndexDocsGrams(db *gorm.DB, gramsSize, jumpSize int) (int, error) {
 	if Docs == nil {
 		Docs = make(map[uint16][]interfaces.IGram)
 	}
+	if CachePositions == nil {
+		CachePositions = make(map[uint16]map[uint16][]int)
+	}
 
 	files, err := os.ReadDir(Dir)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
+	progress := utils.NewProgress(fmt.Sprintf("indexação %d-gramas", gramsSize), len(files))
 	for _, f := range files {
-		ext := filepath.Ext(f.Name())[1:]
-		if f.IsDir() || ext != "txt" {
+		if err = ctx.Err(); err != nil {
+			return 0, err
+		}
+		progress.Add(1)
+
+		kind := models.ParseDocKind(strings.TrimPrefix(filepath.Ext(f.Name()), "."))
+		if f.IsDir() || kind == models.DocKindNone {
 			continue
 		}
 
-		content, err := os.ReadFile(fmt.Sprintf("%s/%s", Dir, f.Name()))
+		content, err := ReadDocText(ctx, fmt.Sprintf("%s/%s", Dir, f.Name()), kind)
 		if err != nil {
 			return 0, err
 		}
-		text := strings.Fields(string(content))
+		text := Analyzer.Analyze(content)
+		indexPositions(CacheDocs[f.Name()].ID, text)
 
-		result, jumps := utils.GetGramsLim(text, gramsSize, jumpSize)
+		result, jumps, err := utils.GetGramsLim(text, gramsSize, jumpSize)
+		if err != nil {
+			return 0, err
+		}
 		for i, word := range result {
 			var ngram interfaces.IGram
 
@@ -234,16 +393,20 @@ func IndexDocsGrams(db *gorm.DB, gramsSize, jumpSize int) (int, error) {
 		}
 	}
 
-	// Verifica se existem documentos no banco
+	progress.Finish()
+
+	if err = savePositions(db); err != nil {
+		return 0, err
+	}
+
+	// Verifica se o índice invertido já foi gravado (banco reaproveitado)
 	var n int64
 	err = db.Table("WORD_DOC").Count(&n).Error
 	if err != nil {
-		log.Fatal(err)
+		return 0, fmt.Errorf("%w: %v", utils.ErrDatabase, err)
 	}
-
-	// Se não houver documentos, insere todos os arquivos do diretório e suas palavras
 	if n > 0 {
-		return 0, nil
+		return int(n), nil
 	}
 
 	vec0 := mgu.VecMap(mgu.MapValues(CacheGrams), func(t map[uint16]interfaces.IGram) []interfaces.IGram {
@@ -275,3 +438,40 @@ func IndexDocsGrams(db *gorm.DB, gramsSize, jumpSize int) (int, error) {
 
 	return 0, nil
 }
+
+// indexPositions registra em CachePositions a posição de cada token de um documento.
+// Tokens sem palavra cadastrada são ignorados.
+func indexPositions(docId uint16, tokens []string) {
+	for i, t := range tokens {
+		word, ok := CacheWords[t]
+		if !ok {
+			continue
+		}
+		if CachePositions[word.ID] == nil {
+			CachePositions[word.ID] = make(map[uint16][]int)
+		}
+		CachePositions[word.ID][docId] = append(CachePositions[word.ID][docId], i)
+	}
+}
+
+// savePositions persiste CachePositions em WORD_POS, se a tabela ainda estiver vazia.
+func savePositions(db *gorm.DB) error {
+	var n int64
+	if err := db.Model(&models.WordPosition{}).Count(&n).Error; err != nil {
+		return fmt.Errorf("%w: %v", utils.ErrDatabase, err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var all []*models.WordPosition
+	for wordId, docs := range CachePositions {
+		for docId, positions := range docs {
+			all = append(all, models.NewWordPosition(docId, wordId, positions))
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(all, 1000).Error
+}
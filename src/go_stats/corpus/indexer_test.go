@@ -0,0 +1,85 @@
+package corpus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// setupCorpus cria uma árvore temporária com o layout esperado pelos caminhos relativos do
+// pacote (misc/corpus/clean, src/data/data.db) contendo docs, e muda o diretório atual para
+// src/go_stats até o fim do teste.
+func setupCorpus(t *testing.T, docs map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	clean := filepath.Join(root, "misc", "corpus", "clean")
+	for _, dir := range []string{clean, filepath.Join(root, "src", "go_stats"), filepath.Join(root, "src", "data")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range docs {
+		if err := os.WriteFile(filepath.Join(clean, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	accents, err := os.ReadFile(filepath.Join("..", AccentsFile)) // relativo ao diretório do pacote
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(root, "misc", "replaces.json"), accents, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(root, "src", "data", "data.db"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(filepath.Join(root, "src", "go_stats")); err != nil {
+		t.Fatal(err)
+	}
+	ResetCache()
+	t.Cleanup(func() {
+		ResetCache()
+		_ = os.Chdir(wd)
+	})
+}
+
+// openIndex executa CreateDatabaseCaches e registra o fechamento e a remoção da cópia.
+func openIndex(t *testing.T, id int64, fromScratch bool) *gorm.DB {
+	t.Helper()
+	name, db, err := CreateDatabaseCaches(context.Background(), id, fromScratch, 1, 0, DefaultAnalyzerConfig())
+	if name != "" && !fromScratch {
+		t.Cleanup(func() { _ = os.Remove(name) })
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, e := db.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestCreateDatabaseCachesReuse(t *testing.T) {
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "dispõe sobre licitação pública e contratos administrativos",
+		"doc_0002_clean.txt": "altera o código penal para agravar a pena de crimes ambientais",
+	})
+
+	// Índice construído no próprio DbFile e depois reaproveitado por uma cópia
+	openIndex(t, 1, true)
+	ResetCache()
+	openIndex(t, 2, false)
+	if len(CacheDocs) != 2 || len(Docs) != 2 {
+		t.Fatalf("expected the reused index to load 2 documents, got %d/%d", len(CacheDocs), len(Docs))
+	}
+}
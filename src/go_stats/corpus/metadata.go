@@ -0,0 +1,92 @@
+package corpus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	metaDir = "./misc/corpus/meta"       // Destino dos metadados no scraping (relativo à raiz)
+	MetaDir = "./../../misc/corpus/meta" // Origem dos metadados na indexação
+)
+
+// MetaFileName retorna o nome do arquivo de metadados de um documento do corpus
+// (doc_0001.pdf e doc_0001_clean.txt -> doc_0001.json).
+func MetaFileName(docName string) string {
+	base := strings.TrimSuffix(docName, filepath.Ext(docName))
+	return strings.TrimSuffix(base, "_clean") + ".json"
+}
+
+// WriteMeta grava os metadados de uma proposição em JSON.
+func WriteMeta(path string, meta *models.DocumentMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadMeta lê os metadados gravados por WriteMeta.
+func ReadMeta(path string) (*models.DocumentMeta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ret models.DocumentMeta
+	if err = json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("invalid metadata file %s: %w", path, err)
+	}
+	return &ret, nil
+}
+
+// LoadMetadata preenche os metadados dos documentos que ainda não os têm a partir dos arquivos
+// em dir. Documentos sem arquivo são mantidos como estão. Retorna quantos foram atualizados.
+func LoadMetadata(db *gorm.DB, dir string) (int, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	var docs []*models.Document
+	if err := db.Where("proposal_id IS NULL OR proposal_id = ''").Find(&docs).Error; err != nil {
+		return 0, fmt.Errorf("%w: failed to load documents: %v", utils.ErrDatabase, err)
+	}
+
+	updated := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, doc := range docs {
+			meta, err := ReadMeta(filepath.Join(dir, MetaFileName(doc.Name)))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			doc.ApplyMeta(meta)
+			if err = tx.Save(doc).Error; err != nil {
+				return fmt.Errorf("%w: failed to save metadata of %s: %v", utils.ErrDatabase, doc.Name, err)
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// parseDate aceita as datas da API ("2019-03-12T15:30", com ou sem segundos, ou só a data).
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05", time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
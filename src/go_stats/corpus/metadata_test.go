@@ -0,0 +1,113 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+// openTestDB cria um banco vazio com as tabelas do índice em um diretório temporário.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	_, db, err := utils.InitDB(0, 1, filepath.Join(t.TempDir(), "data.db"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, e := db.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestMetaFileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"doc_0001_clean.txt": "doc_0001.json",
+		"doc_0001.pdf":       "doc_0001.json",
+		"cd_2196833.docx":    "cd_2196833.json",
+		"doc_0002":           "doc_0002.json",
+	} {
+		if got := MetaFileName(name); got != want {
+			t.Errorf("MetaFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	for s, want := range map[string]string{
+		"2019-03-12T15:30":    "2019-03-12 15:30:00",
+		"2019-03-12T15:30:45": "2019-03-12 15:30:45",
+		"2019-03-12":          "2019-03-12 00:00:00",
+	} {
+		got, ok := parseDate(s)
+		if !ok || got.Format(time.DateTime) != want {
+			t.Errorf("parseDate(%q) = %v %v, want %s", s, got, ok, want)
+		}
+	}
+	for _, s := range []string{"", "12/03/2019", "2019-13-01"} {
+		if _, ok := parseDate(s); ok {
+			t.Errorf("parseDate(%q) should fail", s)
+		}
+	}
+}
+
+func TestLoadMetadata(t *testing.T) {
+	db := openTestDB(t)
+	docs := []*models.Document{
+		{Name: "doc_0001_clean.txt", Kind: models.DocKindText},
+		{Name: "doc_0002_clean.txt", Kind: models.DocKindText},
+		{Name: "doc_0003_clean.txt", Kind: models.DocKindText, ProposalID: "7", Type: "PL"},
+	}
+	if err := db.Create(docs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Sem o diretório de metadados nada muda
+	dir := filepath.Join(t.TempDir(), "meta")
+	if n, err := LoadMetadata(db, dir); n != 0 || err != nil {
+		t.Fatalf("missing dir: got %d %v", n, err)
+	}
+
+	submitted := time.Date(2019, 3, 12, 15, 30, 0, 0, time.UTC)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, meta := range map[string]*models.DocumentMeta{
+		"doc_0001_clean.txt": {Source: "camara", ProposalID: "2196833", Type: "pec", Number: 45, Year: 2019,
+			Authors: []string{"Fulano", "Ciclano"}, Themes: []string{"Saúde"}, SubmittedAt: &submitted},
+		"doc_0003_clean.txt": {Source: "camara", ProposalID: "8", Type: "PEC"},
+	} {
+		if err := WriteMeta(filepath.Join(dir, MetaFileName(name)), meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := LoadMetadata(db, dir)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 updated document, got %d %v", n, err)
+	}
+	var got []*models.Document
+	if err = db.Order("id").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	first := got[0]
+	if first.Type != "PEC" || first.Year != 2019 || first.Authors != "Fulano; Ciclano" || first.ThemeList()[0] != "Saúde" ||
+		first.SubmittedAt == nil || !first.SubmittedAt.Equal(submitted) {
+		t.Errorf("unexpected metadata %+v", first)
+	}
+	// doc_0002 não tem arquivo e doc_0003 já tinha metadados
+	if got[1].ProposalID != "" || got[2].ProposalID != "7" || got[2].Type != "PL" {
+		t.Errorf("unexpected documents %+v %+v", got[1], got[2])
+	}
+
+	// Uma segunda carga não encontra pendências com arquivo
+	if n, err = LoadMetadata(db, dir); n != 0 || err != nil {
+		t.Errorf("second load: got %d %v", n, err)
+	}
+}
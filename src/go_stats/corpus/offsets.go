@@ -0,0 +1,89 @@
+package corpus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	dirOffsets = "./misc/corpus/offsets"       // Destino dos mapas de offsets em TextProcessor (relativo à raiz)
+	OffsetsDir = "./../../misc/corpus/offsets" // Origem dos mapas de offsets na indexação
+)
+
+// OffsetsFileName retorna o nome do mapa de offsets de um documento do corpus
+// (doc_0001.pdf e doc_0001_clean.txt -> doc_0001.off).
+func OffsetsFileName(docName string) string {
+	return strings.TrimSuffix(MetaFileName(docName), ".json") + ".off"
+}
+
+// BuildOffsets converte os spans do texto original em offsets com número de página. O
+// pdftotext separa as páginas com form feed ('\f').
+func BuildOffsets(text string, spans []analysis.Span) []models.TokenOffset {
+	ret := make([]models.TokenOffset, len(spans))
+	page, last := 1, 0
+	for i, s := range spans {
+		page += strings.Count(text[last:s.Start], "\f")
+		last = s.Start
+		ret[i] = models.TokenOffset{Start: s.Start, End: s.End, Page: page}
+	}
+	return ret
+}
+
+// PageAt retorna a página (a partir de 1) do byte offset de text.
+func PageAt(text string, offset int) int {
+	return 1 + strings.Count(text[:min(offset, len(text))], "\f")
+}
+
+// LoadOffsets grava em DOC_OFFSETS os mapas de offsets (arquivos .off de dir) dos documentos
+// que ainda não os têm. Retorna quantos foram gravados.
+func LoadOffsets(db *gorm.DB, dir string) (int, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	var docs []*models.Document
+	err := db.Where("id NOT IN (?)", db.Model(&models.DocOffsets{}).Select("docId")).Find(&docs).Error
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to load documents: %v", utils.ErrDatabase, err)
+	}
+
+	var vec []*models.DocOffsets
+	for _, doc := range docs {
+		data, err := os.ReadFile(filepath.Join(dir, OffsetsFileName(doc.Name)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		vec = append(vec, models.NewDocOffsets(doc.ID, data))
+	}
+	if len(vec) == 0 {
+		return 0, nil
+	}
+	if err = db.CreateInBatches(vec, 100).Error; err != nil {
+		return 0, fmt.Errorf("%w: failed to save offsets: %v", utils.ErrDatabase, err)
+	}
+	return len(vec), nil
+}
+
+// DocumentOffsets retorna o mapa de offsets gravado para o documento, ou nil se não houver.
+func DocumentOffsets(db *gorm.DB, docID uint16) ([]models.TokenOffset, error) {
+	var row models.DocOffsets
+	res := db.Where("docId = ?", docID).Limit(1).Find(&row)
+	if res.Error != nil {
+		return nil, fmt.Errorf("%w: failed to load offsets of document %d: %v", utils.ErrDatabase, docID, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return row.GetOffsets(), nil
+}
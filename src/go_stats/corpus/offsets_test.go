@@ -0,0 +1,97 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/models"
+)
+
+func TestOffsetsEncoding(t *testing.T) {
+	offsets := []models.TokenOffset{
+		{Start: 0, End: 6, Page: 1},
+		{Start: 7, End: 25, Page: 1},
+		{Start: 7, End: 25, Page: 1}, // mesmo trecho: deslocamento zero
+		{Start: 300, End: 312, Page: 3},
+		{Start: 290, End: 295, Page: 3}, // início anterior: deslocamento negativo
+	}
+	if got := models.DecodeOffsets(models.EncodeOffsets(offsets)); !reflect.DeepEqual(got, offsets) {
+		t.Errorf("round trip: got %v, want %v", got, offsets)
+	}
+	if got := models.DecodeOffsets(nil); got != nil {
+		t.Errorf("empty map: got %v", got)
+	}
+	// Um offset truncado é descartado
+	data := models.EncodeOffsets(offsets)
+	if got := models.DecodeOffsets(data[:len(data)-1]); !reflect.DeepEqual(got, offsets[:4]) {
+		t.Errorf("truncated: got %v", got)
+	}
+}
+
+func TestBuildOffsets(t *testing.T) {
+	text := "Altera a lei\fsobre licitações\f\fe contratos"
+	spans := []analysis.Span{
+		{Start: 0, End: 6, Token: "altera"},
+		{Start: 9, End: 12, Token: "lei"},
+		{Start: 19, End: 31, Token: "licitacoes"},
+		{Start: 35, End: 44, Token: "contratos"},
+	}
+	want := []models.TokenOffset{{Start: 0, End: 6, Page: 1}, {Start: 9, End: 12, Page: 1}, {Start: 19, End: 31, Page: 2}, {Start: 35, End: 44, Page: 4}}
+	if got := BuildOffsets(text, spans); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for offset, page := range map[int]int{0: 1, 12: 1, 13: 2, 31: 2, 32: 3, 33: 4, 1000: 4} {
+		if got := PageAt(text, offset); got != page {
+			t.Errorf("PageAt(%d) = %d, want %d", offset, got, page)
+		}
+	}
+}
+
+func TestLoadOffsets(t *testing.T) {
+	db := openTestDB(t)
+	docs := []*models.Document{
+		{Name: "doc_0001_clean.txt", Kind: models.DocKindText},
+		{Name: "doc_0002_clean.txt", Kind: models.DocKindText},
+		{Name: "doc_0003_clean.txt", Kind: models.DocKindText},
+	}
+	if err := db.Create(docs).Error; err != nil {
+		t.Fatal(err)
+	}
+	stored := []models.TokenOffset{{Start: 0, End: 1, Page: 1}}
+	if err := db.Create(models.NewDocOffsets(docs[2].ID, models.EncodeOffsets(stored))).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "offsets")
+	if n, err := LoadOffsets(db, dir); n != 0 || err != nil {
+		t.Fatalf("missing dir: got %d %v", n, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	offsets := []models.TokenOffset{{Start: 0, End: 6, Page: 1}, {Start: 7, End: 10, Page: 2}}
+	for _, name := range []string{"doc_0001_clean.txt", "doc_0003_clean.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, OffsetsFileName(name)), models.EncodeOffsets(offsets), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// doc_0002 não tem arquivo e doc_0003 já tinha mapa
+	n, err := LoadOffsets(db, dir)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 loaded map, got %d %v", n, err)
+	}
+	for id, want := range map[uint16][]models.TokenOffset{docs[0].ID: offsets, docs[1].ID: nil, docs[2].ID: stored} {
+		got, err := DocumentOffsets(db, id)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("document %d: got %v %v, want %v", id, got, err, want)
+		}
+	}
+	if n, err = LoadOffsets(db, dir); n != 0 || err != nil {
+		t.Errorf("second load: got %d %v", n, err)
+	}
+}
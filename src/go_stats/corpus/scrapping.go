@@ -1,229 +1,354 @@
 package corpus
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"log/slog"
 	"os"
-	"strings"
+	"path/filepath"
 	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
 )
 
 const (
-	apiURL    = "https://www.camara.leg.br/busca-api/api/v1/busca/proposicoes/_search"
-	tempDir   = "./misc/corpus/temp"
-	corpusDir = "./misc/corpus/pdf"
+	tempDir        = "./misc/corpus/temp"
+	corpusDir      = "./misc/corpus/pdf"
+	crawlStateFile = "./misc/corpus/crawl_state.json"
+
+	// maxPageFailures é o número de páginas seguidas da busca que podem falhar (após as novas
+	// tentativas do Fetcher) antes de o crawl desistir da paginação.
+	maxPageFailures = 3
 )
 
 var (
-	totalPages int
-	totalDocs  int
-	mu         sync.Mutex
-	wgScrap    sync.WaitGroup
+	totals  scrapeTotals
+	mu      sync.Mutex
+	wgScrap sync.WaitGroup
 )
 
-type apiResponse struct {
-	Hits struct {
-		Hits []struct {
-			ID string `json:"_id"`
-		} `json:"hits"`
-	} `json:"hits"`
+// ScrapeOptions configura StartScrapping. Os limites valem para o corpus da fonte (incluindo
+// o que já foi salvo em execuções anteriores); 0 desativa o limite e, sem nenhum limite, a
+// coleta segue até a fonte não ter mais resultados.
+type ScrapeOptions struct {
+	Query    ScrapeQuery
+	MaxPages int   // Total de páginas de PDF
+	MaxDocs  int   // Total de documentos
+	MaxBytes int64 // Total de bytes dos PDFs
+	Workers  int
+	Delay    time.Duration // Intervalo mínimo entre requisições ao mesmo host
 }
 
-type searchBody struct {
-	Order             string `json:"order"`
-	Pagina            int    `json:"pagina"`
-	Q                 string `json:"q"`
-	TiposDeProposicao string `json:"tiposDeProposicao"`
+// scrapeTotals é o tamanho atual do corpus de uma fonte.
+type scrapeTotals struct {
+	docs  int
+	pages int
+	bytes int64
+	full  bool // Um documento não coube nos limites (ver addTotals)
 }
 
-func StartScrapping(maxPages, maxWorkers int, delayPerReq time.Duration) {
+// reached informa se algum limite de opts foi atingido, ou se um documento já deixou de ser
+// salvo por não caber neles.
+func (opts ScrapeOptions) reached(t scrapeTotals) bool {
+	return t.full || (opts.MaxPages > 0 && t.pages >= opts.MaxPages) ||
+		(opts.MaxDocs > 0 && t.docs >= opts.MaxDocs) ||
+		(opts.MaxBytes > 0 && t.bytes >= opts.MaxBytes)
+}
 
-	err := os.MkdirAll(tempDir, 0755)
+// exceeded informa se t passou de algum limite de opts.
+func (opts ScrapeOptions) exceeded(t scrapeTotals) bool {
+	return (opts.MaxPages > 0 && t.pages > opts.MaxPages) ||
+		(opts.MaxDocs > 0 && t.docs > opts.MaxDocs) ||
+		(opts.MaxBytes > 0 && t.bytes > opts.MaxBytes)
+}
+
+// progress cria o acompanhamento na unidade do primeiro limite definido (páginas, documentos
+// ou bytes) e retorna a função que extrai essa unidade dos totais.
+func (opts ScrapeOptions) progress() (*utils.Progress, func(scrapeTotals) int) {
+	switch {
+	case opts.MaxPages > 0:
+		return utils.NewProgress("download de PDFs (páginas)", opts.MaxPages), func(t scrapeTotals) int { return t.pages }
+	case opts.MaxDocs == 0 && opts.MaxBytes > 0:
+		return utils.NewProgress("download de PDFs (bytes)", int(opts.MaxBytes)), func(t scrapeTotals) int { return int(t.bytes) }
+	default:
+		return utils.NewProgress("download de PDFs (documentos)", opts.MaxDocs), func(t scrapeTotals) int { return t.docs }
+	}
+}
+
+// StartScrapping busca proposições em src (nil = DefaultSource) que atendam a opts.Query e
+// baixa os PDFs até atingir um dos limites de opts. As requisições respeitam opts.Delay por
+// host e são repetidas com espera exponencial em falhas temporárias (ver Fetcher).
+//
+// Cada proposição é salva como <prefixo>_<id>.pdf (ver DocBaseName), com os metadados em
+// <prefixo>_<id>.json, e sua situação fica registrada em crawlStateFile: uma nova execução
+// retoma de onde a anterior parou, pulando o que já foi salvo e tentando de novo o que
+// falhou. PDFs com conteúdo idêntico ao de outra proposição já salva, de qualquer fonte, são
+// marcados como duplicados e descartados. Proposições fora de opts.Query não são registradas,
+// de modo que uma coleta posterior com outros critérios ainda pode incluí-las.
+//
+// Cancelar ctx interrompe a paginação e os downloads em andamento; os PDFs já salvos são
+// mantidos. Falhas em proposições individuais não interrompem o crawl. Retorna erro apenas
+// se os diretórios de trabalho não puderem ser criados ou o estado salvo não puder ser lido.
+func StartScrapping(ctx context.Context, src Source, opts ScrapeOptions) error {
+
+	err := errors.Join(
+		os.MkdirAll(tempDir, 0755),
+		os.MkdirAll(corpusDir, 0755),
+		os.MkdirAll(metaDir, 0755),
+	)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	if src == nil {
+		if src, err = NewSource(""); err != nil {
+			return err
+		}
 	}
+	opts.Workers = max(opts.Workers, 1)
 
-	err = os.MkdirAll(corpusDir, 0755)
+	state, err := LoadCrawlState(crawlStateFile)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	fetcher := NewFetcher(opts.Delay)
+
+	mu.Lock()
+	totals = scrapeTotals{}
+	totals.docs, totals.pages, totals.bytes = state.Totals(src.Prefix() + "_")
+	start := totals
+	mu.Unlock()
+	if start.docs > 0 {
+		slog.Info("retomando crawl", "source", src.Name(), "state", crawlStateFile,
+			"docs", start.docs, "pages", start.pages, "bytes", start.bytes)
 	}
 
 	tasks := make(chan string, 200)
-	for i := 0; i < maxWorkers; i++ {
+	progress, unit := opts.progress()
+	progress.Add(unit(start))
+	for i := 0; i < opts.Workers; i++ {
 		wgScrap.Add(1)
-		go worker(maxPages, delayPerReq, tasks)
+		go worker(ctx, src, opts, fetcher, state, tasks, progress, unit)
 	}
 
+	failures := 0
 pageLoop:
 	for page := 1; ; page++ {
-		if getTotalPages() >= maxPages {
+		if opts.reached(getTotals()) || ctx.Err() != nil {
 			break
 		}
 
-		body := searchBody{
-			Order:             "relevancia",
-			Pagina:            page,
-			Q:                 "*",
-			TiposDeProposicao: "PEC,PLP,PL,PDL",
-		}
-
-		data, _ := json.Marshal(body)
-		req, _ := http.NewRequest("POST", apiURL, strings.NewReader(string(data)))
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
+		ids, err := src.List(ctx, fetcher, opts.Query, page)
 		if err != nil {
-			log.Println("Erro na API:", err)
-			break
-		}
-
-		var apiResp apiResponse
-		respBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if err := json.Unmarshal(respBody, &apiResp); err != nil {
-			log.Println("Erro ao parsear JSON:", err)
-			break
+			if ctx.Err() != nil {
+				break
+			}
+			failures++
+			slog.Error("erro na listagem", "source", src.Name(), "page", page, "failures", failures, "err", err)
+			if failures >= maxPageFailures {
+				slog.Error("muitas falhas seguidas na listagem; paginação encerrada", "source", src.Name(), "page", page)
+				break
+			}
+			continue
 		}
+		failures = 0
 
-		if len(apiResp.Hits.Hits) == 0 {
-			fmt.Println("Sem mais resultados")
+		if len(ids) == 0 {
+			slog.Info("sem mais resultados", "page", page)
 			break
 		}
 
-		for _, h := range apiResp.Hits.Hits {
-			if getTotalPages() >= maxPages {
+		for _, id := range ids {
+			if opts.reached(getTotals()) {
+				break pageLoop
+			}
+			if !state.Pending(DocBaseName(src, id)) {
+				continue
+			}
+			select {
+			case tasks <- id:
+			case <-ctx.Done():
 				break pageLoop
 			}
-			link := fmt.Sprintf("https://www.camara.leg.br/proposicoesWeb/fichadetramitacao?idProposicao=%s", h.ID)
-			tasks <- link
 		}
-
-		time.Sleep(delayPerReq)
 	}
 
 	close(tasks)
 	wgScrap.Wait()
+	progress.Finish()
+
+	if ctx.Err() != nil {
+		slog.Warn("scraping interrompido", "err", ctx.Err())
+	}
+	end := getTotals()
+	slog.Info("scraping finalizado", "source", src.Name(), "new_docs", end.docs-start.docs,
+		"docs", end.docs, "pages", end.pages, "bytes", end.bytes)
+	return nil
+}
 
-	fmt.Printf("Finalizado: %d PDFs válidos, %d páginas totais\n", getTotalDocs(), getTotalPages())
+// sleepCtx espera d ou até ctx ser cancelado; retorna false no cancelamento.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
 }
 
-func worker(maxPages int, delayPerReq time.Duration, tasks <-chan string) {
+// crawlFiltered marca, apenas em memória, uma proposição fora de ScrapeOptions.Query.
+const crawlFiltered = "filtered"
+
+func worker(ctx context.Context, src Source, opts ScrapeOptions, f *Fetcher, state *CrawlState, tasks <-chan string, progress *utils.Progress, unit func(scrapeTotals) int) {
 	defer wgScrap.Done()
-	for url := range tasks {
-		if getTotalPages() >= maxPages {
+	for proposalID := range tasks {
+		if ctx.Err() != nil {
 			return
 		}
-
-		pdfURL, err := getPDFLink(url)
-		if err != nil {
-			log.Printf("[ERRO] Link PDF: %s | %v\n", url, err)
+		// Com o limite atingido a fila é esvaziada sem downloads, para que a paginação (que
+		// para ao ver o limite) nunca fique bloqueada esperando um worker
+		if opts.reached(getTotals()) {
 			continue
 		}
-		pdfURL = strings.Split(pdfURL, "&filename")[0]
-
-		tempFile := fmt.Sprintf("%s/temp_%d.pdf", tempDir, time.Now().UnixNano())
 
-		err = baixarPdfCamara(tempFile, pdfURL)
-		if err != nil {
-			log.Printf("[ERRO] Download PDF: %s | %v\n", pdfURL, err)
-			continue
+		entry, err := fetchProposal(ctx, src, opts, f, state, proposalID)
+		if ctx.Err() != nil {
+			return
 		}
-
-		n, err := pdfcpuapi.PageCountFile(tempFile)
 		if err != nil {
-			os.Remove(tempFile)
+			slog.Error("falha ao baixar proposição", "source", src.Name(), "proposal", proposalID, "err", err)
+			entry = CrawlEntry{Status: CrawlFailed, Error: err.Error()}
+		}
+		switch entry.Status {
+		case "":
+			continue // Limite atingido; a proposição fica para a próxima execução
+		case crawlFiltered:
 			continue
 		}
-
-		if n >= 1 {
-			newTotal := addPages(n)
-			if newTotal > maxPages {
-				os.Remove(tempFile)
-				return
-			}
-
-			id := incDocs()
-			finalFile := fmt.Sprintf("%s/doc_%04d.pdf", corpusDir, id)
-			os.Rename(tempFile, finalFile)
-			log.Printf("[OK] PDF salvo (%d pág): %s | Total: %d páginas\n", n, finalFile, getTotalPages())
-		} else {
-			os.Remove(tempFile)
+		if err := state.Set(DocBaseName(src, proposalID), entry); err != nil {
+			slog.Error("falha ao gravar estado do crawl", "state", crawlStateFile, "err", err)
+		}
+		if entry.Status == CrawlDone {
+			progress.Add(unit(scrapeTotals{docs: 1, pages: entry.Pages, bytes: entry.Bytes}))
 		}
-
-		time.Sleep(delayPerReq)
 	}
 }
 
-func getPDFLink(detailURL string) (string, error) {
-	resp, err := http.Get(detailURL)
-	if err != nil {
-		return "", err
+// fetchProposal baixa o PDF de uma proposição e o salva no corpus com os seus metadados,
+// retornando a sua situação. Os metadados só são buscados antes do download quando a consulta
+// depende deles (ScrapeQuery.NeedsMeta); nos demais casos, apenas para documentos salvos.
+// Retorna uma CrawlEntry vazia se o documento não couber nos limites de opts.
+func fetchProposal(ctx context.Context, src Source, opts ScrapeOptions, f *Fetcher, state *CrawlState, proposalID string) (CrawlEntry, error) {
+	name := DocBaseName(src, proposalID)
+
+	var meta *models.DocumentMeta
+	if opts.Query.NeedsMeta() {
+		var err error
+		if meta, err = src.Metadata(ctx, f, proposalID); err != nil {
+			return CrawlEntry{}, fmt.Errorf("metadata: %w", err)
+		}
+		if !opts.Query.Match(meta) {
+			return CrawlEntry{Status: crawlFiltered}, nil
+		}
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	data, err := src.Document(ctx, f, proposalID)
 	if err != nil {
-		return "", err
+		return CrawlEntry{}, fmt.Errorf("PDF download: %w", err)
 	}
+	checksum := Checksum(data)
 
-	link, exists := doc.Find("a.linkDownloadTeor").First().Attr("href")
-	if !exists {
-		return "", fmt.Errorf("link PDF não encontrado")
+	n, err := pdfcpuapi.PageCount(bytes.NewReader(data), nil)
+	if err != nil {
+		return CrawlEntry{Status: CrawlSkipped, Checksum: checksum, Error: err.Error()}, nil
+	}
+	if n < 1 {
+		return CrawlEntry{Status: CrawlSkipped, Checksum: checksum, Error: "no pages"}, nil
 	}
 
-	if !strings.HasPrefix(link, "http") {
-		link = "https://www.camara.leg.br" + link
+	if other, ok := state.Claim(name, checksum); !ok {
+		slog.Debug("PDF duplicado", "file", name, "dupOf", other)
+		return CrawlEntry{Status: CrawlDuplicate, Checksum: checksum, DupOf: other}, nil
 	}
-	return link, nil
-}
 
-func baixarPdfCamara(fileName, url string) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
+	total, ok := addTotals(opts, n, int64(len(data)))
+	if !ok {
+		state.Release(name, checksum)
+		return CrawlEntry{}, nil
+	}
+	// Um documento não salvo não conta nos limites nem reserva o seu conteúdo
+	unsaved := func(err error) (CrawlEntry, error) {
+		state.Release(name, checksum)
+		removeTotals(n, int64(len(data)))
+		return CrawlEntry{}, err
 	}
-	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	// Grava em tempDir e renomeia, para não deixar PDFs pela metade no corpus
+	tempFile := fmt.Sprintf("%s/%s.pdf", tempDir, name)
+	finalFile := fmt.Sprintf("%s/%s.pdf", corpusDir, name)
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		os.Remove(tempFile)
+		return unsaved(err)
+	}
+	if err := os.Rename(tempFile, finalFile); err != nil {
+		os.Remove(tempFile)
+		return unsaved(err)
 	}
 
-	return os.WriteFile(fileName, data, 0644)
+	// Sem critérios que dependam deles, falhas nos metadados não impedem o download: o
+	// documento entra no corpus sem metadados
+	if meta == nil {
+		var err error
+		if meta, err = src.Metadata(ctx, f, proposalID); err != nil {
+			slog.Warn("metadados não obtidos", "source", src.Name(), "proposal", proposalID, "err", err)
+			meta = nil
+		}
+	}
+	if meta != nil {
+		metaFile := fmt.Sprintf("%s/%s.json", metaDir, name)
+		if err := WriteMeta(metaFile, meta); err != nil {
+			slog.Warn("falha ao gravar metadados", "file", metaFile, "err", err)
+		}
+	}
+	slog.Debug("PDF salvo", "file", finalFile, "pages", n, "bytes", len(data), "total_docs", total.docs, "total_pages", total.pages)
+	return CrawlEntry{Status: CrawlDone, File: filepath.Base(finalFile), Checksum: checksum, Pages: n, Bytes: int64(len(data))}, nil
 }
 
 // ---- Contadores protegidos ----
 
-func getTotalPages() int {
-	mu.Lock()
-	defer mu.Unlock()
-	return totalPages
-}
-
-func addPages(n int) int {
+func getTotals() scrapeTotals {
 	mu.Lock()
 	defer mu.Unlock()
-	totalPages += n
-	return totalPages
+	return totals
 }
 
-func getTotalDocs() int {
+// addTotals soma um documento com pages páginas e size bytes e retorna os novos totais. Se o
+// documento passar de algum limite de opts, os totais não mudam, exceto por full, que encerra
+// a coleta (ver reached), e o retorno é false.
+func addTotals(opts ScrapeOptions, pages int, size int64) (scrapeTotals, bool) {
 	mu.Lock()
 	defer mu.Unlock()
-	return totalDocs
+	next := scrapeTotals{docs: totals.docs + 1, pages: totals.pages + pages, bytes: totals.bytes + size}
+	if opts.exceeded(next) {
+		totals.full = true
+		return totals, false
+	}
+	totals = next
+	return totals, true
 }
 
-func incDocs() int {
+// removeTotals desfaz addTotals para um documento que não chegou a ser salvo.
+func removeTotals(pages int, size int64) {
 	mu.Lock()
 	defer mu.Unlock()
-	totalDocs++
-	return totalDocs
+	totals.docs--
+	totals.pages -= pages
+	totals.bytes -= size
 }
@@ -0,0 +1,312 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pdfcpuapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/tcc2-davi-arthur/models"
+)
+
+func TestScrapeLimits(t *testing.T) {
+	opts := ScrapeOptions{MaxPages: 10, MaxDocs: 3, MaxBytes: 1000}
+	for _, c := range []struct {
+		t                 scrapeTotals
+		reached, exceeded bool
+	}{
+		{scrapeTotals{}, false, false},
+		{scrapeTotals{docs: 2, pages: 9, bytes: 999}, false, false},
+		{scrapeTotals{docs: 3, pages: 5, bytes: 10}, true, false},
+		{scrapeTotals{docs: 1, pages: 10, bytes: 10}, true, false},
+		{scrapeTotals{docs: 1, pages: 11, bytes: 10}, true, true},
+		{scrapeTotals{docs: 1, pages: 1, bytes: 1001}, true, true},
+		{scrapeTotals{docs: 4, pages: 1, bytes: 1}, true, true},
+	} {
+		if got := opts.reached(c.t); got != c.reached {
+			t.Errorf("reached(%+v) = %v, want %v", c.t, got, c.reached)
+		}
+		if got := opts.exceeded(c.t); got != c.exceeded {
+			t.Errorf("exceeded(%+v) = %v, want %v", c.t, got, c.exceeded)
+		}
+	}
+	// Sem limites, a coleta nunca para pelos totais
+	if big := (scrapeTotals{docs: 1e6, pages: 1e6, bytes: 1e12}); (ScrapeOptions{}).reached(big) || (ScrapeOptions{}).exceeded(big) {
+		t.Error("unlimited options reached a limit")
+	}
+}
+
+func TestScrapeProgress(t *testing.T) {
+	totals := scrapeTotals{docs: 2, pages: 30, bytes: 4096}
+	for _, c := range []struct {
+		opts ScrapeOptions
+		want int
+	}{
+		{ScrapeOptions{MaxPages: 100, MaxDocs: 5, MaxBytes: 1 << 20}, 30}, // páginas têm prioridade
+		{ScrapeOptions{MaxDocs: 5, MaxBytes: 1 << 20}, 2},
+		{ScrapeOptions{MaxBytes: 1 << 20}, 4096},
+		{ScrapeOptions{}, 2},
+	} {
+		progress, unit := c.opts.progress()
+		if progress == nil {
+			t.Fatal("nil progress")
+		}
+		if got := unit(totals); got != c.want {
+			t.Errorf("%+v: unit = %d, want %d", c.opts, got, c.want)
+		}
+	}
+}
+
+func TestAddTotals(t *testing.T) {
+	resetTotals(t)
+
+	opts := ScrapeOptions{MaxPages: 10}
+	if got, ok := addTotals(opts, 8, 100); !ok || got != (scrapeTotals{docs: 1, pages: 8, bytes: 100}) {
+		t.Fatalf("first document: got %+v %v", got, ok)
+	}
+	// Um documento que passaria do limite não entra nos totais, mas encerra a coleta
+	if got, ok := addTotals(opts, 5, 50); ok || got != (scrapeTotals{docs: 1, pages: 8, bytes: 100, full: true}) {
+		t.Errorf("document over the limit: got %+v %v", got, ok)
+	}
+	if !opts.reached(getTotals()) {
+		t.Error("limit should be reached after a document over it")
+	}
+
+	resetTotals(t)
+	addTotals(opts, 8, 100)
+	if got, ok := addTotals(opts, 2, 10); !ok || got != (scrapeTotals{docs: 2, pages: 10, bytes: 110}) {
+		t.Errorf("document up to the limit: got %+v %v", got, ok)
+	}
+	if !opts.reached(getTotals()) {
+		t.Error("limit should be reached")
+	}
+	removeTotals(2, 10)
+	if got := getTotals(); got != (scrapeTotals{docs: 1, pages: 8, bytes: 100}) {
+		t.Errorf("removeTotals: got %+v", got)
+	}
+}
+
+// resetTotals zera os totais do scraping até o fim do teste.
+func resetTotals(t *testing.T) {
+	mu.Lock()
+	saved := totals
+	totals = scrapeTotals{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		totals = saved
+		mu.Unlock()
+	})
+}
+
+// fakeSource serve o mesmo PDF para todas as proposições e conta as chamadas a Metadata.
+type fakeSource struct {
+	pdf      []byte
+	meta     map[string]*models.DocumentMeta
+	metaErr  error
+	metaReqs []string
+}
+
+func (this *fakeSource) Name() string   { return "fake" }
+func (this *fakeSource) Prefix() string { return "fk" }
+
+func (this *fakeSource) List(ctx context.Context, f *Fetcher, q ScrapeQuery, page int) ([]string, error) {
+	return nil, nil
+}
+
+func (this *fakeSource) Metadata(ctx context.Context, f *Fetcher, id string) (*models.DocumentMeta, error) {
+	this.metaReqs = append(this.metaReqs, id)
+	if this.metaErr != nil {
+		return nil, this.metaErr
+	}
+	return this.meta[id], nil
+}
+
+func (this *fakeSource) Document(ctx context.Context, f *Fetcher, id string) ([]byte, error) {
+	return this.pdf, nil
+}
+
+// onePagePDF gera um PDF de uma página com uma imagem.
+func onePagePDF(t *testing.T) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "page.png")
+	f, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = png.Encode(f, image.NewGray(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	pdfPath := filepath.Join(dir, "doc.pdf")
+	if err = pdfcpuapi.ImportImagesFile([]string{imgPath}, pdfPath, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// scrapeDirs cria os diretórios de trabalho do scraping em um diretório temporário e muda
+// para ele até o fim do teste.
+func scrapeDirs(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{tempDir, corpusDir, metaDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	resetTotals(t)
+}
+
+func TestFetchProposalMetadata(t *testing.T) {
+	scrapeDirs(t)
+
+	ctx := context.Background()
+	src := &fakeSource{pdf: onePagePDF(t), meta: map[string]*models.DocumentMeta{
+		"1": {Source: "fake", ProposalID: "1", Type: "PL", Year: 2020},
+		"3": {Source: "fake", ProposalID: "3", Type: "PEC", Year: 2020},
+	}}
+	state, err := LoadCrawlState("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sem critérios de metadados: o PDF é baixado primeiro e os metadados só são buscados para
+	// documentos salvos (não para a duplicata "2")
+	opts := ScrapeOptions{MaxDocs: 10}
+	entry, err := fetchProposal(ctx, src, opts, nil, state, "1")
+	if err != nil || entry.Status != CrawlDone {
+		t.Fatalf("proposal 1: %+v %v", entry, err)
+	}
+	state.Set(DocBaseName(src, "1"), entry)
+	if entry, err := fetchProposal(ctx, src, opts, nil, state, "2"); err != nil || entry.Status != CrawlDuplicate {
+		t.Fatalf("duplicate: %+v %v", entry, err)
+	}
+	if len(src.metaReqs) != 1 || src.metaReqs[0] != "1" {
+		t.Errorf("metadata requests = %v, want [1]", src.metaReqs)
+	}
+	if _, err = os.Stat(filepath.Join(metaDir, "fk_1.json")); err != nil {
+		t.Errorf("metadata not saved: %v", err)
+	}
+
+	// Falhas nos metadados não impedem o download sem critérios, mas impedem com eles
+	src.metaErr, src.metaReqs = errors.New("unavailable"), nil
+	state, _ = LoadCrawlState("")
+	if entry, err := fetchProposal(ctx, src, opts, nil, state, "4"); err != nil || entry.Status != CrawlDone {
+		t.Fatalf("metadata failure: %+v %v", entry, err)
+	}
+	opts.Query.Types = []string{"PEC"}
+	state, _ = LoadCrawlState("")
+	if _, err = fetchProposal(ctx, src, opts, nil, state, "5"); err == nil {
+		t.Error("expected metadata error with a type filter")
+	}
+
+	// Com critérios, os metadados vêm antes do download e filtram a proposição
+	src.metaErr, src.metaReqs = nil, nil
+	state, _ = LoadCrawlState("")
+	if entry, err := fetchProposal(ctx, src, opts, nil, state, "1"); err != nil || entry.Status != crawlFiltered {
+		t.Errorf("filtered: %+v %v", entry, err)
+	}
+	if entry, err := fetchProposal(ctx, src, opts, nil, state, "3"); err != nil || entry.Status != CrawlDone {
+		t.Errorf("matching proposal: %+v %v", entry, err)
+	}
+	if len(src.metaReqs) != 2 {
+		t.Errorf("metadata requests = %v, want [1 3]", src.metaReqs)
+	}
+}
+
+func TestFetchProposalWriteFailure(t *testing.T) {
+	scrapeDirs(t)
+	ctx := context.Background()
+	src := &fakeSource{pdf: onePagePDF(t)}
+	state, err := LoadCrawlState("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Um diretório no lugar do PDF final faz o rename falhar
+	blocked := filepath.Join(corpusDir, DocBaseName(src, "1")+".pdf")
+	if err = os.MkdirAll(filepath.Join(blocked, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	opts := ScrapeOptions{MaxDocs: 10}
+	if _, err = fetchProposal(ctx, src, opts, nil, state, "1"); err == nil {
+		t.Fatal("expected a write error")
+	}
+	if got := getTotals(); got != (scrapeTotals{}) {
+		t.Errorf("unsaved document counted in the totals: %+v", got)
+	}
+
+	// O conteúdo não fica reservado pela proposição que falhou
+	entry, err := fetchProposal(ctx, src, opts, nil, state, "2")
+	if err != nil || entry.Status != CrawlDone {
+		t.Errorf("same PDF from another proposal: %+v %v", entry, err)
+	}
+}
+
+// endlessSource lista proposições sem fim, cada uma com um PDF diferente.
+type endlessSource struct {
+	fakeSource
+	lists atomic.Int32
+}
+
+func (this *endlessSource) List(ctx context.Context, f *Fetcher, q ScrapeQuery, page int) ([]string, error) {
+	this.lists.Add(1)
+	ret := make([]string, 10)
+	for i := range ret {
+		ret[i] = strconv.Itoa(page*10 + i)
+	}
+	return ret, nil
+}
+
+func (this *endlessSource) Document(ctx context.Context, f *Fetcher, id string) ([]byte, error) {
+	// Bytes após o %%EOF mudam o checksum sem invalidar o PDF
+	return append(append([]byte(nil), this.pdf...), "\n% "+id+"\n"...), nil
+}
+
+func TestStartScrappingLimit(t *testing.T) {
+	scrapeDirs(t)
+	src := &endlessSource{fakeSource: fakeSource{pdf: onePagePDF(t)}}
+	size := int64(len(src.pdf)) + 8
+
+	// Cabem dois documentos; o terceiro passa do limite e encerra a coleta
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := ScrapeOptions{MaxBytes: 2*size + size/2, Workers: 3}
+	if err := StartScrapping(ctx, src, opts); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("StartScrapping only returned when the context expired")
+	}
+	files, err := os.ReadDir(corpusDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getTotals(); len(files) != 2 || got.docs != 2 || !got.full {
+		t.Errorf("expected 2 saved documents, got %d files and totals %+v", len(files), got)
+	}
+	if n := src.lists.Load(); n > 30 {
+		t.Errorf("listing kept going after the limit: %d pages", n)
+	}
+}
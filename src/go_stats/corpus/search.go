@@ -0,0 +1,421 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/models/support"
+	"github.com/tcc2-davi-arthur/query"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// ErrIndexNotReady indica que Search foi chamado antes de CreateDatabaseCaches.
+var ErrIndexNotReady = errors.New("index not loaded")
+
+// SearchOptions configura uma busca sobre o índice em memória. GramsSize e Jumps devem ser
+// os mesmos usados na indexação.
+type SearchOptions struct {
+	Algo           support.Algo
+	GramsSize      int
+	Jumps          int
+	NormalizeJumps bool
+	Parallel       bool
+	Limit          int      // 0 = sem limite
+	Facets         []string // Facetas de SearchFaceted (nil = DefaultFacets)
+	FacetLimit     int      // Máximo de valores por faceta (0 = todos)
+
+	// CollapseDuplicates mantém apenas o documento mais bem ranqueado de cada grupo de
+	// quase-duplicatas (ver Document.GroupId); os demais vão para Hit.Duplicates.
+	CollapseDuplicates bool
+}
+
+// Hit é um documento retornado por Search com a sua similaridade com a consulta.
+type Hit struct {
+	Doc   *models.Document
+	Score float64
+
+	Duplicates []*models.Document // Quase-duplicatas agrupadas neste hit (SearchOptions.CollapseDuplicates)
+}
+
+// SearchResult é o resultado de SearchFaceted: os documentos mais bem ranqueados, o total de
+// documentos encontrados e as facetas calculadas sobre todos eles (não apenas os de Hits).
+type SearchResult struct {
+	Hits   []Hit
+	Total  int
+	Facets []Facet
+}
+
+// span é uma ocorrência de uma cláusula posicional, da primeira à última posição.
+type span struct {
+	start, end int
+}
+
+// Search interpreta q (ver query.Parse), filtra os documentos pela expressão booleana usando
+// o índice posicional e os filtros de campo (FieldFilters) antes do ranking, e ordena os
+// candidatos pela similaridade de cosseno entre os vetores TF-IDF/BM25 da consulta e de
+// cada documento.
+func Search(ctx context.Context, q string, opts SearchOptions) ([]Hit, error) {
+	ret, err := rank(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(ret) > opts.Limit {
+		ret = ret[:opts.Limit]
+	}
+	return ret, nil
+}
+
+// SearchFaceted executa Search e agrupa todos os documentos encontrados pelas facetas de
+// opts (ver CountFacets), permitindo refinar a consulta com filtros de campo.
+func SearchFaceted(ctx context.Context, q string, opts SearchOptions) (*SearchResult, error) {
+	hits, err := rank(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names := opts.Facets
+	if names == nil {
+		names = DefaultFacets
+	}
+	facets, err := CountFacets(hits, names, opts.FacetLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &SearchResult{Total: len(hits), Facets: facets, Hits: hits}
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		ret.Hits = hits[:opts.Limit]
+	}
+	return ret, nil
+}
+
+// rank retorna todos os documentos que satisfazem q, do mais para o menos similar.
+func rank(ctx context.Context, q string, opts SearchOptions) ([]Hit, error) {
+	if Analyzer == nil || CacheDocs == nil || CachePositions == nil {
+		return nil, ErrIndexNotReady
+	}
+	tree, err := query.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[uint16]*models.Document, len(CacheDocs))
+	for _, doc := range CacheDocs {
+		byId[doc.ID] = doc
+	}
+
+	candidates, ok, err := matchDocs(tree, byId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		candidates = allDocs(byId)
+	}
+
+	var queryVec map[string]*float64
+	if text := Analyzer.AnalyzeString(query.Text(tree)); text != "" {
+		if opts.Algo == support.Bm25 {
+			queryVec, err = utils.ComputeStringBM25(ctx, text, opts.GramsSize, opts.Jumps, len(CacheDocs), CountAllNGrams, CacheGrams, CacheWords, opts.NormalizeJumps, opts.Parallel)
+		} else {
+			queryVec, err = utils.ComputeStringTFIDF(ctx, text, opts.GramsSize, opts.Jumps, len(CacheDocs), CacheGrams, CacheWords, opts.NormalizeJumps, opts.Parallel)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute query vector: %w", err)
+		}
+	}
+
+	ret := make([]Hit, 0, len(candidates))
+	for id := range candidates {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		score := 0.0
+		if queryVec != nil {
+			docVec, err := docVector(ctx, id, opts.Algo, opts.NormalizeJumps, opts.Parallel)
+			if err != nil {
+				return nil, err
+			}
+			score = utils.CosineSimMaps(queryVec, docVec)
+		}
+		ret = append(ret, Hit{Doc: byId[id], Score: score})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Score == ret[j].Score {
+			return ret[i].Doc.ID < ret[j].Doc.ID
+		}
+		return ret[i].Score > ret[j].Score
+	})
+	if opts.CollapseDuplicates {
+		ret = collapseHits(ret)
+	}
+	return ret, nil
+}
+
+// collapseHits agrupa hits ordenados por Document.GroupId, mantendo o primeiro de cada grupo.
+func collapseHits(hits []Hit) []Hit {
+	first := make(map[uint16]int, len(hits))
+	ret := hits[:0]
+	for _, h := range hits {
+		group := h.Doc.GroupId()
+		if i, ok := first[group]; ok {
+			ret[i].Duplicates = append(ret[i].Duplicates, h.Doc)
+			continue
+		}
+		first[group] = len(ret)
+		ret = append(ret, h)
+	}
+	return ret
+}
+
+// matchDocs retorna os documentos que satisfazem n. O segundo retorno é false quando a
+// cláusula não restringe nada (ex.: termos removidos pelo Analyzer) e deve ser ignorada.
+func matchDocs(n query.Node, all map[uint16]*models.Document) (map[uint16]bool, bool, error) {
+	switch v := n.(type) {
+	case *query.Bool:
+		var ret map[uint16]bool
+		for _, c := range v.Must {
+			docs, ok, err := matchDocs(c, all)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				ret = intersect(ret, docs)
+			}
+		}
+
+		// Sem cláusulas obrigatórias, os candidatos são os documentos de qualquer termo.
+		if ret == nil {
+			for _, c := range v.Should {
+				docs, ok, err := matchDocs(c, all)
+				if err != nil {
+					return nil, false, err
+				}
+				if ok {
+					ret = union(ret, docs)
+				}
+			}
+		}
+		if ret == nil {
+			ret = allDocs(all)
+		}
+
+		for _, c := range v.MustNot {
+			docs, ok, err := matchDocs(c, all)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				ret = subtract(ret, docs)
+			}
+		}
+		return ret, true, nil
+
+	case *query.And:
+		var ret map[uint16]bool
+		for _, c := range v.Children {
+			docs, ok, err := matchDocs(c, all)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				ret = intersect(ret, docs)
+			}
+		}
+		return ret, ret != nil, nil
+
+	case *query.Or:
+		var ret map[uint16]bool
+		for _, c := range v.Children {
+			docs, ok, err := matchDocs(c, all)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				// Um lado sem restrição torna o OR irrestrito.
+				return nil, false, nil
+			}
+			ret = union(ret, docs)
+		}
+		return ret, true, nil
+
+	case *query.Not:
+		docs, ok, err := matchDocs(v.Child, all)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		return subtract(allDocs(all), docs), true, nil
+
+	case *query.Field:
+		filter, ok := FieldFilters[v.Name]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: unknown field %q", query.ErrSyntax, v.Name)
+		}
+		ret := make(map[uint16]bool)
+		for id, doc := range all {
+			match, err := filter(doc, v.Value)
+			if err != nil {
+				return nil, false, err
+			}
+			if match {
+				ret[id] = true
+			}
+		}
+		return ret, true, nil
+
+	default:
+		occ, ok, err := occurrences(n)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		ret := make(map[uint16]bool, len(occ))
+		for id := range occ {
+			ret[id] = true
+		}
+		return ret, true, nil
+	}
+}
+
+// occurrences localiza as ocorrências de uma cláusula posicional (termo, frase ou NEAR) em
+// cada documento. O segundo retorno é false quando a cláusula não tem termos após a análise.
+func occurrences(n query.Node) (map[uint16][]span, bool, error) {
+	switch v := n.(type) {
+	case *query.Term:
+		occ, ok := phraseOccurrences(Analyzer.Analyze(v.Value))
+		return occ, ok, nil
+	case *query.Phrase:
+		occ, ok := phraseOccurrences(Analyzer.Analyze(query.Text(v)))
+		return occ, ok, nil
+	case *query.Near:
+		left, okL, err := occurrences(v.Left)
+		if err != nil {
+			return nil, false, err
+		}
+		right, okR, err := occurrences(v.Right)
+		if err != nil {
+			return nil, false, err
+		}
+		switch {
+		case !okL && !okR:
+			return nil, false, nil
+		case !okL:
+			return right, true, nil
+		case !okR:
+			return left, true, nil
+		}
+		ret := make(map[uint16][]span)
+		for id, ls := range left {
+			if rs, ok := right[id]; ok {
+				if near := nearSpans(ls, rs, v.Distance); len(near) > 0 {
+					ret[id] = near
+				}
+			}
+		}
+		return ret, true, nil
+	default:
+		return nil, false, fmt.Errorf("%w: NEAR operands must be words or phrases, got %s", query.ErrSyntax, n)
+	}
+}
+
+// phraseOccurrences encontra os tokens adjacentes e na ordem (salto 1 entre cada par, como
+// em GetGramsLim) usando o índice posicional.
+func phraseOccurrences(tokens []string) (map[uint16][]span, bool) {
+	if len(tokens) == 0 {
+		return nil, false
+	}
+
+	postings := make([]map[uint16][]int, len(tokens))
+	for i, t := range tokens {
+		word, ok := CacheWords[t]
+		if !ok {
+			return map[uint16][]span{}, true
+		}
+		postings[i] = CachePositions[word.ID]
+	}
+
+	jumps := make([]int, len(tokens)-1)
+	for i := range jumps {
+		jumps[i] = 1
+	}
+
+	ret := make(map[uint16][]span)
+	for id, first := range postings[0] {
+		lists := [][]int{first}
+		for _, p := range postings[1:] {
+			if p[id] == nil {
+				lists = nil
+				break
+			}
+			lists = append(lists, p[id])
+		}
+		if lists == nil {
+			continue
+		}
+		for _, start := range utils.MatchJumps(lists, jumps, true) {
+			ret[id] = append(ret[id], span{start: start, end: start + len(tokens) - 1})
+		}
+	}
+	return ret, true
+}
+
+// nearSpans combina ocorrências de dois lados que estejam a no máximo k posições (em qualquer
+// ordem); cada par vira um span cobrindo os dois.
+func nearSpans(left, right []span, k int) []span {
+	var ret []span
+	for _, l := range left {
+		for _, r := range right {
+			if (r.start > l.end && r.start-l.end <= k) || (l.start > r.end && l.start-r.end <= k) {
+				ret = append(ret, span{start: min(l.start, r.start), end: max(l.end, r.end)})
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].start < ret[j].start
+	})
+	return ret
+}
+
+func allDocs(all map[uint16]*models.Document) map[uint16]bool {
+	ret := make(map[uint16]bool, len(all))
+	for id := range all {
+		ret[id] = true
+	}
+	return ret
+}
+
+func union(a, b map[uint16]bool) map[uint16]bool {
+	ret := make(map[uint16]bool, len(a)+len(b))
+	for id := range a {
+		ret[id] = true
+	}
+	for id := range b {
+		ret[id] = true
+	}
+	return ret
+}
+
+func subtract(a, b map[uint16]bool) map[uint16]bool {
+	ret := make(map[uint16]bool, len(a))
+	for id := range a {
+		if !b[id] {
+			ret[id] = true
+		}
+	}
+	return ret
+}
+
+func intersect(a, b map[uint16]bool) map[uint16]bool {
+	if a == nil {
+		return b
+	}
+	ret := make(map[uint16]bool)
+	for id := range a {
+		if b[id] {
+			ret[id] = true
+		}
+	}
+	return ret
+}
@@ -0,0 +1,152 @@
+package corpus
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/query"
+)
+
+// docIDs converte os nomes dos documentos em IDs do índice carregado.
+func docIDs(names ...string) map[uint16]bool {
+	ret := make(map[uint16]bool, len(names))
+	for _, name := range names {
+		ret[CacheDocs[name].ID] = true
+	}
+	return ret
+}
+
+func TestPhraseNear(t *testing.T) {
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "servidor publico direito adquirido estabilidade",
+		"doc_0002_clean.txt": "estabilidade garantida servidor efetivo",
+		"doc_0003_clean.txt": "licitação de obras públicas",
+		"doc_0004_clean.txt": "licitação pública de obras",
+	})
+	openIndex(t, 1, true)
+	doc := func(n int) uint16 { return CacheDocs["doc_000"+string(rune('0'+n))+"_clean.txt"].ID }
+
+	// A stopword removida não conta como posição: a frase casa em doc_0003, mas não em
+	// doc_0004, em que outra palavra separa os termos
+	occ, ok := phraseOccurrences(Analyzer.Analyze("licitação de obras"))
+	if want := map[uint16][]span{doc(3): {{start: 0, end: 1}}}; !ok || !reflect.DeepEqual(occ, want) {
+		t.Errorf("phrase: got %v %v, want %v", occ, ok, want)
+	}
+	if occ, ok = phraseOccurrences(Analyzer.Analyze("servidor inexistente")); !ok || len(occ) != 0 {
+		t.Errorf("unknown word: got %v %v", occ, ok)
+	}
+	if _, ok = phraseOccurrences(Analyzer.Analyze("de")); ok {
+		t.Error("a phrase of stopwords should not restrict the results")
+	}
+
+	// NEAR/k aceita os dois termos em qualquer ordem, a até k posições (servidor e
+	// estabilidade estão a 4 posições em doc_0001 e a 2, invertidos, em doc_0002)
+	near := func(k int) map[uint16][]span {
+		t.Helper()
+		occ, ok, err := occurrences(&query.Near{Left: &query.Term{Value: "servidor"}, Right: &query.Term{Value: "estabilidade"}, Distance: k})
+		if err != nil || !ok {
+			t.Fatalf("NEAR/%d: %v %v", k, ok, err)
+		}
+		return occ
+	}
+	for k, want := range map[int]map[uint16][]span{
+		1: {},
+		2: {doc(2): {{start: 0, end: 2}}},
+		3: {doc(2): {{start: 0, end: 2}}},
+		4: {doc(1): {{start: 0, end: 4}}, doc(2): {{start: 0, end: 2}}},
+	} {
+		if got := near(k); !reflect.DeepEqual(got, want) {
+			t.Errorf("NEAR/%d: got %v, want %v", k, got, want)
+		}
+	}
+
+	// Uma frase pode ser operando de NEAR; o span cobre a frase inteira
+	occ, _, err := occurrences(&query.Near{Left: &query.Phrase{Terms: []string{"licitação", "de", "obras"}}, Right: &query.Term{Value: "públicas"}, Distance: 1})
+	if want := map[uint16][]span{doc(3): {{start: 0, end: 2}}}; err != nil || !reflect.DeepEqual(occ, want) {
+		t.Errorf("phrase NEAR: got %v %v, want %v", occ, err, want)
+	}
+
+	// O mesmo pela consulta
+	for q, want := range map[string]map[uint16]bool{
+		`"licitação de obras"`:         docIDs("doc_0003_clean.txt"),
+		`servidor NEAR/2 estabilidade`: docIDs("doc_0002_clean.txt"),
+		`estabilidade NEAR/4 servidor`: docIDs("doc_0001_clean.txt", "doc_0002_clean.txt"),
+	} {
+		hits, err := Search(context.Background(), q, SearchOptions{GramsSize: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[uint16]bool, len(hits))
+		for _, h := range hits {
+			got[h.Doc.ID] = true
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestSearchBoolean(t *testing.T) {
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "licitação obras saneamento",
+		"doc_0002_clean.txt": "licitação saúde",
+		"doc_0003_clean.txt": "obras saúde",
+		"doc_0004_clean.txt": "educação escolas",
+	})
+	if err := os.MkdirAll(MetaDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, meta := range map[string]*models.DocumentMeta{
+		"doc_0001_clean.txt": {Source: "camara", ProposalID: "1", Type: "PEC", Number: 1, Year: 2019},
+		"doc_0002_clean.txt": {Source: "senado", ProposalID: "2", Type: "PL", Number: 2, Year: 2021},
+		"doc_0003_clean.txt": {Source: "camara", ProposalID: "3", Type: "PL", Number: 3, Year: 2020},
+	} {
+		if err := WriteMeta(MetaDir+"/"+MetaFileName(name), meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openIndex(t, 1, false)
+
+	// OR tem a menor precedência, depois AND, depois as cláusulas justapostas (NOT e '-' valem
+	// só para a cláusula seguinte). Um NOT no início exclui de todos os documentos, inclusive
+	// os sem metadados; palavras soltas ao lado de um filtro só contam para o ranking.
+	for q, want := range map[string][]string{
+		`licitação OR obras AND saúde`:   {"doc_0001_clean.txt", "doc_0002_clean.txt", "doc_0003_clean.txt"},
+		`(licitação OR obras) AND saúde`: {"doc_0002_clean.txt", "doc_0003_clean.txt"},
+		`licitação AND NOT saúde`:        {"doc_0001_clean.txt"},
+		`NOT licitação`:                  {"doc_0003_clean.txt", "doc_0004_clean.txt"},
+		`NOT saúde OR licitação`:         {"doc_0001_clean.txt", "doc_0002_clean.txt", "doc_0004_clean.txt"},
+		`NOT (saúde OR licitação)`:       {"doc_0004_clean.txt"},
+		`-obras`:                         {"doc_0002_clean.txt", "doc_0004_clean.txt"},
+		`tipo:pl AND obras`:              {"doc_0003_clean.txt"},
+		`tipo:pl OR educação`:            {"doc_0002_clean.txt", "doc_0003_clean.txt", "doc_0004_clean.txt"},
+		`fonte:camara licitação`:         {"doc_0001_clean.txt", "doc_0003_clean.txt"},
+		`ano:2019..2020 -saneamento`:     {"doc_0003_clean.txt"},
+		`tipo:pec "obras saneamento"`:    {"doc_0001_clean.txt"},
+		`tipo:pec saúde`:                 {"doc_0001_clean.txt"},
+	} {
+		hits, err := Search(context.Background(), q, SearchOptions{GramsSize: 1})
+		if err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		got := make(map[uint16]bool, len(hits))
+		for _, h := range hits {
+			got[h.Doc.ID] = true
+		}
+		if !reflect.DeepEqual(got, docIDs(want...)) {
+			t.Errorf("%s: got %v, want %v", q, got, want)
+		}
+	}
+
+	// O termo ao lado do filtro ordena os documentos filtrados
+	hits, err := Search(context.Background(), `fonte:camara licitação`, SearchOptions{GramsSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 || hits[0].Doc.Name != "doc_0001_clean.txt" || hits[0].Score <= hits[1].Score {
+		t.Errorf("expected doc_0001 to rank first, got %+v", hits)
+	}
+}
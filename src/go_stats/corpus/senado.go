@@ -0,0 +1,277 @@
+package corpus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tcc2-davi-arthur/models"
+)
+
+// Senado coleta matérias do Senado Federal pela API de Dados Abertos. A pesquisa da API não
+// é paginada, então cada página de List corresponde a um ano com resultados, do mais recente
+// (ToYear) ao mais antigo (FromYear); os anos de ScrapeQuery, se informados, substituem os da
+// fonte.
+type Senado struct {
+	DataURL   string   // API de Dados Abertos (/dadosabertos)
+	PortalURL string   // Página pública da matéria
+	Types     []string // Siglas buscadas
+	FromYear  int
+	ToYear    int // 0 = ano corrente
+
+	mu        sync.Mutex
+	pageYears map[int]int // Ano de cada página já listada, reiniciado na página 1
+}
+
+// NewSenado cria a fonte com os endereços oficiais, buscando PEC, PLP, PL (PLS até 2018) e
+// PDL desde 2000.
+func NewSenado() *Senado {
+	return &Senado{
+		DataURL:   "https://legis.senado.leg.br/dadosabertos",
+		PortalURL: "https://www25.senado.leg.br/web/atividade/materias/-/materia",
+		Types:     []string{"PEC", "PLP", "PL", "PDL"},
+		FromYear:  2000,
+	}
+}
+
+func (this *Senado) Name() string {
+	return "senado"
+}
+
+func (this *Senado) Prefix() string {
+	return "sf"
+}
+
+// oneOrMany decodifica listas da API do Senado, que vêm como objeto quando têm um só item.
+type oneOrMany[T any] []T
+
+func (this *oneOrMany[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, (*[]T)(this))
+	}
+	if bytes.Equal(data, []byte("null")) {
+		*this = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*this = oneOrMany[T]{v}
+	return nil
+}
+
+type senadoIdent struct {
+	CodigoMateria       string `json:"CodigoMateria"`
+	SiglaSubtipoMateria string `json:"SiglaSubtipoMateria"`
+	NumeroMateria       string `json:"NumeroMateria"`
+	AnoMateria          string `json:"AnoMateria"`
+}
+
+// senadoSearch é a resposta de /materia/pesquisa/lista.
+type senadoSearch struct {
+	PesquisaBasicaMateria struct {
+		Materias struct {
+			Materia oneOrMany[struct {
+				IdentificacaoMateria senadoIdent `json:"IdentificacaoMateria"`
+				DadosBasicosMateria  struct {
+					EmentaMateria string `json:"EmentaMateria"`
+				} `json:"DadosBasicosMateria"`
+			}] `json:"Materia"`
+		} `json:"Materias"`
+	} `json:"PesquisaBasicaMateria"`
+}
+
+// List pesquisa as matérias de um ano por sigla; q.Keyword é procurada na ementa. Anos sem
+// resultados são pulados: a página seguinte à de ano N começa em N-1, e o retorno só é vazio
+// quando não há mais anos com resultados.
+func (this *Senado) List(ctx context.Context, f *Fetcher, q ScrapeQuery, page int) ([]string, error) {
+	from, to := this.FromYear, this.ToYear
+	if q.FromYear != 0 {
+		from = q.FromYear
+	}
+	if q.ToYear != 0 {
+		to = q.ToYear
+	}
+	if to == 0 {
+		to = time.Now().Year()
+	}
+	if page < 1 {
+		return nil, nil
+	}
+	year := to - page + 1
+	this.mu.Lock()
+	if page == 1 || this.pageYears == nil {
+		this.pageYears = make(map[int]int)
+	}
+	if prev, ok := this.pageYears[page-1]; ok {
+		year = prev - 1
+	}
+	this.mu.Unlock()
+
+	for ; year >= from; year-- {
+		ids, err := this.listYear(ctx, f, q, year)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			this.mu.Lock()
+			this.pageYears[page] = year
+			this.mu.Unlock()
+			return ids, nil
+		}
+	}
+	return nil, nil
+}
+
+// listYear pesquisa as matérias de year com as siglas de q (ou da fonte).
+func (this *Senado) listYear(ctx context.Context, f *Fetcher, q ScrapeQuery, year int) ([]string, error) {
+	types := this.Types
+	if len(q.Types) > 0 {
+		types = q.Types
+	}
+
+	var ret []string
+	for _, t := range types {
+		for _, sigla := range senadoSiglas(t, year) {
+			params := url.Values{"sigla": {sigla}, "ano": {strconv.Itoa(year)}}
+			var resp senadoSearch
+			if err := f.GetJSON(ctx, this.DataURL+"/materia/pesquisa/lista?"+params.Encode(), &resp); err != nil {
+				return nil, err
+			}
+			for _, m := range resp.PesquisaBasicaMateria.Materias.Materia {
+				if q.Keyword != "" && !containsFolded(m.DadosBasicosMateria.EmentaMateria, q.Keyword) {
+					continue
+				}
+				if id := m.IdentificacaoMateria.CodigoMateria; id != "" {
+					ret = append(ret, id)
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+// senadoUnifiedYear é o ano em que as siglas do Senado passaram a ser as mesmas da Câmara:
+// até 2018 os projetos de lei apresentados no Senado eram "PLS".
+const senadoUnifiedYear = 2019
+
+// senadoSiglas retorna as siglas pesquisadas na API para o tipo t (PL, PEC, ...) no ano year.
+func senadoSiglas(t string, year int) []string {
+	t = strings.ToUpper(t)
+	if t == "PL" && year < senadoUnifiedYear {
+		return []string{"PL", "PLS"}
+	}
+	return []string{t}
+}
+
+// senadoType converte a sigla de uma matéria no tipo usado no corpus (PLS -> PL).
+func senadoType(sigla string) string {
+	sigla = strings.ToUpper(strings.TrimSpace(sigla))
+	if sigla == "PLS" {
+		return "PL"
+	}
+	return sigla
+}
+
+// senadoDetail é a resposta de /materia/{codigo}.
+type senadoDetail struct {
+	DetalheMateria struct {
+		Materia struct {
+			IdentificacaoMateria senadoIdent `json:"IdentificacaoMateria"`
+			DadosBasicosMateria  struct {
+				EmentaMateria    string `json:"EmentaMateria"`
+				DataApresentacao string `json:"DataApresentacao"`
+			} `json:"DadosBasicosMateria"`
+			Classificacoes struct {
+				Classificacao oneOrMany[struct {
+					DescricaoClasse string `json:"DescricaoClasse"`
+				}] `json:"Classificacao"`
+			} `json:"Classificacoes"`
+			Autoria struct {
+				Autor oneOrMany[struct {
+					NomeAutor string `json:"NomeAutor"`
+				}] `json:"Autor"`
+			} `json:"Autoria"`
+			SituacaoAtual struct {
+				Autuacoes struct {
+					Autuacao oneOrMany[struct {
+						Situacao struct {
+							DescricaoSituacao string `json:"DescricaoSituacao"`
+						} `json:"Situacao"`
+					}] `json:"Autuacao"`
+				} `json:"Autuacoes"`
+			} `json:"SituacaoAtual"`
+		} `json:"Materia"`
+	} `json:"DetalheMateria"`
+}
+
+func (this *Senado) Metadata(ctx context.Context, f *Fetcher, id string) (*models.DocumentMeta, error) {
+	var resp senadoDetail
+	if err := f.GetJSON(ctx, fmt.Sprintf("%s/materia/%s", this.DataURL, id), &resp); err != nil {
+		return nil, err
+	}
+	m := resp.DetalheMateria.Materia
+
+	ret := &models.DocumentMeta{
+		Source:     this.Name(),
+		ProposalID: id,
+		Type:       senadoType(m.IdentificacaoMateria.SiglaSubtipoMateria),
+		Ementa:     strings.TrimSpace(m.DadosBasicosMateria.EmentaMateria),
+		SourceURL:  fmt.Sprintf("%s/%s", this.PortalURL, id),
+	}
+	ret.Number, _ = strconv.Atoi(m.IdentificacaoMateria.NumeroMateria)
+	ret.Year, _ = strconv.Atoi(m.IdentificacaoMateria.AnoMateria)
+	for _, a := range m.Autoria.Autor {
+		if name := strings.TrimSpace(a.NomeAutor); name != "" {
+			ret.Authors = append(ret.Authors, name)
+		}
+	}
+	for _, c := range m.Classificacoes.Classificacao {
+		if name := strings.TrimSpace(c.DescricaoClasse); name != "" {
+			ret.Themes = append(ret.Themes, name)
+		}
+	}
+	if a := m.SituacaoAtual.Autuacoes.Autuacao; len(a) > 0 {
+		ret.Status = a[0].Situacao.DescricaoSituacao
+	}
+	if t, ok := parseDate(m.DadosBasicosMateria.DataApresentacao); ok {
+		ret.SubmittedAt = &t
+	}
+	return ret, nil
+}
+
+// senadoTexts é a resposta de /materia/textos/{codigo}.
+type senadoTexts struct {
+	TextoMateria struct {
+		Materia struct {
+			Textos struct {
+				Texto oneOrMany[struct {
+					UrlTexto     string `json:"UrlTexto"`
+					FormatoTexto string `json:"FormatoTexto"`
+				}] `json:"Texto"`
+			} `json:"Textos"`
+		} `json:"Materia"`
+	} `json:"TextoMateria"`
+}
+
+// Document baixa o primeiro texto em PDF da matéria (o texto inicial, na ordem da API).
+func (this *Senado) Document(ctx context.Context, f *Fetcher, id string) ([]byte, error) {
+	var resp senadoTexts
+	if err := f.GetJSON(ctx, fmt.Sprintf("%s/materia/textos/%s", this.DataURL, id), &resp); err != nil {
+		return nil, err
+	}
+	for _, t := range resp.TextoMateria.Materia.Textos.Texto {
+		if t.UrlTexto != "" && strings.Contains(strings.ToLower(t.FormatoTexto), "pdf") {
+			return f.Get(ctx, t.UrlTexto, nil)
+		}
+	}
+	return nil, fmt.Errorf("matéria %s sem texto em PDF", id)
+}
@@ -0,0 +1,165 @@
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/models/support"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// ErrDocNotFound indica que o documento pedido não está no índice.
+var ErrDocNotFound = errors.New("document not indexed")
+
+// EmbeddingsFile é o arquivo padrão com os embeddings dos documentos gerados pelo cmd_embed.
+const EmbeddingsFile = "./../../misc/corpus/embeddings.json"
+
+// SimilarOptions configura Similar. Sem Embeddings, os documentos são comparados pelos
+// vetores TF-IDF/BM25 do índice (Algo, NormalizeJumps e Parallel como em SearchOptions).
+type SimilarOptions struct {
+	Algo           support.Algo
+	NormalizeJumps bool
+	Parallel       bool
+
+	// Embeddings, se não nil, substitui os vetores esparsos pelos embeddings densos de cada
+	// documento (ver LoadEmbeddings); documentos sem embedding são ignorados.
+	Embeddings map[uint16][]float32
+}
+
+// Similar retorna os k documentos mais similares (cosseno) ao documento docId, do mais para o
+// menos similar, sem o próprio documento e sem as suas quase-duplicatas (Document.GroupId).
+// k <= 0 retorna todos.
+func Similar(ctx context.Context, docId uint16, k int, opts SimilarOptions) ([]Hit, error) {
+	if CacheDocs == nil || Docs == nil {
+		return nil, ErrIndexNotReady
+	}
+	byId := make(map[uint16]*models.Document, len(CacheDocs))
+	for _, doc := range CacheDocs {
+		byId[doc.ID] = doc
+	}
+	source, ok := byId[docId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrDocNotFound, docId)
+	}
+
+	// score retorna a similaridade de id com o documento de origem (false = sem vetor)
+	var score func(id uint16) (float64, bool, error)
+	if opts.Embeddings != nil {
+		srcVec, ok := opts.Embeddings[docId]
+		if !ok {
+			return nil, fmt.Errorf("%w: no embedding for %s", ErrDocNotFound, source.Name)
+		}
+		score = func(id uint16) (float64, bool, error) {
+			vec, ok := opts.Embeddings[id]
+			if !ok {
+				return 0, false, nil
+			}
+			sim, err := utils.CosineSimVecs(srcVec, vec)
+			return float64(sim), true, err
+		}
+	} else {
+		srcVec, err := docVector(ctx, docId, opts.Algo, opts.NormalizeJumps, opts.Parallel)
+		if err != nil {
+			return nil, err
+		}
+		score = func(id uint16) (float64, bool, error) {
+			if len(Docs[id]) == 0 {
+				return 0, false, nil
+			}
+			vec, err := docVector(ctx, id, opts.Algo, opts.NormalizeJumps, opts.Parallel)
+			if err != nil {
+				return 0, false, err
+			}
+			return utils.CosineSimMaps(srcVec, vec), true, nil
+		}
+	}
+
+	ret := make([]Hit, 0, len(byId))
+	for id, doc := range byId {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if doc.GroupId() == source.GroupId() {
+			continue
+		}
+		s, ok, err := score(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare document %d: %w", id, err)
+		}
+		if !ok {
+			continue
+		}
+		ret = append(ret, Hit{Doc: doc, Score: s})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Score == ret[j].Score {
+			return ret[i].Doc.ID < ret[j].Doc.ID
+		}
+		return ret[i].Score > ret[j].Score
+	})
+	if k > 0 && len(ret) > k {
+		ret = ret[:k]
+	}
+	return ret, nil
+}
+
+// docVector calcula o vetor TF-IDF ou BM25 do documento id a partir do índice em memória.
+func docVector(ctx context.Context, id uint16, algo support.Algo, normalizeJumps, parallel bool) (map[string]*float64, error) {
+	var ret map[string]*float64
+	var err error
+	if algo == support.Bm25 {
+		ret, err = utils.ComputeDocPreIndexedBM25(ctx, Docs[id], len(CacheDocs), CountAllNGrams, CacheGrams, normalizeJumps, parallel)
+	} else {
+		ret, err = utils.ComputeDocPreIndexedTFIDF(ctx, Docs[id], len(CacheDocs), CacheGrams, normalizeJumps, parallel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute vector of document %d: %w", id, err)
+	}
+	return ret, nil
+}
+
+// SaveEmbeddings grava em path os embeddings dos documentos, indexados pelo nome do arquivo.
+func SaveEmbeddings(path string, vecs map[string][]float32) error {
+	data, err := json.Marshal(vecs)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadEmbeddings lê os embeddings gravados por SaveEmbeddings e os associa aos documentos
+// indexados (CacheDocs) pelo nome; embeddings de documentos fora do índice são ignorados.
+func LoadEmbeddings(path string) (map[uint16][]float32, error) {
+	if CacheDocs == nil {
+		return nil, ErrIndexNotReady
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vecs map[string][]float32
+	if err = json.Unmarshal(data, &vecs); err != nil {
+		return nil, fmt.Errorf("invalid embeddings file %s: %w", path, err)
+	}
+	ret := make(map[uint16][]float32, len(vecs))
+	for name, vec := range vecs {
+		if doc, ok := CacheDocs[name]; ok {
+			ret[doc.ID] = vec
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,106 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/models/interfaces"
+	"github.com/tcc2-davi-arthur/models/support"
+)
+
+func TestSimilarEmbeddings(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	CacheDocs = map[string]*models.Document{
+		"a.txt": {ID: 1, Name: "a.txt"},
+		"b.txt": {ID: 2, Name: "b.txt", DupOf: 1},
+		"c.txt": {ID: 3, Name: "c.txt"},
+		"d.txt": {ID: 4, Name: "d.txt"},
+		"e.txt": {ID: 5, Name: "e.txt"},
+	}
+	Docs = map[uint16][]interfaces.IGram{}
+
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	err := SaveEmbeddings(path, map[string][]float32{
+		"a.txt": {1, 0}, "b.txt": {1, 0}, "c.txt": {0.6, 0.8}, "d.txt": {-1, 0.1}, "x.txt": {1, 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vecs, err := LoadEmbeddings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vecs) != 4 {
+		t.Fatalf("expected 4 indexed embeddings, got %d", len(vecs))
+	}
+
+	hits, err := Similar(context.Background(), 2, 0, SimilarOptions{Embeddings: vecs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a.txt é o canônico de b.txt e e.txt não tem embedding
+	if len(hits) != 2 || hits[0].Doc.Name != "c.txt" || hits[1].Doc.Name != "d.txt" || hits[1].Score >= 0 {
+		t.Errorf("unexpected hits %+v", hits)
+	}
+
+	if _, err = Similar(context.Background(), 5, 1, SimilarOptions{Embeddings: vecs}); !errors.Is(err, ErrDocNotFound) {
+		t.Errorf("expected ErrDocNotFound, got %v", err)
+	}
+	if _, err = Similar(context.Background(), 9, 1, SimilarOptions{}); !errors.Is(err, ErrDocNotFound) {
+		t.Errorf("expected ErrDocNotFound, got %v", err)
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "licitação pública contratos administrativos pregão eletrônico",
+		"doc_0002_clean.txt": "licitação pública contratos administrativos pregão eletrônico federal",
+		"doc_0003_clean.txt": "licitação pública contratos obras rodovias",
+		"doc_0004_clean.txt": "pregão hospitais saúde vacinas",
+		"doc_0005_clean.txt": "código penal crimes ambientais",
+	})
+	db := openIndex(t, 1, true)
+	doc := func(n int) *models.Document {
+		return CacheDocs[fmt.Sprintf("doc_%04d_clean.txt", n)]
+	}
+	clusters := []DupCluster{{Canonical: doc(1), Duplicates: []DupMember{{Doc: doc(2)}}}}
+	if err := MarkDuplicates(db, clusters); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, algo := range []support.Algo{support.TdIdf, support.Bm25} {
+		opts := SimilarOptions{Algo: algo}
+
+		// Nem a origem nem a sua quase-duplicata aparecem; a ordem segue os termos em comum.
+		hits, err := Similar(context.Background(), doc(1).ID, 0, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, h := range hits {
+			got = append(got, h.Doc.Name)
+		}
+		want := []string{doc(3).Name, doc(4).Name, doc(5).Name}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %v, want %v", algo, got, want)
+		}
+		if hits[0].Score <= hits[1].Score || hits[1].Score <= 0 || hits[2].Score != 0 {
+			t.Errorf("%s: unexpected scores %+v", algo, hits)
+		}
+
+		// A partir da duplicata o canônico também fica de fora, e k limita os hits.
+		dupHits, err := Similar(context.Background(), doc(2).ID, 1, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dupHits) != 1 || dupHits[0].Doc != doc(3) {
+			t.Errorf("%s: unexpected hits from the duplicate %+v", algo, dupHits)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package corpus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/models"
+	"github.com/tcc2-davi-arthur/utils"
+	"gorm.io/gorm"
+)
+
+// SnapshotsDir guarda uma cópia de cada manifesto, para que o ID registrado nos resultados
+// dos benchmarks possa ser consultado depois que os bancos temporários forem apagados.
+const SnapshotsDir = "./../../misc/corpus/snapshots"
+
+// Snapshot é o manifesto do corpus indexado pelo índice atual (nil antes de CreateDatabaseCaches).
+var Snapshot *Manifest
+
+// ManifestFile é um arquivo do corpus em um snapshot.
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest descreve uma versão do corpus: os arquivos indexados e o pipeline que os
+// processou. O ID é derivado do conteúdo (tudo menos CreatedAt e Inferred), então o mesmo
+// corpus processado da mesma forma sempre tem o mesmo ID.
+type Manifest struct {
+	ID             string         `json:"id"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	CleanerVersion string         `json:"cleanerVersion"` // analysis.Version
+	Analyzer       string         `json:"analyzer"`       // analysis.Config.Signature
+	Stopwords      []string       `json:"stopwords"`      // Stopwords além da lista fixa de stopwords_pt
+	StopwordsHash  string         `json:"stopwordsHash"`  // analysis.Config.StopwordsDigest (inclui a lista fixa)
+	Files          []ManifestFile `json:"files"`
+
+	// Inferred indica que o manifesto foi calculado dos arquivos atuais de Dir para um índice
+	// anterior aos snapshots, e pode não corresponder ao corpus de fato indexado.
+	Inferred bool `json:"inferred,omitempty"`
+}
+
+// BuildManifest calcula o manifesto dos documentos de dir que seriam registrados por
+// RegisterDocs, processados com cfg.
+func BuildManifest(dir string, cfg analysis.Config) (*Manifest, error) {
+	stopwords, err := cfg.StopwordList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stopwords: %w", err)
+	}
+	stopwordsHash, err := cfg.StopwordsDigest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stopwords: %w", err)
+	}
+	ret := &Manifest{
+		CreatedAt:      time.Now().UTC(),
+		CleanerVersion: analysis.Version,
+		Analyzer:       cfg.Signature(),
+		Stopwords:      stopwords,
+		StopwordsHash:  stopwordsHash,
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		kind := models.ParseDocKind(strings.TrimPrefix(filepath.Ext(e.Name()), "."))
+		if e.IsDir() || kind == models.DocKindNone {
+			continue
+		}
+		f, err := fileChecksum(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		f.Name = e.Name()
+		ret.Files = append(ret.Files, f)
+	}
+	sort.Slice(ret.Files, func(i, j int) bool {
+		return ret.Files[i].Name < ret.Files[j].Name
+	})
+
+	ret.ID = ret.digest()
+	return ret, nil
+}
+
+func fileChecksum(path string) (ManifestFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// digest é o ID do manifesto: os 12 primeiros dígitos hexadecimais do SHA-256 do conteúdo.
+func (this *Manifest) digest() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "cleaner %s\nanalyzer %s\n", this.CleanerVersion, this.Analyzer)
+	for _, w := range this.Stopwords {
+		fmt.Fprintf(h, "stopword %s\n", w)
+	}
+	fmt.Fprintf(h, "stopwords %s\n", this.StopwordsHash)
+	for _, f := range this.Files {
+		fmt.Fprintf(h, "file %s %d %s\n", f.Name, f.Size, f.SHA256)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// WriteManifest grava o manifesto em dir/<ID>.json, se ainda não existir.
+func WriteManifest(dir string, m *Manifest) error {
+	path := filepath.Join(dir, m.ID+".json")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadManifest lê um manifesto gravado por WriteManifest.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ret Manifest
+	if err = json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &ret, nil
+}
+
+// SaveSnapshot registra o manifesto na tabela SNAPSHOT. Um snapshot com o mesmo ID já
+// registrado é mantido, com a data original.
+func SaveSnapshot(db *gorm.DB, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var n int64
+	if err = db.Model(&models.Snapshot{}).Where("id = ?", m.ID).Count(&n).Error; err != nil {
+		return fmt.Errorf("%w: failed to look up snapshot: %v", utils.ErrDatabase, err)
+	}
+	if n > 0 {
+		return nil
+	}
+	snap := models.NewSnapshot(m.ID, m.CleanerVersion, len(m.Files), string(data), m.CreatedAt)
+	if err = db.Create(snap).Error; err != nil {
+		return fmt.Errorf("%w: failed to save snapshot: %v", utils.ErrDatabase, err)
+	}
+	return nil
+}
+
+// LatestSnapshot retorna o manifesto registrado mais recentemente, ou nil se não houver.
+func LatestSnapshot(db *gorm.DB) (*Manifest, error) {
+	var snap models.Snapshot
+	res := db.Model(&models.Snapshot{}).Order("created_at desc").Limit(1).Find(&snap)
+	if res.Error != nil {
+		return nil, fmt.Errorf("%w: failed to load snapshot: %v", utils.ErrDatabase, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	var ret Manifest
+	if err := json.Unmarshal([]byte(snap.Manifest), &ret); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %v", snap.ID, err)
+	}
+	return &ret, nil
+}
+
+// loadSnapshot define Snapshot: com registered, o manifesto é calculado a partir de Dir (os
+// documentos acabaram de ser registrados); caso contrário é usado o último registrado no
+// banco. Um índice anterior aos snapshots recebe um manifesto calculado de Dir e marcado
+// como Inferred.
+func loadSnapshot(db *gorm.DB, cfg analysis.Config, registered bool) error {
+	var m *Manifest
+	var err error
+	if !registered {
+		if m, err = LatestSnapshot(db); err != nil {
+			return err
+		}
+	}
+	if m == nil {
+		if m, err = BuildManifest(Dir, cfg); err != nil {
+			return fmt.Errorf("failed to build corpus manifest: %w", err)
+		}
+		if !registered {
+			m.Inferred = true
+			slog.Warn("índice sem snapshot; manifesto inferido dos arquivos atuais do corpus", "id", m.ID, "dir", Dir)
+		}
+		if err = SaveSnapshot(db, m); err != nil {
+			return err
+		}
+	}
+	if err = WriteManifest(SnapshotsDir, m); err != nil {
+		return fmt.Errorf("failed to write corpus manifest: %w", err)
+	}
+	Snapshot = m
+	return nil
+}
@@ -0,0 +1,149 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuildManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"doc_0002_clean.txt": "altera o código penal",
+		"doc_0001_clean.txt": "dispõe sobre licitação",
+		"notas.md":           "não indexado",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "sub.txt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultAnalyzerConfig()
+
+	m, err := BuildManifest(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 2 || m.Files[0].Name != "doc_0001_clean.txt" || m.Files[1].Name != "doc_0002_clean.txt" {
+		t.Fatalf("expected the two documents in order, got %+v", m.Files)
+	}
+	if f := m.Files[0]; f.Size != int64(len("dispõe sobre licitação")) || len(f.SHA256) != 64 {
+		t.Errorf("unexpected file entry %+v", f)
+	}
+	if len(m.ID) != 12 || m.StopwordsHash == "" || m.Analyzer != cfg.Signature() || m.Inferred {
+		t.Errorf("unexpected manifest %+v", m)
+	}
+
+	// O ID depende só do conteúdo: CreatedAt não entra.
+	time.Sleep(time.Millisecond)
+	again, err := BuildManifest(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != m.ID || again.CreatedAt.Equal(m.CreatedAt) {
+		t.Errorf("expected same ID with a new date: %s %v / %s %v", m.ID, m.CreatedAt, again.ID, again.CreatedAt)
+	}
+
+	custom, err := BuildManifest(dir, cfg.WithStopwords(nil, []string{"lei"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.ID == m.ID || custom.StopwordsHash == m.StopwordsHash {
+		t.Error("custom stopwords should change the ID")
+	}
+
+	writeFiles(t, dir, map[string]string{"doc_0002_clean.txt": "altera o código civil"})
+	changed, err := BuildManifest(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.ID == m.ID {
+		t.Error("editing a document should change the ID")
+	}
+
+	// WriteManifest/ReadManifest preservam o manifesto.
+	out := t.TempDir()
+	if err = WriteManifest(out, m); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadManifest(filepath.Join(out, m.ID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.ID != m.ID || read.StopwordsHash != m.StopwordsHash || len(read.Files) != 2 || read.digest() != m.ID {
+		t.Errorf("manifest round trip: got %+v", read)
+	}
+}
+
+func TestSaveSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	if m, err := LatestSnapshot(db); err != nil || m != nil {
+		t.Fatalf("expected no snapshot, got %v %v", m, err)
+	}
+
+	old := &Manifest{ID: "aaaaaaaaaaaa", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CleanerVersion: "1"}
+	newer := &Manifest{ID: "bbbbbbbbbbbb", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), CleanerVersion: "1"}
+	for _, m := range []*Manifest{old, newer} {
+		if err := SaveSnapshot(db, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Um ID já registrado mantém a data original.
+	dup := *old
+	dup.CreatedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := SaveSnapshot(db, &dup); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LatestSnapshot(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.ID != newer.ID {
+		t.Errorf("expected the newest snapshot %s, got %+v", newer.ID, m)
+	}
+}
+
+func TestLoadSnapshotInferred(t *testing.T) {
+	setupCorpus(t, map[string]string{
+		"doc_0001_clean.txt": "dispõe sobre licitação pública",
+	})
+	db := openTestDB(t)
+
+	// Índice sem snapshot: o manifesto vem de Dir e é marcado como inferido
+	if err := loadSnapshot(db, DefaultAnalyzerConfig(), false); err != nil {
+		t.Fatal(err)
+	}
+	if Snapshot == nil || !Snapshot.Inferred || len(Snapshot.Files) != 1 {
+		t.Fatalf("expected an inferred manifest, got %+v", Snapshot)
+	}
+	if _, err := os.Stat(filepath.Join(SnapshotsDir, Snapshot.ID+".json")); err != nil {
+		t.Errorf("manifest not written: %v", err)
+	}
+
+	// Da próxima vez o snapshot registrado é usado.
+	id := Snapshot.ID
+	Snapshot = nil
+	if err := loadSnapshot(db, DefaultAnalyzerConfig(), false); err != nil {
+		t.Fatal(err)
+	}
+	if Snapshot == nil || Snapshot.ID != id {
+		t.Errorf("expected the saved snapshot %s, got %+v", id, Snapshot)
+	}
+
+	// Documentos recém-registrados geram um manifesto normal.
+	if err := loadSnapshot(db, DefaultAnalyzerConfig(), true); err != nil {
+		t.Fatal(err)
+	}
+	if Snapshot.Inferred || Snapshot.ID != id {
+		t.Errorf("expected a regular manifest with ID %s, got %+v", id, Snapshot)
+	}
+}
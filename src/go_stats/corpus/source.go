@@ -0,0 +1,104 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/models"
+)
+
+// Source é um portal legislativo de onde as proposições do corpus são coletadas. As
+// requisições passam pelo Fetcher recebido, que cuida do limite de taxa e das novas tentativas.
+type Source interface {
+	// Name é o nome da fonte ("camara", "senado"), gravado em DocumentMeta.Source.
+	Name() string
+	// Prefix é o prefixo dos arquivos da fonte no corpus (ver DocBaseName).
+	Prefix() string
+	// List retorna os IDs das proposições da página page (a partir de 1) que atendem a q;
+	// vazio indica o fim. A fonte aplica o que a sua API permitir (ao menos q.Keyword); os
+	// demais critérios são conferidos nos metadados (ver ScrapeQuery.Match).
+	List(ctx context.Context, f *Fetcher, q ScrapeQuery, page int) ([]string, error)
+	// Metadata obtém os metadados de uma proposição.
+	Metadata(ctx context.Context, f *Fetcher, id string) (*models.DocumentMeta, error)
+	// Document baixa o PDF do inteiro teor de uma proposição.
+	Document(ctx context.Context, f *Fetcher, id string) ([]byte, error)
+}
+
+// ScrapeQuery restringe as proposições coletadas. Campos vazios não filtram.
+type ScrapeQuery struct {
+	Keyword  string   // Palavra-chave (busca textual da fonte)
+	Types    []string // Siglas (PL, PEC, ...); vazio = tipos padrão da fonte
+	FromYear int      // Ano de apresentação mínimo
+	ToYear   int      // Ano de apresentação máximo
+	Theme    string   // Parte do nome de um tema
+	Author   string   // Parte do nome de um autor
+}
+
+// NeedsMeta informa se a consulta tem critérios que só podem ser conferidos nos metadados.
+func (q ScrapeQuery) NeedsMeta() bool {
+	return len(q.Types) > 0 || q.FromYear != 0 || q.ToYear != 0 || q.Theme != "" || q.Author != ""
+}
+
+// Match confere os critérios de q, exceto Keyword, nos metadados de uma proposição. Tema e
+// autor são comparados sem diferenciar maiúsculas e acentos.
+func (q ScrapeQuery) Match(meta *models.DocumentMeta) bool {
+	if len(q.Types) > 0 && !slices.ContainsFunc(q.Types, func(t string) bool { return strings.EqualFold(t, meta.Type) }) {
+		return false
+	}
+	if (q.FromYear != 0 && meta.Year < q.FromYear) || (q.ToYear != 0 && meta.Year > q.ToYear) {
+		return false
+	}
+	if q.Theme != "" && !anyContainsFolded(meta.Themes, q.Theme) {
+		return false
+	}
+	if q.Author != "" && !anyContainsFolded(meta.Authors, q.Author) {
+		return false
+	}
+	return true
+}
+
+// DefaultSource é a fonte usada quando nenhuma é informada.
+const DefaultSource = "camara"
+
+var sources = map[string]func() Source{}
+
+// RegisterSource registra uma fonte para uso em NewSource.
+func RegisterSource(name string, factory func() Source) {
+	sources[strings.ToLower(name)] = factory
+}
+
+func init() {
+	RegisterSource("camara", func() Source { return NewCamara() })
+	RegisterSource("senado", func() Source { return NewSenado() })
+}
+
+// NewSource cria a fonte registrada com o nome informado ("" = DefaultSource).
+func NewSource(name string) (Source, error) {
+	if name == "" {
+		name = DefaultSource
+	}
+	factory, ok := sources[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q (available: %s)", name, strings.Join(SourceNames(), ", "))
+	}
+	return factory(), nil
+}
+
+// SourceNames retorna os nomes das fontes registradas, em ordem alfabética.
+func SourceNames() []string {
+	ret := make([]string, 0, len(sources))
+	for name := range sources {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// DocBaseName é o nome (sem extensão) dos arquivos de uma proposição no corpus e a sua chave
+// no estado do crawl (ex.: cd_2196833 para a Câmara).
+func DocBaseName(src Source, proposalID string) string {
+	return src.Prefix() + "_" + proposalID
+}
@@ -0,0 +1,259 @@
+package corpus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tcc2-davi-arthur/models"
+)
+
+// fixtureServer responde com arquivos de testdata/<dir> gravados das APIs reais. routes liga
+// "MÉTODO caminho?query" ao arquivo; "SRV" no conteúdo é trocado pelo endereço do servidor.
+func fixtureServer(t *testing.T, dir string, routes map[string]string) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.RawQuery
+		}
+		file, ok := routes[key]
+		if !ok {
+			t.Errorf("unexpected request %s", key)
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(bytes.ReplaceAll(data, []byte("SRV"), []byte(srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCamaraSource(t *testing.T) {
+	page := 0
+	srv := fixtureServer(t, "camara", map[string]string{
+		"GET /proposicoes/2345678":                                   "proposicao.json",
+		"GET /proposicoes/2345678/autores":                           "autores.json",
+		"GET /proposicoes/2345678/temas":                             "temas.json",
+		"GET /proposicoesWeb/fichadetramitacao?idProposicao=2345678": "ficha.html",
+		"GET /proposicoesWeb/prop_mostrarintegra?codteor=2250001":    "teor.pdf",
+	})
+	search := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body camaraSearchBody
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil || r.Method != http.MethodPost {
+			t.Errorf("bad search request %s: %s", r.Method, data)
+		}
+		if body.TiposDeProposicao != "PL,PEC" || body.Q != "educação" {
+			t.Errorf("search: q = %q, types = %q", body.Q, body.TiposDeProposicao)
+		}
+		page = body.Pagina
+		file := "busca_2.json" // sem mais resultados
+		if page == 1 {
+			file = "busca_1.json"
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", "camara", file))
+	}))
+	defer search.Close()
+
+	src := &Camara{SearchURL: search.URL, PortalURL: srv.URL, DataURL: srv.URL + "/proposicoes", Types: []string{"PDL"}}
+	f := NewFetcher(0)
+	ctx := context.Background()
+	q := ScrapeQuery{Keyword: "educação", Types: []string{"pl", "pec"}}
+
+	ids, err := src.List(ctx, f, q, 1)
+	if err != nil || !reflect.DeepEqual(ids, []string{"2345678", "2345679"}) {
+		t.Fatalf("page 1: %v %v", ids, err)
+	}
+	if ids, err = src.List(ctx, f, q, 2); err != nil || len(ids) != 0 || page != 2 {
+		t.Fatalf("page 2: %v %v", ids, err)
+	}
+
+	meta, err := src.Metadata(ctx, f, "2345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted := time.Date(2023, 3, 14, 16, 20, 0, 0, time.UTC)
+	if meta.Source != "camara" || meta.Type != "PL" || meta.Number != 1234 || meta.Year != 2023 ||
+		meta.Ementa != "Altera a Lei nº 9.394, de 20 de dezembro de 1996." ||
+		!reflect.DeepEqual(meta.Authors, []string{"Maria da Silva", "João Souza"}) ||
+		!reflect.DeepEqual(meta.Themes, []string{"Educação"}) ||
+		meta.SubmittedAt == nil || !meta.SubmittedAt.Equal(submitted) ||
+		meta.SourceURL != srv.URL+"/proposicoesWeb/fichadetramitacao?idProposicao=2345678" {
+		t.Fatalf("bad metadata: %+v", meta)
+	}
+
+	data, err := src.Document(ctx, f, "2345678")
+	if err != nil || !bytes.HasPrefix(data, []byte("%PDF-1.4 teor 2250001")) {
+		t.Fatalf("document: %q %v", data, err)
+	}
+}
+
+func TestSenadoSource(t *testing.T) {
+	srv := fixtureServer(t, "senado", map[string]string{
+		"GET /materia/pesquisa/lista?ano=2023&sigla=PL":  "lista_pl_2023.json",
+		"GET /materia/pesquisa/lista?ano=2023&sigla=PEC": "lista_pec_2023.json",
+		"GET /materia/pesquisa/lista?ano=2022&sigla=PL":  "lista_vazia.json",
+		"GET /materia/pesquisa/lista?ano=2022&sigla=PEC": "lista_vazia.json",
+		"GET /materia/155001":                            "materia.json",
+		"GET /materia/textos/155001":                     "textos.json",
+		"GET /sdleg-getter/documento?dm=2":               "texto.pdf",
+	})
+
+	src := &Senado{DataURL: srv.URL, PortalURL: "https://portal/materia", Types: []string{"PL", "PEC"}, FromYear: 2000, ToYear: 2030}
+	f := NewFetcher(0)
+	ctx := context.Background()
+
+	// Os anos da consulta substituem os da fonte; a palavra-chave é procurada na ementa
+	q := ScrapeQuery{FromYear: 2022, ToYear: 2023}
+	want := [][]string{{"155001", "155002", "155100"}, nil, nil}
+	for i, w := range want {
+		ids, err := src.List(ctx, f, q, i+1)
+		if err != nil || !reflect.DeepEqual(ids, w) {
+			t.Fatalf("page %d: %v %v", i+1, ids, err)
+		}
+	}
+	q.Keyword = "leitura"
+	if ids, err := src.List(ctx, f, q, 1); err != nil || !reflect.DeepEqual(ids, []string{"155001", "155100"}) {
+		t.Fatalf("keyword: %v %v", ids, err)
+	}
+
+	meta, err := src.Metadata(ctx, f, "155001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Source != "senado" || meta.Type != "PL" || meta.Number != 10 || meta.Year != 2023 ||
+		!reflect.DeepEqual(meta.Authors, []string{"Senadora Ana Lima"}) ||
+		!reflect.DeepEqual(meta.Themes, []string{"Educação"}) ||
+		meta.Status != "AGUARDANDO DESIGNAÇÃO DO RELATOR" || meta.SubmittedAt == nil ||
+		meta.SourceURL != "https://portal/materia/155001" {
+		t.Fatalf("bad metadata: %+v", meta)
+	}
+
+	data, err := src.Document(ctx, f, "155001")
+	if err != nil || !bytes.HasPrefix(data, []byte("%PDF-1.4 materia 155001")) {
+		t.Fatalf("document: %q %v", data, err)
+	}
+}
+
+func TestSenadoLegacySiglas(t *testing.T) {
+	// Até 2018 os projetos de lei do Senado são "PLS"; depois, "PL" como na Câmara
+	srv := fixtureServer(t, "senado", map[string]string{
+		"GET /materia/pesquisa/lista?ano=2019&sigla=PL":  "lista_vazia.json",
+		"GET /materia/pesquisa/lista?ano=2018&sigla=PL":  "lista_vazia.json",
+		"GET /materia/pesquisa/lista?ano=2018&sigla=PLS": "lista_pls_2018.json",
+		"GET /materia/132001":                            "materia_pls.json",
+	})
+	src := &Senado{DataURL: srv.URL, PortalURL: "https://portal/materia", Types: []string{"PL"}, FromYear: 2018, ToYear: 2019}
+	f := NewFetcher(0)
+	ctx := context.Background()
+
+	// 2019 não tem resultados, então a primeira página já é a de 2018
+	want := [][]string{{"132001"}, nil}
+	for i, w := range want {
+		ids, err := src.List(ctx, f, ScrapeQuery{}, i+1)
+		if err != nil || !reflect.DeepEqual(ids, w) {
+			t.Fatalf("page %d: %v %v", i+1, ids, err)
+		}
+	}
+
+	meta, err := src.Metadata(ctx, f, "132001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Type != "PL" || meta.Number != 50 || meta.Year != 2018 || !(ScrapeQuery{Types: []string{"pl"}}).Match(meta) {
+		t.Fatalf("bad metadata: %+v", meta)
+	}
+}
+
+func TestSenadoSkipsEmptyYears(t *testing.T) {
+	srv := fixtureServer(t, "senado", map[string]string{
+		"GET /materia/pesquisa/lista?ano=2023&sigla=PL": "lista_pl_2023.json",
+		"GET /materia/pesquisa/lista?ano=2022&sigla=PL": "lista_vazia.json",
+		"GET /materia/pesquisa/lista?ano=2021&sigla=PL": "lista_pl_2021.json",
+		"GET /materia/pesquisa/lista?ano=2020&sigla=PL": "lista_vazia.json",
+	})
+	src := &Senado{DataURL: srv.URL, PortalURL: "https://portal/materia", Types: []string{"PL"}, FromYear: 2020, ToYear: 2023}
+	f := NewFetcher(0)
+	ctx := context.Background()
+
+	// Um ano vazio no meio não encerra a listagem; os anos filtrados pela palavra-chave também
+	// são pulados
+	for _, c := range []struct {
+		q    ScrapeQuery
+		want [][]string
+	}{
+		{ScrapeQuery{}, [][]string{{"155001", "155002"}, {"150001"}, nil}},
+		{ScrapeQuery{Keyword: "saneamento"}, [][]string{{"150001"}, nil}},
+	} {
+		for i, w := range c.want {
+			ids, err := src.List(ctx, f, c.q, i+1)
+			if err != nil || !reflect.DeepEqual(ids, w) {
+				t.Fatalf("%+v page %d: %v %v", c.q, i+1, ids, err)
+			}
+		}
+	}
+}
+
+func TestScrapeQueryMatch(t *testing.T) {
+	meta := &models.DocumentMeta{Type: "PL", Year: 2021, Authors: []string{"João Souza"}, Themes: []string{"Educação", "Cultura"}}
+	tests := []struct {
+		q    ScrapeQuery
+		want bool
+	}{
+		{ScrapeQuery{}, true},
+		{ScrapeQuery{Types: []string{"pec", "pl"}}, true},
+		{ScrapeQuery{Types: []string{"PEC"}}, false},
+		{ScrapeQuery{FromYear: 2019, ToYear: 2021}, true},
+		{ScrapeQuery{FromYear: 2022}, false},
+		{ScrapeQuery{Author: "joao"}, true},
+		{ScrapeQuery{Author: "maria"}, false},
+		{ScrapeQuery{Theme: "educacao"}, true},
+		{ScrapeQuery{Theme: "saude"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Match(meta); got != tt.want {
+			t.Errorf("%+v: got %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	// A palavra-chave é aplicada pela busca da fonte e não exige metadados
+	if (ScrapeQuery{Keyword: "educação"}).NeedsMeta() || !(ScrapeQuery{ToYear: 2020}).NeedsMeta() || !(ScrapeQuery{Theme: "saude"}).NeedsMeta() {
+		t.Error("NeedsMeta mismatch")
+	}
+}
+
+func TestCamaraOptionalThemes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/proposicoes/2345678":
+			http.ServeFile(w, r, filepath.Join("testdata", "camara", "proposicao.json"))
+		case "/proposicoes/2345678/autores":
+			http.ServeFile(w, r, filepath.Join("testdata", "camara", "autores.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	src := &Camara{DataURL: srv.URL + "/proposicoes", PortalURL: srv.URL}
+	meta, err := src.Metadata(context.Background(), NewFetcher(0), "2345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Type != "PL" || len(meta.Authors) != 2 || meta.Themes != nil {
+		t.Errorf("bad metadata without themes: %+v", meta)
+	}
+}
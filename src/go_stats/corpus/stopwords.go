@@ -0,0 +1,51 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcc2-davi-arthur/analysis"
+	"github.com/tcc2-davi-arthur/utils"
+)
+
+// LegislativeStopwordsFile é a lista de termos recorrentes do processo legislativo.
+const LegislativeStopwordsFile = "./../../misc/stopwords_legislativo.txt"
+
+// DeriveStopwords analisa todos os documentos de Dir com cfg e retorna os tokens presentes
+// em pelo menos a fração threshold dos documentos (ex.: 0.8 = 80%), candidatos a stopwords
+// do corpus.
+func DeriveStopwords(ctx context.Context, cfg analysis.Config, threshold float64) ([]string, error) {
+	a, err := analysis.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	df := analysis.NewDocFreq()
+	progress := utils.NewProgress("stopwords do corpus", len(files))
+	for _, f := range files {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		progress.Add(1)
+
+		if f.IsDir() || strings.TrimPrefix(filepath.Ext(f.Name()), ".") != "txt" {
+			continue
+		}
+		content, err := os.ReadFile(fmt.Sprintf("%s/%s", Dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		df.Add(a.Analyze(string(content)))
+	}
+	progress.Finish()
+
+	return df.Stopwords(threshold), nil
+}
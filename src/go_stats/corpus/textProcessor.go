@@ -1,14 +1,17 @@
 package corpus
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 
+	"github.com/tcc2-davi-arthur/extract"
+	"github.com/tcc2-davi-arthur/models"
 	"github.com/tcc2-davi-arthur/utils"
 )
 
@@ -18,75 +21,154 @@ const (
 	dir     = "./misc/corpus/pdf"
 )
 
-func TextProcessor(maxWorkers int) {
+// TextProcessor converte todos os documentos de dir (PDF, DOCX, ODT e HTML) em texto bruto
+// (dirTxt), texto limpo (dirClen) e no mapa de offsets que liga os dois (dirOffsets, ver
+// BuildOffsets). Os PDFs são extraídos com backend (nil = extract.DefaultBackend) e os
+// demais com o extrator do seu tipo (ver extract.ForExtension). As páginas ficam separadas por '\f' no texto bruto.
+// Retorna erro se o backend não estiver disponível; falhas em arquivos individuais são
+// registradas no log e não interrompem o processamento.
+func TextProcessor(ctx context.Context, maxWorkers int, backend extract.Backend) error {
+
+	if backend == nil {
+		b, err := extract.New("")
+		if err != nil {
+			return err
+		}
+		backend = b
+	}
+	if err := backend.Available(); err != nil {
+		return err
+	}
+	slog.Info("extração de texto", "backend", backend.Name())
 
 	err := errors.Join(
 		os.MkdirAll(dir, 0755),
 		os.MkdirAll(dirTxt, 0755),
 		os.MkdirAll(dirClen, 0755),
+		os.MkdirAll(dirOffsets, 0755),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	files, err := os.ReadDir(dir)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	var wgCleaner sync.WaitGroup
-	workerLimit := make(chan struct{}, maxWorkers)
-
+	// Apenas os arquivos com extrator entram no total do progresso
+	type job struct {
+		name    string
+		backend extract.Backend
+	}
+	var jobs []job
 	for _, f := range files {
-		if f.IsDir() || filepath.Ext(f.Name()) != ".pdf" {
+		if f.IsDir() {
 			continue
 		}
+		if b, err := extract.ForExtension(f.Name(), backend); err == nil {
+			jobs = append(jobs, job{f.Name(), b})
+		}
+	}
 
+	var wgCleaner sync.WaitGroup
+	workerLimit := make(chan struct{}, maxWorkers)
+	progress := utils.NewProgress("extração de texto", len(jobs))
+
+	for _, j := range jobs {
 		wgCleaner.Add(1)
 		workerLimit <- struct{}{}
 
-		go func(name string) {
+		go func(j job) {
 			defer func() {
+				progress.Add(1)
 				<-workerLimit
 			}()
-			processPDF(filepath.Join(dir, name), &wgCleaner)
-		}(f.Name())
+			processDoc(ctx, j.backend, filepath.Join(dir, j.name), &wgCleaner)
+		}(j)
 	}
 
 	wgCleaner.Wait()
+	progress.Finish()
+	return nil
 }
 
-func processPDF(path string, wg *sync.WaitGroup) {
+func processDoc(ctx context.Context, backend extract.Backend, path string, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	// Extrai texto
-	out, err := exec.Command("pdftotext", path, "-").Output()
+	// Extrai texto, página a página
+	pages, err := backend.Extract(ctx, path)
 	if err != nil {
-		log.Printf("Erro pdftotext %s: %v\n", path, err)
+		slog.Error("erro extraindo texto", "file", path, "backend", backend.Name(), "err", err)
 		return
 	}
+	out := []byte(extract.JoinPages(pages))
 
 	// Salva texto convertido
 	filename := filepath.Base(path)[:len(filepath.Base(path))-len(filepath.Ext(path))]
 	txtPath := fmt.Sprintf("./misc/corpus/txt/%s.txt", filename)
 	if err := os.WriteFile(txtPath, out, 0744); err != nil {
-		log.Printf("Erro salvando %s: %v\n", txtPath, err)
+		slog.Error("erro salvando texto", "file", txtPath, "err", err)
 		return
 	}
 
-	// Limpa o texto
-	clean, err := utils.CleanText(string(out))
+	// Documentos com pouco texto aproveitável (ex.: digitalizados sem OCR) continuam no corpus,
+	// mas são sinalizados para que sejam extraídos de novo com OCR
+	if extract.LowText(string(out), extract.OCROptions{}) {
+		slog.Warn("documento com pouco texto; use um backend com OCR", "file", path, "backend", backend.Name())
+	}
+
+	// Limpa o texto com o pipeline padrão (os tokens são os de utils.CleanText), guardando o
+	// trecho original de cada token
+	spans, err := utils.CleanTextSpans(string(out))
 	if err != nil {
-		log.Printf("Erro limpando %s: %v\n", path, err)
+		slog.Error("erro limpando texto", "file", path, "err", err)
 		return
 	}
+	tokens := make([]string, len(spans))
+	for i, s := range spans {
+		tokens[i] = s.Token
+	}
 
 	// Salva texto limpo
 	cleanPath := fmt.Sprintf("./misc/corpus/clean/%s_clean.txt", filename)
-	if err := os.WriteFile(cleanPath, []byte(clean), 0744); err != nil {
-		log.Printf("Erro salvando %s: %v\n", cleanPath, err)
+	if err := os.WriteFile(cleanPath, []byte(strings.Join(tokens, " ")), 0744); err != nil {
+		slog.Error("erro salvando texto limpo", "file", cleanPath, "err", err)
+		return
+	}
+
+	// Salva o mapa token limpo -> trecho original e página
+	offsetsPath := fmt.Sprintf("%s/%s.off", dirOffsets, filename)
+	if err := os.WriteFile(offsetsPath, models.EncodeOffsets(BuildOffsets(string(out), spans)), 0744); err != nil {
+		slog.Error("erro salvando offsets", "file", offsetsPath, "err", err)
 		return
 	}
 
-	log.Printf("Processado: %s\n", path)
+	slog.Debug("processado", "file", path)
+}
+
+// ReadDocText retorna o texto de um documento conforme o seu tipo: arquivos txt são lidos
+// diretamente e os demais passam pelo extrator do tipo (extract.ForExtension), com as
+// páginas separadas por '\f'.
+func ReadDocText(ctx context.Context, path string, kind models.DocKind) (string, error) {
+	switch kind {
+	case models.DocKindText:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case models.DocKindNone:
+		return "", fmt.Errorf("unsupported document kind: %s", filepath.Base(path))
+	}
+
+	b, err := extract.ForExtension(path, nil)
+	if err != nil {
+		return "", err
+	}
+	pages, err := b.Extract(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", filepath.Base(path), err)
+	}
+	return extract.JoinPages(pages), nil
 }
@@ -0,0 +1,244 @@
+package extract
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// font decodifica as strings de um content stream para texto Unicode.
+type font struct {
+	codeLen   int                // Bytes por código (1 em fontes simples, 2 em Type0)
+	toUnicode map[uint32]string  // CMap ToUnicode, se houver
+	encoding  *[256]rune         // Codificação de fontes simples sem ToUnicode
+	widths    map[uint32]float64 // Avanço de cada código, em milésimos de em
+	defWidth  float64            // Avanço dos códigos fora de widths (0 = estimado)
+}
+
+// defaultAdvance é o avanço estimado (em em) de um caractere quando a fonte não informa
+// as larguras.
+const defaultAdvance = 0.5
+
+// decode converte os bytes de uma string e retorna também o seu avanço horizontal (em em).
+// Sem fonte conhecida, os bytes são lidos como WinAnsi.
+func (f *font) decode(obj any) (string, float64) {
+	b, ok := obj.(pdfStr)
+	if !ok {
+		return "", 0
+	}
+	if f == nil {
+		f = &font{codeLen: 1}
+	}
+	codeLen := max(1, f.codeLen)
+	enc := f.encoding
+	if enc == nil {
+		enc = &winAnsi
+	}
+
+	var sb strings.Builder
+	advance := 0.0
+	for i := 0; i+codeLen <= len(b); i += codeLen {
+		code := uint32(0)
+		for _, c := range b[i : i+codeLen] {
+			code = code<<8 | uint32(c)
+		}
+		if w, ok := f.widths[code]; ok {
+			advance += w / 1000
+		} else if f.defWidth > 0 {
+			advance += f.defWidth / 1000
+		} else {
+			advance += defaultAdvance
+		}
+		if s, ok := f.toUnicode[code]; ok {
+			sb.WriteString(s)
+		} else if codeLen == 1 {
+			if r := enc[code]; r != 0 {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String(), advance
+}
+
+// parseToUnicode lê os mapeamentos bfchar e bfrange de um CMap ToUnicode. O segundo retorno é
+// o tamanho dos códigos declarado em codespacerange (0 se ausente).
+func parseToUnicode(data []byte) (map[uint32]string, int) {
+	ret := make(map[uint32]string)
+	codeLen := 0
+	l := &lexer{data: data}
+	var operands []any
+
+	for {
+		obj := l.next()
+		if obj == nil {
+			return ret, codeLen
+		}
+		op, ok := obj.(pdfOp)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if s, ok := operands[0].(pdfStr); ok {
+					codeLen = len(s)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfStr)
+				dst, ok2 := operands[i+1].(pdfStr)
+				if ok1 && ok2 {
+					ret[codeOf(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfStr)
+				hi, ok2 := operands[i+1].(pdfStr)
+				if !ok1 || !ok2 {
+					continue
+				}
+				from, to := codeOf(lo), codeOf(hi)
+				if to < from || to-from > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfStr:
+					// O último código UTF-16 é incrementado ao longo do intervalo.
+					base := utf16.Decode(toUint16(dst))
+					for c := from; c <= to; c++ {
+						if len(base) == 0 {
+							break
+						}
+						r := append([]rune(nil), base...)
+						r[len(r)-1] += rune(c - from)
+						ret[c] = string(r)
+					}
+				case pdfArray:
+					for j, d := range dst {
+						if s, ok := d.(pdfStr); ok && from+uint32(j) <= to {
+							ret[from+uint32(j)] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func codeOf(b []byte) uint32 {
+	ret := uint32(0)
+	for _, c := range b {
+		ret = ret<<8 | uint32(c)
+	}
+	return ret
+}
+
+func toUint16(b []byte) []uint16 {
+	ret := make([]uint16, len(b)/2)
+	for i := range ret {
+		ret[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return ret
+}
+
+func utf16BE(b []byte) string {
+	if len(b) == 1 {
+		return string(rune(b[0]))
+	}
+	return string(utf16.Decode(toUint16(b)))
+}
+
+// withDifferences aplica um array /Differences (código, /nome, /nome, ...) a uma codificação.
+func withDifferences(base *[256]rune, diffs []any) *[256]rune {
+	ret := *base
+	code := 0
+	for _, d := range diffs {
+		switch v := d.(type) {
+		case float64:
+			code = int(v)
+		case pdfName:
+			if code >= 0 && code < 256 {
+				if r, ok := glyphRune(string(v)); ok {
+					ret[code] = r
+				}
+			}
+			code++
+		}
+	}
+	return &ret
+}
+
+// glyphRune converte nomes de glifos (Adobe Glyph List) usados em português, além de
+// "uniXXXX" e dos caracteres ASCII de nome igual ao próprio caractere.
+func glyphRune(name string) (rune, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	if hex, ok := strings.CutPrefix(name, "uni"); ok && len(hex) >= 4 {
+		if v, err := strconv.ParseUint(hex[:4], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	return 0, false
+}
+
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$', "percent": '%',
+	"ampersand": '&', "quotesingle": '\'', "parenleft": '(', "parenright": ')', "asterisk": '*',
+	"plus": '+', "comma": ',', "hyphen": '-', "period": '.', "slash": '/', "zero": '0', "one": '1',
+	"two": '2', "three": '3', "four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8',
+	"nine": '9', "colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\', "bracketright": ']',
+	"underscore": '_', "quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”',
+	"endash": '–', "emdash": '—', "bullet": '•', "ellipsis": '…', "section": '§', "paragraph": '¶',
+	"degree": '°', "ordfeminine": 'ª', "ordmasculine": 'º', "guillemotleft": '«', "guillemotright": '»',
+	"aacute": 'á', "agrave": 'à', "acircumflex": 'â', "atilde": 'ã', "adieresis": 'ä',
+	"eacute": 'é', "egrave": 'è', "ecircumflex": 'ê', "edieresis": 'ë',
+	"iacute": 'í', "igrave": 'ì', "icircumflex": 'î', "idieresis": 'ï',
+	"oacute": 'ó', "ograve": 'ò', "ocircumflex": 'ô', "otilde": 'õ', "odieresis": 'ö',
+	"uacute": 'ú', "ugrave": 'ù', "ucircumflex": 'û', "udieresis": 'ü', "ccedilla": 'ç', "ntilde": 'ñ',
+	"Aacute": 'Á', "Agrave": 'À', "Acircumflex": 'Â', "Atilde": 'Ã', "Adieresis": 'Ä',
+	"Eacute": 'É', "Egrave": 'È', "Ecircumflex": 'Ê', "Edieresis": 'Ë',
+	"Iacute": 'Í', "Igrave": 'Ì', "Icircumflex": 'Î', "Idieresis": 'Ï',
+	"Oacute": 'Ó', "Ograve": 'Ò', "Ocircumflex": 'Ô', "Otilde": 'Õ', "Odieresis": 'Ö',
+	"Uacute": 'Ú', "Ugrave": 'Ù', "Ucircumflex": 'Û', "Udieresis": 'Ü', "Ccedilla": 'Ç', "Ntilde": 'Ñ',
+	"fi": 'ﬁ', "fl": 'ﬂ', "nbspace": ' ', "Euro": '€',
+}
+
+// winAnsi é a WinAnsiEncoding: Latin-1 com os caracteres tipográficos em 0x80-0x9F.
+var winAnsi = func() [256]rune {
+	var ret [256]rune
+	for i := 0x20; i < 0x7F; i++ {
+		ret[i] = rune(i)
+	}
+	for i := 0xA0; i <= 0xFF; i++ {
+		ret[i] = rune(i)
+	}
+	ret['\t'], ret['\n'], ret['\r'] = '\t', '\n', '\r'
+	high := []rune("€\u0000‚ƒ„…†‡ˆ‰Š‹Œ\u0000Ž\u0000\u0000‘’“”•–—˜™š›œ\u0000žŸ")
+	for i, r := range high {
+		ret[0x80+i] = r
+	}
+	return ret
+}()
+
+// macRoman é a MacRomanEncoding.
+var macRoman = func() [256]rune {
+	var ret [256]rune
+	for i := 0x20; i < 0x7F; i++ {
+		ret[i] = rune(i)
+	}
+	high := []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range high {
+		ret[0x80+i] = r
+	}
+	return ret
+}()
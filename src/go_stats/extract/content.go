@@ -0,0 +1,368 @@
+package extract
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tipos dos objetos lidos de um content stream.
+type (
+	pdfName  string
+	pdfOp    string
+	pdfStr   []byte
+	pdfArray []any
+	pdfDict  map[string]any
+)
+
+// lexer lê os objetos e operadores de um content stream (ou de um CMap, que usa a mesma
+// sintaxe).
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isWhite(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else if !isWhite(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// next retorna o próximo objeto, ou nil no fim dos dados.
+func (l *lexer) next() any {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(l.word())
+	case c == '(':
+		return l.literal()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		ret := pdfDict{}
+		for {
+			l.skipSpace()
+			if l.pos+1 >= len(l.data) || (l.data[l.pos] == '>' && l.data[l.pos+1] == '>') {
+				l.pos += 2
+				return ret
+			}
+			key, ok := l.next().(pdfName)
+			if !ok {
+				continue
+			}
+			ret[string(key)] = l.next()
+		}
+	case c == '<':
+		return l.hex()
+	case c == '[':
+		l.pos++
+		var ret pdfArray
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) || l.data[l.pos] == ']' {
+				l.pos++
+				return ret
+			}
+			ret = append(ret, l.next())
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfOp(string(c))
+	}
+
+	w := l.word()
+	if n, err := strconv.ParseFloat(w, 64); err == nil {
+		return n
+	}
+	if w == "BI" {
+		l.skipInlineImage()
+	}
+	return pdfOp(w)
+}
+
+func (l *lexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isWhite(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++ // delimitador isolado
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literal lê uma string entre parênteses, com parênteses aninhados e escapes.
+func (l *lexer) literal() pdfStr {
+	l.pos++
+	var ret []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return ret
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return ret
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				if e == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func (l *lexer) hex() pdfStr {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isWhite(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	ret := make([]byte, len(digits)/2)
+	for i := range ret {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		ret[i] = byte(v)
+	}
+	return ret
+}
+
+// skipInlineImage pula os dados binários de uma imagem inline (BI ... ID dados EI).
+func (l *lexer) skipInlineImage() {
+	if i := bytes.Index(l.data[l.pos:], []byte("ID")); i >= 0 {
+		l.pos += i + 2
+	}
+	for l.pos+2 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' && isWhite(l.data[l.pos-1]) &&
+			(l.pos+2 == len(l.data) || isWhite(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+// resources resolve as fontes e os form XObjects referenciados em um content stream.
+type resources interface {
+	font(name string) *font
+	form(name string) (content []byte, res resources, ok bool)
+}
+
+// maxFormDepth limita a recursão em form XObjects que se referenciam.
+const maxFormDepth = 8
+
+// textWriter acumula o texto de uma página, inserindo quebras de linha e espaços a partir
+// do posicionamento do texto e das larguras dos glifos, o que distingue letras posicionadas
+// uma a uma de palavras separadas.
+type textWriter struct {
+	buf []byte
+
+	size  float64 // Tamanho da fonte (Tf)
+	scale float64 // Escala horizontal da matriz de texto (Tm)
+	lineX float64 // Início da linha atual
+	penX  float64 // Fim estimado do último texto desenhado
+	y     float64
+	hasY  bool
+}
+
+func (w *textWriter) last() byte {
+	if len(w.buf) == 0 {
+		return '\n'
+	}
+	return w.buf[len(w.buf)-1]
+}
+
+func (w *textWriter) newline() {
+	w.buf = bytes.TrimRight(w.buf, " ")
+	if len(w.buf) > 0 && w.last() != '\n' {
+		w.buf = append(w.buf, '\n')
+	}
+}
+
+func (w *textWriter) space() {
+	if c := w.last(); c != ' ' && c != '\n' {
+		w.buf = append(w.buf, ' ')
+	}
+}
+
+func (w *textWriter) em() float64 {
+	return max(w.size, 1) * w.scale
+}
+
+// moveTo posiciona o início da linha em (x, y): muda de linha se y mudou, ou insere um espaço
+// se x estiver adiante do fim do último texto.
+func (w *textWriter) moveTo(x, y float64) {
+	if w.hasY && math.Abs(y-w.y) > 1 {
+		w.newline()
+	} else if x-w.penX > 0.15*w.em() {
+		w.space()
+	}
+	w.lineX, w.penX, w.y, w.hasY = x, x, y, true
+}
+
+func (w *textWriter) write(text string, advance float64) {
+	w.buf = append(w.buf, text...)
+	w.penX += advance * w.em()
+}
+
+// adjust aplica um deslocamento de TJ (em milésimos de em); deslocamentos grandes separam
+// palavras.
+func (w *textWriter) adjust(v float64) {
+	w.penX -= v / 1000 * w.em()
+	if v < -200 {
+		w.space()
+	}
+}
+
+// pageText interpreta os operadores de texto do content stream e retorna o texto na ordem
+// em que é desenhado.
+func pageText(content []byte, res resources) string {
+	w := &textWriter{scale: 1}
+	runContent(w, content, res, 0)
+	return strings.TrimSpace(string(w.buf))
+}
+
+func runContent(w *textWriter, content []byte, res resources, depth int) {
+	l := &lexer{data: content}
+	var operands []any
+	var cur *font
+	num := func(i int) float64 {
+		v, _ := operands[len(operands)-i].(float64)
+		return v
+	}
+
+	for {
+		obj := l.next()
+		if obj == nil {
+			return
+		}
+		op, ok := obj.(pdfOp)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			w.scale = 1
+			w.moveTo(0, 0)
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					cur = res.font(string(name))
+				}
+				w.size = math.Abs(num(1))
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				w.moveTo(w.lineX+num(2)*w.scale, w.y+num(1)*w.scale)
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				if a := math.Abs(num(6)); a > 0 {
+					w.scale = a
+				}
+				w.moveTo(num(2), num(1))
+			}
+		case "T*":
+			w.newline()
+			w.penX = w.lineX
+		case "Tj":
+			if len(operands) >= 1 {
+				w.write(cur.decode(operands[len(operands)-1]))
+			}
+		case "'", "\"":
+			w.newline()
+			w.penX = w.lineX
+			if len(operands) >= 1 {
+				w.write(cur.decode(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				arr, _ := operands[len(operands)-1].(pdfArray)
+				for _, e := range arr {
+					switch v := e.(type) {
+					case pdfStr:
+						w.write(cur.decode(v))
+					case float64:
+						w.adjust(v)
+					}
+				}
+			}
+		case "ET":
+			w.space()
+		case "Do":
+			if len(operands) >= 1 && depth < maxFormDepth {
+				if name, ok := operands[len(operands)-1].(pdfName); ok {
+					if content, formRes, ok := res.form(string(name)); ok {
+						runContent(w, content, formRes, depth+1)
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
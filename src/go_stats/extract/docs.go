@@ -0,0 +1,291 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ForExtension retorna o backend adequado à extensão de path: pdf usa pdf (nil =
+// DefaultBackend); docx, odt e html/htm usam os extratores de documentos de texto.
+func ForExtension(path string, pdf Backend) (Backend, error) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")) {
+	case "pdf":
+		if pdf != nil {
+			return pdf, nil
+		}
+		return New("")
+	case "docx":
+		return &Docx{}, nil
+	case "odt":
+		return &Odt{}, nil
+	case "html", "htm":
+		return &Html{}, nil
+	default:
+		return nil, fmt.Errorf("no extraction backend for %s", filepath.Base(path))
+	}
+}
+
+// ---- DOCX ----
+
+const wordNS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// Docx extrai o texto de word/document.xml. As páginas seguem as quebras explícitas e as
+// registradas pelo editor na última renderização (w:lastRenderedPageBreak), quando houver.
+type Docx struct{}
+
+func (this *Docx) Name() string     { return "docx" }
+func (this *Docx) Available() error { return nil }
+
+func (this *Docx) Extract(ctx context.Context, path string) ([]Page, error) {
+	data, err := readZipEntry(path, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	w := &pageWriter{}
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid document.xml in %s: %w", path, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				w.write("\t")
+			case "cr":
+				w.write("\n")
+			case "br":
+				if attr(t, "type") == "page" {
+					w.pageBreak()
+				} else {
+					w.write("\n")
+				}
+			case "lastRenderedPageBreak":
+				w.pageBreak()
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				w.write("\n")
+			}
+		case xml.CharData:
+			if inText {
+				w.write(string(t))
+			}
+		}
+	}
+	return w.pages(), nil
+}
+
+// ---- ODT ----
+
+const odtTextNS = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+
+// Odt extrai o texto de content.xml, separando as páginas nas quebras registradas pelo editor
+// (text:soft-page-break).
+type Odt struct{}
+
+func (this *Odt) Name() string     { return "odt" }
+func (this *Odt) Available() error { return nil }
+
+func (this *Odt) Extract(ctx context.Context, path string) ([]Page, error) {
+	data, err := readZipEntry(path, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	w := &pageWriter{}
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	depth := 0 // Profundidade dentro de parágrafos e títulos
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid content.xml in %s: %w", path, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odtTextNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "p", "h":
+				depth++
+			case "s":
+				n, err := strconv.Atoi(attr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				w.write(strings.Repeat(" ", n))
+			case "tab":
+				w.write("\t")
+			case "line-break":
+				w.write("\n")
+			case "soft-page-break":
+				w.pageBreak()
+			}
+		case xml.EndElement:
+			if t.Name.Space == odtTextNS && (t.Name.Local == "p" || t.Name.Local == "h") {
+				depth--
+				w.write("\n")
+			}
+		case xml.CharData:
+			if depth > 0 {
+				w.write(string(t))
+			}
+		}
+	}
+	return w.pages(), nil
+}
+
+// ---- HTML ----
+
+// Html extrai o texto visível do corpo da página (sem scripts e estilos), com uma linha por
+// bloco. O documento inteiro é uma página.
+type Html struct{}
+
+func (this *Html) Name() string     { return "html" }
+func (this *Html) Available() error { return nil }
+
+func (this *Html) Extract(ctx context.Context, path string) ([]Page, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTML in %s: %w", path, err)
+	}
+	doc.Find("script, style, noscript, template").Remove()
+
+	w := &pageWriter{}
+	htmlText(doc.Find("body"), w)
+	return w.pages(), nil
+}
+
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "li": true, "ul": true, "ol": true, "tr": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "section": true,
+	"article": true, "blockquote": true, "pre": true, "header": true, "footer": true, "dd": true, "dt": true,
+}
+
+func htmlText(sel *goquery.Selection, w *pageWriter) {
+	sel.Contents().Each(func(_ int, s *goquery.Selection) {
+		switch name := goquery.NodeName(s); {
+		case name == "#text":
+			if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+				w.write(text + " ")
+			}
+		case name == "br":
+			w.write("\n")
+		case htmlBlocks[name]:
+			w.write("\n")
+			htmlText(s, w)
+			w.write("\n")
+		case name == "td" || name == "th":
+			htmlText(s, w)
+			w.write("\t")
+		default:
+			htmlText(s, w)
+		}
+	})
+}
+
+// ---- Auxiliares ----
+
+// pageWriter acumula o texto separado em páginas, sem linhas em branco repetidas nem espaços
+// no fim das linhas.
+type pageWriter struct {
+	done []string
+	cur  []byte
+}
+
+func (w *pageWriter) write(s string) {
+	if s == "\n" {
+		w.cur = bytes.TrimRight(w.cur, " ")
+		if len(w.cur) == 0 || w.cur[len(w.cur)-1] == '\n' {
+			return
+		}
+	}
+	w.cur = append(w.cur, s...)
+}
+
+func (w *pageWriter) pageBreak() {
+	if len(bytes.TrimSpace(w.cur)) == 0 {
+		return
+	}
+	w.done = append(w.done, string(w.cur))
+	w.cur = w.cur[:0]
+}
+
+func (w *pageWriter) pages() []Page {
+	w.pageBreak()
+	ret := make([]Page, len(w.done))
+	for i, text := range w.done {
+		ret[i] = Page{Number: i + 1, Text: strings.TrimSpace(text)}
+	}
+	return ret
+}
+
+func attr(t xml.StartElement, local string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func readZipEntry(path, name string) ([]byte, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s not found in %s", name, filepath.Base(path))
+}
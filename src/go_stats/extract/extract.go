@@ -0,0 +1,71 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnavailable indica que o backend não pode ser usado nesta máquina (ex.: binário ausente).
+var ErrUnavailable = errors.New("extraction backend unavailable")
+
+// Page é o texto de uma página do documento (Number a partir de 1).
+type Page struct {
+	Number int
+	Text   string
+}
+
+// Backend extrai o texto de um PDF página a página.
+type Backend interface {
+	// Name identifica o backend (ex.: "pdfcpu", "pdftotext").
+	Name() string
+	// Available retorna ErrUnavailable (embrulhado) se o backend não puder ser usado.
+	Available() error
+	// Extract retorna o texto de cada página de path, na ordem.
+	Extract(ctx context.Context, path string) ([]Page, error)
+}
+
+// DefaultBackend é o backend usado quando nenhum é informado; não depende de binários externos.
+const DefaultBackend = "pdfcpu"
+
+var backends = map[string]func() Backend{}
+
+// Register registra um backend para uso em New.
+func Register(name string, factory func() Backend) {
+	backends[name] = factory
+}
+
+// New constrói o backend registrado com o nome dado ("" = DefaultBackend).
+func New(name string) (Backend, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+	factory, ok := backends[name]
+	if !ok {
+		names := make([]string, 0, len(backends))
+		for k := range backends {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown extraction backend %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return factory(), nil
+}
+
+func init() {
+	Register("pdfcpu", func() Backend { return NewPdfcpu() })
+	Register("pdftotext", func() Backend { return NewPdftotext("") })
+	Register("pdfcpu+tesseract", func() Backend { return WithOCR(NewPdfcpu(), NewTesseract("", ""), OCROptions{}) })
+	Register("pdftotext+tesseract", func() Backend { return WithOCR(NewPdftotext(""), NewTesseract("", ""), OCROptions{}) })
+}
+
+// JoinPages junta as páginas separando-as com form feed ('\f'), como faz o pdftotext.
+func JoinPages(pages []Page) string {
+	parts := make([]string, len(pages))
+	for i, p := range pages {
+		parts[i] = p.Text
+	}
+	return strings.Join(parts, "\f")
+}
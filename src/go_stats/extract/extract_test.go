@@ -0,0 +1,200 @@
+package extract
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeResources serve fontes e form XObjects fixos para pageText.
+type fakeResources struct {
+	fonts map[string]*font
+	forms map[string][]byte
+}
+
+func (this *fakeResources) font(name string) *font {
+	return this.fonts[name]
+}
+
+func (this *fakeResources) form(name string) ([]byte, resources, bool) {
+	content, ok := this.forms[name]
+	return content, this, ok
+}
+
+func TestPageText(t *testing.T) {
+	cmap, codeLen := parseToUnicode([]byte(`
+/CIDInit /ProcSet findresource begin
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar <0001> <0050> <0002> <00E7> endbfchar
+1 beginbfrange <0010> <0014> <0061> endbfrange
+1 beginbfrange <0020> <0021> [<00E3> <006F>] endbfrange
+endcmap`))
+	if codeLen != 2 || cmap[0x0002] != "ç" || cmap[0x0012] != "c" || cmap[0x0021] != "o" {
+		t.Fatalf("bad cmap (%d): %v", codeLen, cmap)
+	}
+
+	res := &fakeResources{
+		fonts: map[string]*font{
+			"F1": {codeLen: 1, encoding: withDifferences(&winAnsi, []any{float64(200), pdfName("atilde"), pdfName("ccedilla")})},
+			"F2": {codeLen: codeLen, toUnicode: cmap, defWidth: 500},
+		},
+		forms: map[string][]byte{"Fm1": []byte(`BT /F1 10 Tf 0 -20 Td (rodap\351) Tj ET`)},
+	}
+	content := []byte(`
+BT /F1 12 Tf 72 700 Td (Proje) Tj (to de lei) Tj 0 -14 Td [(Disp)-20(\365e sobre a)-500(licita\311\310o)] TJ ET
+BT /F2 12 Tf 1 0 0 1 72 650 Tm <0001 0010 0012 0013 0014> Tj 40 0 Td <0002 0020 0021> Tj ET
+q 100 0 0 100 0 0 cm BI /W 1 /H 1 ID ÿ) EI Q
+/Fm1 Do`)
+
+	got := pageText(content, res)
+	want := "Projeto de lei\nDispõe sobre a licitação\nPacde ção\nrodapé"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBackends(t *testing.T) {
+	if b, err := New(""); err != nil || b.Name() != DefaultBackend || b.Available() != nil {
+		t.Fatalf("default backend: %v %v", b, err)
+	}
+	if _, err := New("nope"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+
+	missing := NewPdftotext("/nonexistent/pdftotext")
+	if _, err := missing.Extract(context.Background(), "x.pdf"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+
+	if got := JoinPages([]Page{{1, "a"}, {2, "b"}}); got != "a\fb" {
+		t.Errorf("JoinPages = %q", got)
+	}
+}
+
+// fakeBackend devolve páginas fixas.
+type fakeBackend []Page
+
+func (this fakeBackend) Name() string     { return "fake" }
+func (this fakeBackend) Available() error { return nil }
+func (this fakeBackend) Extract(ctx context.Context, path string) ([]Page, error) {
+	return append([]Page(nil), this...), nil
+}
+
+func TestOCR(t *testing.T) {
+	long := "Dispõe sobre a obrigatoriedade de licitação pública para concessões"
+	if LowText(long, OCROptions{}) {
+		t.Error("regular text flagged as low-text")
+	}
+	if !LowText("", OCROptions{}) || !LowText("3 .. 4", OCROptions{}) || !LowText("@#$%&*!?+=[]{}~^ ab", OCROptions{MinLetters: 2}) {
+		t.Error("empty/garbage text not flagged")
+	}
+
+	base := fakeBackend{{1, long}, {2, ""}, {3, "�"}}
+	b := WithOCR(base, OCRFunc(func(ctx context.Context, img Image) (string, error) {
+		return "página digitalizada " + img.FileType, nil
+	}), OCROptions{}).(*ocrBackend)
+
+	var requested []int
+	b.images = func(ctx context.Context, path string, pages []int) (map[int][]Image, error) {
+		requested = pages
+		return map[int][]Image{2: {{Page: 2, FileType: "png"}}}, nil
+	}
+
+	pages, err := b.Extract(context.Background(), "x.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 2 || requested[0] != 2 || requested[1] != 3 {
+		t.Errorf("requested pages %v, want [2 3]", requested)
+	}
+	if pages[0].Text != long || pages[1].Text != "página digitalizada png" || pages[2].Text != "�" {
+		t.Errorf("unexpected pages %q", pages)
+	}
+	if b.Name() != "fake+func" {
+		t.Errorf("Name = %q", b.Name())
+	}
+
+	for filters, want := range map[string]bool{
+		"":                           false,
+		"DCTDecode":                  false,
+		"FlateDecode":                false,
+		"CCITTFaxDecode":             true,
+		"FlateDecode,JBIG2Decode":    true,
+		"CCITTFaxDecode,FlateDecode": false,
+	} {
+		if got := ocrSkipped(filters); got != want {
+			t.Errorf("ocrSkipped(%q) = %v, want %v", filters, got, want)
+		}
+	}
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDocumentFormats(t *testing.T) {
+	dir := t.TempDir()
+	docx := filepath.Join(dir, "doc.docx")
+	writeZip(t, docx, map[string]string{"word/document.xml": `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>PROJETO DE LEI</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">Dispõe sobre </w:t></w:r><w:r><w:t>licitação</w:t></w:r></w:p>
+<w:p><w:r><w:br w:type="page"/><w:t>Art. 1º</w:t><w:tab/><w:t>Esta lei</w:t></w:r></w:p>
+</w:body></w:document>`})
+
+	odt := filepath.Join(dir, "doc.odt")
+	writeZip(t, odt, map[string]string{"content.xml": `<?xml version="1.0"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:text><text:h>PROJETO DE LEI</text:h><text:p>Dispõe<text:s/>sobre <text:span>licitação</text:span></text:p>
+<text:soft-page-break/><text:p>Art. 1º<text:tab/>Esta lei</text:p></office:text></office:body></office:document-content>`})
+
+	html := filepath.Join(dir, "doc.html")
+	os.WriteFile(html, []byte(`<html><head><title>x</title><style>p{}</style></head><body>
+<h1>PROJETO   DE LEI</h1><script>var x = 1;</script><p>Dispõe sobre <b>licitação</b></p><p>Art. 1º<br>Esta lei</p></body></html>`), 0644)
+
+	for path, want := range map[string][]string{
+		docx: {"PROJETO DE LEI\nDispõe sobre licitação", "Art. 1º\tEsta lei"},
+		odt:  {"PROJETO DE LEI\nDispõe sobre licitação", "Art. 1º\tEsta lei"},
+		html: {"PROJETO DE LEI\nDispõe sobre licitação\nArt. 1º\nEsta lei"},
+	} {
+		b, err := ForExtension(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages, err := b.Extract(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range pages {
+			got = append(got, p.Text)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", b.Name(), got, want)
+		}
+	}
+
+	if _, err := ForExtension("doc.xls", nil); err == nil {
+		t.Error("expected error for unsupported extension")
+	}
+}
@@ -0,0 +1,278 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Image é uma imagem de uma página do PDF, no formato em que está gravada (jpg, png, tif...).
+type Image struct {
+	Page     int
+	FileType string
+	Data     []byte
+}
+
+// OCR reconhece o texto de uma imagem.
+type OCR interface {
+	Name() string
+	// Available retorna ErrUnavailable (embrulhado) se o OCR não puder ser usado.
+	Available() error
+	Recognize(ctx context.Context, img Image) (string, error)
+}
+
+// OCRFunc adapta uma função a OCR (útil como stub em testes).
+type OCRFunc func(ctx context.Context, img Image) (string, error)
+
+func (f OCRFunc) Name() string {
+	return "func"
+}
+
+func (f OCRFunc) Available() error {
+	return nil
+}
+
+func (f OCRFunc) Recognize(ctx context.Context, img Image) (string, error) {
+	return f(ctx, img)
+}
+
+// OCROptions define quando uma página tem pouco texto e precisa de OCR; valores zero usam
+// os padrões indicados.
+type OCROptions struct {
+	MinLetters     int     // Mínimo de letras por página (40)
+	MinLetterRatio float64 // Fração mínima de letras entre os caracteres visíveis (0.5)
+}
+
+func (this OCROptions) withDefaults() OCROptions {
+	if this.MinLetters <= 0 {
+		this.MinLetters = 40
+	}
+	if this.MinLetterRatio <= 0 {
+		this.MinLetterRatio = 0.5
+	}
+	return this
+}
+
+// LowText informa se text é curto demais ou composto principalmente de símbolos (texto de
+// fontes sem mapeamento Unicode), como acontece em páginas digitalizadas.
+func LowText(text string, opts OCROptions) bool {
+	opts = opts.withDefaults()
+	letters, visible := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		visible++
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters < opts.MinLetters || float64(letters) < opts.MinLetterRatio*float64(visible)
+}
+
+// ocrBackend complementa outro backend com OCR nas páginas com pouco texto.
+type ocrBackend struct {
+	base   Backend
+	ocr    OCR
+	opts   OCROptions
+	images func(ctx context.Context, path string, pages []int) (map[int][]Image, error)
+}
+
+// WithOCR retorna um backend que extrai o texto com base e, nas páginas em que ele fica
+// abaixo de opts (ver LowText), reconhece as imagens da página com ocr. O texto reconhecido
+// só substitui o extraído quando tem mais letras.
+func WithOCR(base Backend, ocr OCR, opts OCROptions) Backend {
+	return &ocrBackend{base: base, ocr: ocr, opts: opts, images: pageImages}
+}
+
+func (this *ocrBackend) Name() string {
+	return this.base.Name() + "+" + this.ocr.Name()
+}
+
+func (this *ocrBackend) Available() error {
+	return errors.Join(this.base.Available(), this.ocr.Available())
+}
+
+func (this *ocrBackend) Extract(ctx context.Context, path string) ([]Page, error) {
+	pages, err := this.base.Extract(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var low []int
+	for _, p := range pages {
+		if LowText(p.Text, this.opts) {
+			low = append(low, p.Number)
+		}
+	}
+	if len(low) == 0 {
+		return pages, nil
+	}
+
+	images, err := this.images(ctx, path, low)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract images of %s: %w", path, err)
+	}
+	for i, p := range pages {
+		if len(images[p.Number]) == 0 {
+			continue
+		}
+		var parts []string
+		for _, img := range images[p.Number] {
+			text, err := this.ocr.Recognize(ctx, img)
+			if err != nil {
+				return nil, fmt.Errorf("OCR of page %d of %s: %w", p.Number, path, err)
+			}
+			parts = append(parts, strings.TrimSpace(text))
+		}
+		if text := strings.Join(parts, "\n"); letterCount(text) > letterCount(p.Text) {
+			pages[i].Text = text
+			slog.Debug("página reconhecida por OCR", "file", path, "page", p.Number, "ocr", this.ocr.Name())
+		}
+	}
+	return pages, nil
+}
+
+func letterCount(s string) int {
+	ret := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			ret++
+		}
+	}
+	return ret
+}
+
+// pageImages extrai com o pdfcpu as imagens das páginas pedidas. Imagens CCITT e JBIG2 são
+// ignoradas (ver ocrSkipped).
+func pageImages(ctx context.Context, path string, pages []int) (map[int][]Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	conf.Cmd = model.EXTRACTIMAGES
+	pdf, err := api.ReadValidateAndOptimize(f, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int][]Image)
+	for _, page := range pages {
+		if page < 1 || page > pdf.PageCount {
+			continue
+		}
+		// Os stubs trazem os filtros de cada imagem, que a extração completa não informa
+		stubs, err := pdfcpu.ExtractPageImages(pdf, page, true)
+		if err != nil {
+			return nil, err
+		}
+		images, err := pdfcpu.ExtractPageImages(pdf, page, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, objNr := range slices.Sorted(maps.Keys(images)) {
+			img := images[objNr]
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+			if img.Thumb || img.Reader == nil {
+				continue
+			}
+			if ocrSkipped(stubs[objNr].Filter) {
+				slog.Debug("imagem ignorada no OCR", "file", path, "page", page, "filter", stubs[objNr].Filter)
+				continue
+			}
+			data, err := io.ReadAll(img)
+			if err != nil {
+				return nil, err
+			}
+			ret[page] = append(ret[page], Image{Page: page, FileType: img.FileType, Data: data})
+		}
+	}
+	return ret, nil
+}
+
+// ocrSkipped informa se uma imagem com a cadeia de filtros filters (ex.: "FlateDecode,
+// CCITTFaxDecode") fica fora do OCR: o pdfcpu entrega fluxos CCITT e JBIG2 sem decodificar,
+// em um formato que o tesseract não lê.
+func ocrSkipped(filters string) bool {
+	parts := strings.Split(filters, ",")
+	switch strings.TrimSpace(parts[len(parts)-1]) {
+	case filter.CCITTFax, filter.JBIG2:
+		return true
+	}
+	return false
+}
+
+// Tesseract reconhece o texto com o binário tesseract, que precisa estar instalado com o
+// idioma Lang.
+type Tesseract struct {
+	Bin  string
+	Lang string
+}
+
+// NewTesseract cria o OCR com o binário bin ("" = "tesseract" no PATH) e o idioma lang
+// ("" = "por").
+func NewTesseract(bin, lang string) *Tesseract {
+	if bin == "" {
+		bin = "tesseract"
+	}
+	if lang == "" {
+		lang = "por"
+	}
+	return &Tesseract{Bin: bin, Lang: lang}
+}
+
+func (this *Tesseract) Name() string {
+	return "tesseract"
+}
+
+func (this *Tesseract) Available() error {
+	if _, err := exec.LookPath(this.Bin); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnavailable, this.Bin, err)
+	}
+	return nil
+}
+
+// Recognize grava a imagem em um arquivo temporário e roda "tesseract img stdout -l Lang".
+func (this *Tesseract) Recognize(ctx context.Context, img Image) (string, error) {
+	tmp, err := os.CreateTemp("", "ocr-*."+img.FileType)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(img.Data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, this.Bin, tmp.Name(), "stdout", "-l", this.Lang)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v: %s", this.Bin, err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
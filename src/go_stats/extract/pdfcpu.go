@@ -0,0 +1,249 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Pdfcpu extrai o texto em Go puro: lê o PDF com o pdfcpu e interpreta os operadores de texto
+// do content stream de cada página, decodificando as strings pelo CMap ToUnicode ou pela
+// codificação de cada fonte. Não reconstrói colunas nem tabelas como o pdftotext -layout.
+type Pdfcpu struct{}
+
+func NewPdfcpu() *Pdfcpu {
+	return &Pdfcpu{}
+}
+
+func (this *Pdfcpu) Name() string {
+	return "pdfcpu"
+}
+
+func (this *Pdfcpu) Available() error {
+	return nil
+}
+
+func (this *Pdfcpu) Extract(ctx context.Context, path string) ([]Page, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	pdf, err := api.ReadContext(f, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err = pdf.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to count pages of %s: %w", path, err)
+	}
+
+	ret := make([]Page, 0, pdf.PageCount)
+	for n := 1; n <= pdf.PageCount; n++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		d, _, attrs, err := pdf.PageDict(n, true)
+		if err != nil {
+			return nil, fmt.Errorf("page %d of %s: %w", n, path, err)
+		}
+		content, err := pdf.PageContent(d, n)
+		if err != nil && err != model.ErrNoContent {
+			return nil, fmt.Errorf("page %d of %s: %w", n, path, err)
+		}
+
+		var res types.Dict
+		if attrs != nil {
+			res = attrs.Resources
+		}
+		ret = append(ret, Page{Number: n, Text: pageText(content, newPdfResources(pdf.XRefTable, res))})
+	}
+	return ret, nil
+}
+
+// pdfResources resolve fontes e XObjects de um dicionário /Resources, guardando as fontes já
+// decodificadas.
+type pdfResources struct {
+	xref  *model.XRefTable
+	dict  types.Dict
+	fonts map[string]*font
+}
+
+func newPdfResources(xref *model.XRefTable, dict types.Dict) *pdfResources {
+	return &pdfResources{xref: xref, dict: dict, fonts: make(map[string]*font)}
+}
+
+func (this *pdfResources) entry(kind, name string) types.Object {
+	if this.dict == nil {
+		return nil
+	}
+	sub, err := this.xref.DereferenceDict(this.dict[kind])
+	if err != nil || sub == nil {
+		return nil
+	}
+	return sub[name]
+}
+
+func (this *pdfResources) font(name string) *font {
+	if f, ok := this.fonts[name]; ok {
+		return f
+	}
+	var ret *font
+	if d, err := this.xref.DereferenceDict(this.entry("Font", name)); err == nil && d != nil {
+		ret = this.loadFont(d)
+	}
+	this.fonts[name] = ret
+	return ret
+}
+
+func (this *pdfResources) loadFont(d types.Dict) *font {
+	ret := &font{codeLen: 1}
+	if st := d.Subtype(); st != nil && *st == "Type0" {
+		ret.codeLen = 2
+		this.loadCIDWidths(d, ret)
+	} else {
+		this.loadWidths(d, ret)
+	}
+
+	if sd, _, err := this.xref.DereferenceStreamDict(d["ToUnicode"]); err == nil && sd != nil {
+		if err = sd.Decode(); err == nil {
+			cmap, codeLen := parseToUnicode(sd.Content)
+			ret.toUnicode = cmap
+			if codeLen > 0 {
+				ret.codeLen = codeLen
+			}
+		}
+	}
+
+	enc, _ := this.xref.Dereference(d["Encoding"])
+	switch v := enc.(type) {
+	case types.Name:
+		ret.encoding = namedEncoding(string(v))
+	case types.Dict:
+		base := &winAnsi
+		if n := v.NameEntry("BaseEncoding"); n != nil {
+			base = namedEncoding(*n)
+		}
+		ret.encoding = base
+		if diffs, err := this.xref.DereferenceArray(v["Differences"]); err == nil && diffs != nil {
+			ret.encoding = withDifferences(base, toOperands(diffs))
+		}
+	}
+	return ret
+}
+
+// loadWidths lê /FirstChar e /Widths de uma fonte simples.
+func (this *pdfResources) loadWidths(d types.Dict, f *font) {
+	first, err := this.xref.DereferenceNumber(d["FirstChar"])
+	if err != nil {
+		return
+	}
+	widths, err := this.xref.DereferenceArray(d["Widths"])
+	if err != nil || widths == nil {
+		return
+	}
+	f.widths = make(map[uint32]float64, len(widths))
+	for i, o := range widths {
+		if w, err := this.xref.DereferenceNumber(o); err == nil {
+			f.widths[uint32(int(first)+i)] = w
+		}
+	}
+}
+
+// loadCIDWidths lê /DW e /W da fonte descendente de uma fonte Type0. /W alterna entradas
+// "c [w1 w2 ...]" e "cInicial cFinal w".
+func (this *pdfResources) loadCIDWidths(d types.Dict, f *font) {
+	f.defWidth = 1000
+	desc, err := this.xref.DereferenceArray(d["DescendantFonts"])
+	if err != nil || len(desc) == 0 {
+		return
+	}
+	cid, err := this.xref.DereferenceDict(desc[0])
+	if err != nil || cid == nil {
+		return
+	}
+	if dw, err := this.xref.DereferenceNumber(cid["DW"]); err == nil {
+		f.defWidth = dw
+	}
+	w, err := this.xref.DereferenceArray(cid["W"])
+	if err != nil || w == nil {
+		return
+	}
+
+	f.widths = make(map[uint32]float64)
+	for i := 0; i+1 < len(w); {
+		start, err := this.xref.DereferenceNumber(w[i])
+		if err != nil {
+			return
+		}
+		if arr, err := this.xref.DereferenceArray(w[i+1]); err == nil && arr != nil {
+			for j, o := range arr {
+				if v, err := this.xref.DereferenceNumber(o); err == nil {
+					f.widths[uint32(int(start)+j)] = v
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		end, err1 := this.xref.DereferenceNumber(w[i+1])
+		v, err2 := this.xref.DereferenceNumber(w[i+2])
+		if err1 != nil || err2 != nil || end < start || end-start > 0xFFFF {
+			return
+		}
+		for c := int(start); c <= int(end); c++ {
+			f.widths[uint32(c)] = v
+		}
+		i += 3
+	}
+}
+
+func (this *pdfResources) form(name string) ([]byte, resources, bool) {
+	sd, _, err := this.xref.DereferenceStreamDict(this.entry("XObject", name))
+	if err != nil || sd == nil {
+		return nil, nil, false
+	}
+	if st := sd.Dict.Subtype(); st == nil || *st != "Form" {
+		return nil, nil, false
+	}
+	if err = sd.Decode(); err != nil {
+		return nil, nil, false
+	}
+
+	res := resources(this)
+	if d, err := this.xref.DereferenceDict(sd.Dict["Resources"]); err == nil && d != nil {
+		res = newPdfResources(this.xref, d)
+	}
+	return sd.Content, res, true
+}
+
+func namedEncoding(name string) *[256]rune {
+	if name == "MacRomanEncoding" {
+		return &macRoman
+	}
+	return &winAnsi
+}
+
+// toOperands converte um array do pdfcpu nos tipos usados pelo lexer.
+func toOperands(arr types.Array) []any {
+	ret := make([]any, 0, len(arr))
+	for _, o := range arr {
+		switch v := o.(type) {
+		case types.Integer:
+			ret = append(ret, float64(v))
+		case types.Float:
+			ret = append(ret, float64(v))
+		case types.Name:
+			ret = append(ret, pdfName(v))
+		}
+	}
+	return ret
+}
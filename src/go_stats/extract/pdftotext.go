@@ -0,0 +1,54 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Pdftotext extrai o texto com o binário pdftotext (poppler), que precisa estar instalado.
+type Pdftotext struct {
+	Bin string
+}
+
+// NewPdftotext cria o backend com o binário bin ("" = "pdftotext" no PATH).
+func NewPdftotext(bin string) *Pdftotext {
+	if bin == "" {
+		bin = "pdftotext"
+	}
+	return &Pdftotext{Bin: bin}
+}
+
+func (this *Pdftotext) Name() string {
+	return "pdftotext"
+}
+
+func (this *Pdftotext) Available() error {
+	if _, err := exec.LookPath(this.Bin); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnavailable, this.Bin, err)
+	}
+	return nil
+}
+
+// Extract roda "pdftotext path -" e separa as páginas pelos form feeds da saída.
+func (this *Pdftotext) Extract(ctx context.Context, path string) ([]Page, error) {
+	if err := this.Available(); err != nil {
+		return nil, err
+	}
+	out, err := exec.CommandContext(ctx, this.Bin, path, "-").Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return nil, fmt.Errorf("%s %s: %v: %s", this.Bin, path, err, strings.TrimSpace(string(exit.Stderr)))
+		}
+		return nil, fmt.Errorf("%s %s: %v", this.Bin, path, err)
+	}
+
+	// O pdftotext termina cada página com '\f'.
+	parts := strings.Split(strings.TrimSuffix(string(out), "\f"), "\f")
+	ret := make([]Page, len(parts))
+	for i, p := range parts {
+		ret[i] = Page{Number: i + 1, Text: p}
+	}
+	return ret, nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Citation registra uma citação legal canônica (ex.: "lei:8666/1993") encontrada em um
+// documento, permitindo busca exata por citação.
+type Citation struct {
+	ID    uint   `json:"id"    gorm:"column:id;primary_key;auto_increment;notnull"`
+	DocID uint16 `json:"docId" gorm:"column:doc_id;index;notnull"`
+	Kind  string `json:"kind"  gorm:"column:kind;type:varchar(10);notnull"`
+	Value string `json:"value" gorm:"column:value;type:varchar(40);index;notnull"`
+	Count uint16 `json:"count" gorm:"column:count;notnull"`
+}
+
+func NewCitation(docId uint16, kind, value string, count uint16) *Citation {
+	return &Citation{
+		DocID: docId,
+		Kind:  kind,
+		Value: value,
+		Count: count,
+	}
+}
+
+func (this *Citation) ToString() string {
+	return fmt.Sprintf("{ id: %d; docId: %d; value: %s; count: %d }", this.ID, this.DocID, this.Value, this.Count)
+}
+
+func (this *Citation) TableName() string {
+	return "CITATION"
+}
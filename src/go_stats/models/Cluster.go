@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cluster é um tópico do corpus encontrado por corpus.ClusterDocs. Os documentos do tópico
+// têm Document.Cluster igual ao ID; Terms são os termos de maior peso TF-IDF do tópico,
+// separados por TermsSep.
+type Cluster struct {
+	ID     uint16 `json:"id"     gorm:"column:id;primary_key;notnull"`
+	Method string `json:"method" gorm:"column:method;type:varchar(40);notnull"`
+	Label  string `json:"label"  gorm:"column:label;type:varchar(200);notnull"`
+	Terms  string `json:"terms"  gorm:"column:terms;type:text"`
+	Size   int    `json:"size"   gorm:"column:size;notnull"`
+}
+
+// TermsSep separa os termos em Cluster.Terms.
+const TermsSep = "; "
+
+func NewCluster(id uint16, method string, terms []string, size int) *Cluster {
+	label := terms
+	if len(label) > 3 {
+		label = label[:3]
+	}
+	return &Cluster{
+		ID:     id,
+		Method: method,
+		Label:  strings.Join(label, ", "),
+		Terms:  strings.Join(terms, TermsSep),
+		Size:   size,
+	}
+}
+
+func (this *Cluster) ToString() string {
+	return fmt.Sprintf("{ id: %d; method: %s; label: %s; size: %d }", this.ID, this.Method, this.Label, this.Size)
+}
+
+func (this *Cluster) TableName() string {
+	return "CLUSTER"
+}
+
+// TermList retorna os termos de Terms.
+func (this *Cluster) TermList() []string {
+	if this.Terms == "" {
+		return nil
+	}
+	return strings.Split(this.Terms, TermsSep)
+}
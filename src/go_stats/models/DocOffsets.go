@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TokenOffset liga o i-ésimo token do texto limpo ao trecho do texto original (bytes) que o
+// gerou e à página do PDF em que ele está (a partir de 1).
+type TokenOffset struct {
+	Start int
+	End   int
+	Page  int
+}
+
+// DocOffsets é o mapa de offsets de um documento, na ordem dos tokens do texto limpo.
+type DocOffsets struct {
+	DocId   uint16 `gorm:"column:docId;primary_key;notnull"`
+	Offsets []byte `gorm:"column:offsets;notnull"`
+
+	Document *Document `gorm:"foreignKey:DocId;references:ID"`
+}
+
+func NewDocOffsets(docID uint16, offsets []byte) *DocOffsets {
+	return &DocOffsets{
+		DocId:   docID,
+		Offsets: offsets,
+	}
+}
+
+// GetOffsets decodifica o mapa gravado.
+func (this *DocOffsets) GetOffsets() []TokenOffset {
+	return DecodeOffsets(this.Offsets)
+}
+
+func (this *DocOffsets) ToString() string {
+	return fmt.Sprintf("{ docId: %d; tokens: %d }", this.DocId, len(this.GetOffsets()))
+}
+
+func (this *DocOffsets) TableName() string {
+	return "DOC_OFFSETS"
+}
+
+// EncodeOffsets codifica cada offset como três varints: o deslocamento do início em relação
+// ao início anterior (com sinal, já que tokens da mesma palavra repetem o trecho), o tamanho
+// do trecho e o avanço de página.
+func EncodeOffsets(offsets []TokenOffset) []byte {
+	ret := make([]byte, 0, len(offsets)*3)
+	prevStart, prevPage := 0, 0
+	for _, o := range offsets {
+		ret = binary.AppendVarint(ret, int64(o.Start-prevStart))
+		ret = binary.AppendUvarint(ret, uint64(o.End-o.Start))
+		ret = binary.AppendVarint(ret, int64(o.Page-prevPage))
+		prevStart, prevPage = o.Start, o.Page
+	}
+	return ret
+}
+
+// DecodeOffsets é o inverso de EncodeOffsets.
+func DecodeOffsets(data []byte) []TokenOffset {
+	var ret []TokenOffset
+	prevStart, prevPage := 0, 0
+	for len(data) > 0 {
+		start, n1 := binary.Varint(data)
+		if n1 <= 0 {
+			break
+		}
+		size, n2 := binary.Uvarint(data[n1:])
+		if n2 <= 0 {
+			break
+		}
+		page, n3 := binary.Varint(data[n1+n2:])
+		if n3 <= 0 {
+			break
+		}
+		prevStart += int(start)
+		prevPage += int(page)
+		ret = append(ret, TokenOffset{Start: prevStart, End: prevStart + int(size), Page: prevPage})
+		data = data[n1+n2+n3:]
+	}
+	return ret
+}
@@ -3,24 +3,39 @@ package models
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 var docNameRgx = regexp.MustCompile(`[A-Z]{2,3} \d{2}/\d{4}`)
 
+// proposalRgx separa sigla, número e ano de um identificador como "PEC 45/2019".
+var proposalRgx = regexp.MustCompile(`([A-Z]{2,3}) (\d+)/(\d{4})`)
+
 const (
 	DocKindNone DocKind = "none"
 	DocKindText DocKind = "txt"
 	DocKindPDF  DocKind = "pdf"
+	DocKindDOCX DocKind = "docx"
+	DocKindODT  DocKind = "odt"
+	DocKindHTML DocKind = "html"
 )
 
 func ParseDocKind(s string) DocKind {
-	switch s {
+	switch strings.ToLower(s) {
 	case "txt":
 		return DocKindText
 	case "pdf":
 		return DocKindPDF
+	case "docx":
+		return DocKindDOCX
+	case "odt":
+		return DocKindODT
+	case "html", "htm":
+		return DocKindHTML
 	default:
 		return DocKindNone
 	}
@@ -35,6 +50,37 @@ type (
 		Size    uint16  `json:"size"    gorm:"column:size;notnull"`
 		Kind    DocKind `json:"kind"    gorm:"column:kind;type:varchar(5);notnull"`
 		Content []byte  `json:"content" gorm:"-"`
+		DupOf   uint16  `json:"dupOf"   gorm:"column:dup_of;index"`  // Documento canônico do grupo de quase-duplicatas (0 = nenhum)
+		Cluster uint16  `json:"cluster" gorm:"column:cluster;index"` // Tópico do documento (Cluster.ID; 0 = não agrupado)
+
+		// Metadados da proposição (vazios se o documento não tiver arquivo de metadados)
+		Source      string     `json:"source"      gorm:"column:source;type:varchar(10);index"` // camara, senado
+		ProposalID  string     `json:"proposalId"  gorm:"column:proposal_id;type:varchar(20)"`
+		Type        string     `json:"type"        gorm:"column:type;type:varchar(5);index"`
+		Number      int        `json:"number"      gorm:"column:number"`
+		Year        int        `json:"year"        gorm:"column:year;index"`
+		Authors     string     `json:"authors"     gorm:"column:authors;type:text"` // separados por AuthorsSep
+		Ementa      string     `json:"ementa"      gorm:"column:ementa;type:text"`
+		Themes      string     `json:"themes"      gorm:"column:themes;type:text"` // separados por AuthorsSep
+		SubmittedAt *time.Time `json:"submittedAt" gorm:"column:submitted_at"`
+		Status      string     `json:"status"      gorm:"column:status;type:varchar(120)"`
+		SourceURL   string     `json:"sourceUrl"   gorm:"column:source_url;type:text"`
+	}
+
+	// DocumentMeta são os metadados de uma proposição coletados no scraping, gravados ao
+	// lado do documento (cd_2196833.json) e carregados na indexação.
+	DocumentMeta struct {
+		Source      string     `json:"source"`
+		ProposalID  string     `json:"proposalId"`
+		Type        string     `json:"type"`
+		Number      int        `json:"number"`
+		Year        int        `json:"year"`
+		Authors     []string   `json:"authors"`
+		Ementa      string     `json:"ementa"`
+		Themes      []string   `json:"themes,omitempty"`
+		SubmittedAt *time.Time `json:"submittedAt,omitempty"`
+		Status      string     `json:"status"`
+		SourceURL   string     `json:"sourceUrl"`
 	}
 )
 
@@ -53,6 +99,75 @@ func (this *Document) ToString() string {
 	return fmt.Sprintf("{ id: %d; name: %s; size: %d }", this.ID, this.Name, this.Size)
 }
 
+// GroupId identifica o grupo de quase-duplicatas do documento: o documento canônico, ou o
+// próprio documento se ele não for duplicata de outro.
+func (this *Document) GroupId() uint16 {
+	if this.DupOf != 0 {
+		return this.DupOf
+	}
+	return this.ID
+}
+
+// AuthorsSep separa os autores em Document.Authors (e os temas em Document.Themes).
+const AuthorsSep = "; "
+
+// ApplyMeta copia os metadados da proposição para o documento.
+func (this *Document) ApplyMeta(meta *DocumentMeta) {
+	this.Source = meta.Source
+	this.ProposalID = meta.ProposalID
+	this.Type = strings.ToUpper(meta.Type)
+	this.Number = meta.Number
+	this.Year = meta.Year
+	this.Authors = strings.Join(meta.Authors, AuthorsSep)
+	this.Ementa = meta.Ementa
+	this.Themes = strings.Join(meta.Themes, AuthorsSep)
+	this.SubmittedAt = meta.SubmittedAt
+	this.Status = meta.Status
+	this.SourceURL = meta.SourceURL
+}
+
+// AuthorList retorna os autores da proposição.
+func (this *Document) AuthorList() []string {
+	if this.Authors == "" {
+		return nil
+	}
+	return strings.Split(this.Authors, AuthorsSep)
+}
+
+// ThemeList retorna os temas da proposição.
+func (this *Document) ThemeList() []string {
+	if this.Themes == "" {
+		return nil
+	}
+	return strings.Split(this.Themes, AuthorsSep)
+}
+
+// ProposalType retorna a sigla da proposição (PEC, PL, ...) dos metadados ou, na falta
+// deles, contida no nome ("PEC 45/2019"); "" se desconhecida. Os nomes do corpus limpo
+// (doc_0001_clean.txt) não trazem a sigla, então ali ela depende dos metadados.
+func (this *Document) ProposalType() string {
+	if this.Type != "" {
+		return this.Type
+	}
+	if m := proposalRgx.FindStringSubmatch(this.Name); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ProposalYear retorna o ano da proposição dos metadados ou contido no nome, ou 0 (ver
+// ProposalType).
+func (this *Document) ProposalYear() int {
+	if this.Year != 0 {
+		return this.Year
+	}
+	if m := proposalRgx.FindStringSubmatch(this.Name); m != nil {
+		year, _ := strconv.Atoi(m[3])
+		return year
+	}
+	return 0
+}
+
 func (this *Document) TableName() string {
 	return "DOCUMENT"
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// IndexMeta guarda a configuração com que um índice foi construído, para que documentos
+// e consultas sejam processados pelo mesmo pipeline de análise.
+type IndexMeta struct {
+	ID        uint16    `json:"id"        gorm:"column:id;primary_key;auto_increment;notnull"`
+	Analyzer  string    `json:"analyzer"  gorm:"column:analyzer;type:text;notnull"`
+	Stemmer   string    `json:"stemmer"   gorm:"column:stemmer;type:varchar(20);notnull"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;notnull"`
+}
+
+func NewIndexMeta(analyzer, stemmer string) *IndexMeta {
+	return &IndexMeta{
+		Analyzer:  analyzer,
+		Stemmer:   stemmer,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (this *IndexMeta) ToString() string {
+	return fmt.Sprintf("{ id: %d; analyzer: %s; stemmer: %s; createdAt: %s }", this.ID, this.Analyzer, this.Stemmer, this.CreatedAt)
+}
+
+func (this *IndexMeta) TableName() string {
+	return "INDEX_META"
+}
+
+func (this *IndexMeta) GetId() uint16 {
+	return this.ID
+}
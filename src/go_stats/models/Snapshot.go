@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Snapshot registra a versão do corpus com que um índice foi construído (ver
+// corpus.Manifest). Manifest guarda o manifesto completo em JSON.
+type Snapshot struct {
+	ID             string    `json:"id"             gorm:"column:id;primary_key;type:varchar(16)"`
+	CleanerVersion string    `json:"cleanerVersion" gorm:"column:cleaner_version;type:varchar(20);notnull"`
+	Files          int       `json:"files"          gorm:"column:files;notnull"`
+	Manifest       string    `json:"manifest"       gorm:"column:manifest;type:text;notnull"`
+	CreatedAt      time.Time `json:"createdAt"      gorm:"column:created_at;notnull"`
+}
+
+func NewSnapshot(id, cleanerVersion string, files int, manifest string, createdAt time.Time) *Snapshot {
+	return &Snapshot{
+		ID:             id,
+		CleanerVersion: cleanerVersion,
+		Files:          files,
+		Manifest:       manifest,
+		CreatedAt:      createdAt,
+	}
+}
+
+func (this *Snapshot) ToString() string {
+	return fmt.Sprintf("{ id: %s; cleanerVersion: %s; files: %d; createdAt: %s }", this.ID, this.CleanerVersion, this.Files, this.CreatedAt)
+}
+
+func (this *Snapshot) TableName() string {
+	return "SNAPSHOT"
+}
@@ -8,6 +8,8 @@ import (
 type TestConfigResult struct {
 	TotalDocs int
 	TotalTime int64
+	// Quantidade de frases que excederam o timeout por consulta e foram descartadas
+	TimedOut int
 	// Velocidade de cálculo dos vetores vase dos documentos em bytes por segundo
 	DocCalcBytesPerSecondAvgTime float64
 
@@ -23,6 +25,8 @@ type TestConfigResult struct {
 	MinTime10 int64
 	// Maior tempo em millis para calcular o vetor base de frases de 10 palavras
 	MaxTime10 int64
+	// Quantidade de frases de 10 palavras efetivamente processadas
+	Count10 int
 
 	AvgSpearmanSim20 float64
 	MinSpearmanSim20 float64
@@ -30,6 +34,7 @@ type TestConfigResult struct {
 	AvgTime20        int64
 	MinTime20        int64
 	MaxTime20        int64
+	Count20          int
 
 	AvgSpearmanSim40 float64
 	MinSpearmanSim40 float64
@@ -37,6 +42,7 @@ type TestConfigResult struct {
 	AvgTime40        int64
 	MinTime40        int64
 	MaxTime40        int64
+	Count40          int
 }
 
 func NewTestConfigResult(totalDocs int) TestConfigResult {
@@ -69,6 +75,7 @@ func NewTestConfigResult(totalDocs int) TestConfigResult {
 }
 
 func (this *TestConfigResult) Push10(spearman float64, elapsedMicro int64) {
+	this.Count10++
 	this.AvgSpearmanSim10 += spearman
 	if spearman < this.MinSpearmanSim10 {
 		this.MinSpearmanSim10 = spearman
@@ -87,6 +94,7 @@ func (this *TestConfigResult) Push10(spearman float64, elapsedMicro int64) {
 }
 
 func (this *TestConfigResult) Push20(spearman float64, elapsedMicro int64) {
+	this.Count20++
 	this.AvgSpearmanSim20 += spearman
 	if spearman < this.MinSpearmanSim20 {
 		this.MinSpearmanSim20 = spearman
@@ -105,6 +113,7 @@ func (this *TestConfigResult) Push20(spearman float64, elapsedMicro int64) {
 }
 
 func (this *TestConfigResult) Push40(spearman float64, elapsedMicro int64) {
+	this.Count40++
 	this.AvgSpearmanSim40 += spearman
 	if spearman < this.MinSpearmanSim40 {
 		this.MinSpearmanSim40 = spearman
@@ -122,34 +131,53 @@ func (this *TestConfigResult) Push40(spearman float64, elapsedMicro int64) {
 	}
 }
 
+// avgF divide a soma acumulada pela quantidade de frases processadas.
+func avgF(sum float64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// avgI divide a soma acumulada pela quantidade de frases processadas.
+func avgI(sum int64, n int) int64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / int64(n)
+}
+
 func (t *TestConfigResult) String() string {
 	return fmt.Sprintf(
 		"%d,%d,"+
 			"%.4f,%.4f,%.4f,%d,%d,%d,"+
 			"%.4f,%.4f,%.4f,%d,%d,%d,"+
-			"%.4f,%.4f,%.4f,%d,%d,%d",
+			"%.4f,%.4f,%.4f,%d,%d,%d,"+
+			"%d",
 		t.TotalDocs,
 		t.TotalTime,
 
-		t.AvgSpearmanSim10/50,
+		avgF(t.AvgSpearmanSim10, t.Count10),
 		t.MinSpearmanSim10,
 		t.MaxSpearmanSim10,
-		t.AvgTime10/50,
+		avgI(t.AvgTime10, t.Count10),
 		t.MinTime10,
 		t.MaxTime10,
 
-		t.AvgSpearmanSim20/50,
+		avgF(t.AvgSpearmanSim20, t.Count20),
 		t.MinSpearmanSim20,
 		t.MaxSpearmanSim20,
-		t.AvgTime20/50,
+		avgI(t.AvgTime20, t.Count20),
 		t.MinTime20,
 		t.MaxTime20,
 
-		t.AvgSpearmanSim40/50,
+		avgF(t.AvgSpearmanSim40, t.Count40),
 		t.MinSpearmanSim40,
 		t.MaxSpearmanSim40,
-		t.AvgTime40/50,
+		avgI(t.AvgTime40, t.Count40),
 		t.MinTime40,
 		t.MaxTime40,
+
+		t.TimedOut,
 	)
 }
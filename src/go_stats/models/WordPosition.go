@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// WordPosition é a lista de posições (índices no fluxo de tokens analisados) de uma palavra
+// em um documento. As posições são gravadas ordenadas, como deltas em varint.
+type WordPosition struct {
+	DocId     uint16 `gorm:"column:docId;uniqueIndex:wordposindex;notnull"`
+	WordId    uint16 `gorm:"column:wordId;uniqueIndex:wordposindex;notnull"`
+	Positions []byte `gorm:"column:positions;notnull"`
+
+	Document *Document `gorm:"foreignKey:DocId;references:ID"`
+	Word     *Word     `gorm:"foreignKey:WordId;references:ID"`
+}
+
+func NewWordPosition(docID, wordID uint16, positions []int) *WordPosition {
+	return &WordPosition{
+		DocId:     docID,
+		WordId:    wordID,
+		Positions: EncodePositions(positions),
+	}
+}
+
+// GetPositions decodifica as posições gravadas.
+func (this *WordPosition) GetPositions() []int {
+	return DecodePositions(this.Positions)
+}
+
+func (this *WordPosition) ToString() string {
+	return fmt.Sprintf("{ docId: %d; wordId: %d; positions: %v }", this.DocId, this.WordId, this.GetPositions())
+}
+
+func (this *WordPosition) TableName() string {
+	return "WORD_POS"
+}
+
+// EncodePositions codifica posições ordenadas como deltas em varint.
+func EncodePositions(positions []int) []byte {
+	ret := make([]byte, 0, len(positions)*2)
+	prev := 0
+	for _, p := range positions {
+		ret = binary.AppendUvarint(ret, uint64(p-prev))
+		prev = p
+	}
+	return ret
+}
+
+// DecodePositions é o inverso de EncodePositions.
+func DecodePositions(data []byte) []int {
+	var ret []int
+	prev := 0
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		prev += int(delta)
+		ret = append(ret, prev)
+		data = data[n:]
+	}
+	return ret
+}
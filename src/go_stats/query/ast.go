@@ -0,0 +1,146 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node é um nó da árvore sintática de uma consulta.
+type Node interface {
+	String() string
+}
+
+type (
+	// Term é uma palavra solta da consulta (ainda não analisada).
+	Term struct {
+		Value string
+	}
+
+	// Phrase exige os termos na ordem dada e adjacentes ("licitação pública").
+	Phrase struct {
+		Terms []string
+	}
+
+	// Near exige que Left e Right ocorram a no máximo Distance posições um do outro,
+	// em qualquer ordem (servidor NEAR/5 estabilidade).
+	Near struct {
+		Left     Node
+		Right    Node
+		Distance int
+	}
+
+	// Bool combina cláusulas justapostas: todas de Must são obrigatórias, as de MustNot
+	// excluem documentos e as de Should apenas contribuem para o ranking (ou, sem Must,
+	// definem os candidatos).
+	Bool struct {
+		Must    []Node
+		Should  []Node
+		MustNot []Node
+	}
+
+	// And exige todos os filhos (a AND b).
+	And struct {
+		Children []Node
+	}
+
+	// Or aceita qualquer um dos filhos (a OR b).
+	Or struct {
+		Children []Node
+	}
+
+	// Not exclui os documentos que satisfazem Child.
+	Not struct {
+		Child Node
+	}
+
+	// Field filtra pelos metadados do documento (kind:pdf, tipo:PEC, ano:2019, autor:silva).
+	Field struct {
+		Name  string
+		Value string
+	}
+)
+
+func (this *Term) String() string {
+	return this.Value
+}
+
+func (this *Phrase) String() string {
+	return fmt.Sprintf("%q", strings.Join(this.Terms, " "))
+}
+
+func (this *Near) String() string {
+	return fmt.Sprintf("(%s NEAR/%d %s)", this.Left, this.Distance, this.Right)
+}
+
+func (this *Bool) String() string {
+	var parts []string
+	for _, n := range this.Must {
+		parts = append(parts, "+"+n.String())
+	}
+	for _, n := range this.Should {
+		parts = append(parts, n.String())
+	}
+	for _, n := range this.MustNot {
+		parts = append(parts, "-"+n.String())
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (this *And) String() string {
+	return joinNodes(this.Children, " AND ")
+}
+
+func (this *Or) String() string {
+	return joinNodes(this.Children, " OR ")
+}
+
+func (this *Not) String() string {
+	return "NOT " + this.Child.String()
+}
+
+func (this *Field) String() string {
+	return this.Name + ":" + this.Value
+}
+
+func joinNodes(nodes []Node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// Text retorna o texto (não analisado) de todos os termos positivos da consulta, usado para
+// montar o vetor de ranking. Termos negados e filtros de campo são ignorados.
+func Text(n Node) string {
+	var parts []string
+	var walk func(Node)
+	walk = func(n Node) {
+		switch v := n.(type) {
+		case *Term:
+			parts = append(parts, v.Value)
+		case *Phrase:
+			parts = append(parts, strings.Join(v.Terms, " "))
+		case *Near:
+			walk(v.Left)
+			walk(v.Right)
+		case *Bool:
+			for _, c := range v.Must {
+				walk(c)
+			}
+			for _, c := range v.Should {
+				walk(c)
+			}
+		case *And:
+			for _, c := range v.Children {
+				walk(c)
+			}
+		case *Or:
+			for _, c := range v.Children {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(parts, " ")
+}
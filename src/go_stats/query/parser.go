@@ -0,0 +1,306 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrSyntax é retornado (embrulhado) para consultas mal formadas.
+var ErrSyntax = errors.New("query syntax error")
+
+// Fields são os nomes aceitos em filtros "nome:valor". Palavras com ':' e prefixo fora
+// desta lista (como as citações canônicas "lei:8666/1993") são tratadas como termos.
+var Fields = map[string]bool{}
+
+// RegisterField habilita name como filtro de campo.
+func RegisterField(name string) {
+	Fields[strings.ToLower(name)] = true
+}
+
+var fieldRgx = regexp.MustCompile(`^([A-Za-z]+):(.+)$`)
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokField
+	tokNear
+	tokAnd
+	tokOr
+	tokNot
+	tokPlus
+	tokMinus
+	tokLParen
+	tokRParen
+	tokEOF
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	field string // nome do campo em tokField
+	num   int    // distância de NEAR/k
+	pos   int
+}
+
+// lex divide a consulta em palavras, frases entre aspas, filtros de campo, parênteses,
+// prefixos +/- e operadores (AND, OR, NOT, NEAR/k; sempre em maiúsculas).
+func lex(input string) ([]token, error) {
+	var ret []token
+	runes := []rune(input)
+	isDelim := func(r rune) bool {
+		return unicode.IsSpace(r) || r == '"' || r == '(' || r == ')'
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(' || r == ')':
+			kind := tokLParen
+			if r == ')' {
+				kind = tokRParen
+			}
+			ret = append(ret, token{kind: kind, value: string(r), pos: i})
+			i++
+
+		case (r == '+' || r == '-') && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			kind := tokPlus
+			if r == '-' {
+				kind = tokMinus
+			}
+			ret = append(ret, token{kind: kind, value: string(r), pos: i})
+			i++
+
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated quote at %d", ErrSyntax, i)
+			}
+			ret = append(ret, token{kind: tokPhrase, value: string(runes[i+1 : end]), pos: i})
+			i = end + 1
+
+		default:
+			end := i
+			for end < len(runes) && !isDelim(runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			t := token{kind: tokWord, value: word, pos: i}
+
+			switch word {
+			case "AND":
+				t.kind = tokAnd
+			case "OR":
+				t.kind = tokOr
+			case "NOT":
+				t.kind = tokNot
+			default:
+				if rest, ok := strings.CutPrefix(word, "NEAR/"); ok {
+					k, err := strconv.Atoi(rest)
+					if err != nil || k <= 0 {
+						return nil, fmt.Errorf("%w: invalid proximity %q at %d", ErrSyntax, word, i)
+					}
+					t.kind, t.num = tokNear, k
+				} else if m := fieldRgx.FindStringSubmatch(word); m != nil && Fields[strings.ToLower(m[1])] {
+					t.kind, t.field, t.value = tokField, strings.ToLower(m[1]), m[2]
+				}
+			}
+			ret = append(ret, t)
+			i = end
+		}
+	}
+	return append(ret, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// Parse converte a consulta em uma árvore sintática. Sintaxe, da menor para a maior precedência:
+//   - a OR b: qualquer um dos lados;
+//   - a AND b: os dois lados;
+//   - cláusulas justapostas: palavras soltas só contam para o ranking; frases, NEAR, filtros,
+//     grupos e termos com '+' são obrigatórios; termos com '-' ou NOT são excluídos;
+//   - a NEAR/k b: a e b a no máximo k posições um do outro (encadeado agrupa à esquerda);
+//   - "frase entre aspas": termos adjacentes e na ordem;
+//   - campo:valor: filtro por metadado (ver Fields);
+//   - ( ... ): agrupamento.
+func Parse(input string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	if p.peek().kind == tokEOF {
+		return &Bool{}, nil
+	}
+	ret, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.value, t.pos)
+	}
+	return ret, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []Node{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &Or{Children: children}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	first, err := p.parseSeq()
+	if err != nil {
+		return nil, err
+	}
+	children := []Node{first}
+	for p.peek().kind == tokAnd {
+		p.next()
+		n, err := p.parseSeq()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &And{Children: children}, nil
+}
+
+// parseSeq lê cláusulas justapostas até um operador binário, ')' ou o fim da consulta.
+func (p *parser) parseSeq() (Node, error) {
+	ret := &Bool{}
+	for {
+		switch p.peek().kind {
+		case tokEOF, tokRParen, tokAnd, tokOr:
+			if len(ret.Must)+len(ret.Should)+len(ret.MustNot) == 0 {
+				t := p.peek()
+				return nil, fmt.Errorf("%w: expected a term at %d", ErrSyntax, t.pos)
+			}
+			return simplify(ret), nil
+		}
+
+		prefix := p.peek().kind
+		if prefix == tokPlus || prefix == tokMinus || prefix == tokNot {
+			p.next()
+		}
+		n, err := p.parseNear()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case prefix == tokMinus || prefix == tokNot:
+			ret.MustNot = append(ret.MustNot, n)
+		case prefix == tokPlus:
+			ret.Must = append(ret.Must, n)
+		default:
+			if _, ok := n.(*Term); ok {
+				ret.Should = append(ret.Should, n)
+			} else {
+				ret.Must = append(ret.Must, n)
+			}
+		}
+	}
+}
+
+// simplify devolve a própria cláusula quando o Bool tem apenas uma, positiva.
+func simplify(b *Bool) Node {
+	if len(b.MustNot) == 0 && len(b.Must)+len(b.Should) == 1 {
+		if len(b.Must) == 1 {
+			return b.Must[0]
+		}
+		return b.Should[0]
+	}
+	if len(b.MustNot) == 1 && len(b.Must)+len(b.Should) == 0 {
+		return &Not{Child: b.MustNot[0]}
+	}
+	return b
+}
+
+func (p *parser) parseNear() (Node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokNear {
+		op := p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = &Near{Left: left, Right: right, Distance: op.num}
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokWord:
+		return &Term{Value: t.value}, nil
+	case tokPhrase:
+		terms := strings.Fields(t.value)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("%w: empty phrase at %d", ErrSyntax, t.pos)
+		}
+		return &Phrase{Terms: terms}, nil
+	case tokField:
+		return &Field{Name: t.field, Value: t.value}, nil
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if end := p.next(); end.kind != tokRParen {
+			return nil, fmt.Errorf("%w: missing ')' for '(' at %d", ErrSyntax, t.pos)
+		}
+		return n, nil
+	case tokEOF:
+		return nil, fmt.Errorf("%w: unexpected end of query", ErrSyntax)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.value, t.pos)
+	}
+}
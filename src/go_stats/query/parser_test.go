@@ -0,0 +1,34 @@
+package query
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	n, err := Parse(`"licitação pública" servidor NEAR/5 estabilidade contrato`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.String(), `(+"licitação pública" +(servidor NEAR/5 estabilidade) contrato)`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := Text(n), "licitação pública servidor estabilidade contrato"; got != want {
+		t.Errorf("Text: got %q, want %q", got, want)
+	}
+
+	RegisterField("tipo")
+	n, err = Parse(`(licitação OR contrato) AND tipo:PEC -revogado NOT "medida provisória" +lei:8666/1993`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.String(), `((licitação OR contrato) AND (+tipo:PEC +lei:8666/1993 -revogado -"medida provisória"))`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	for _, bad := range []string{`"aberta`, `NEAR/2 a`, `a NEAR/x b`, `a NEAR/3`, `""`, `(a OR b`, `a AND`, `OR a`, `a)`} {
+		if _, err = Parse(bad); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q): expected ErrSyntax, got %v", bad, err)
+		}
+	}
+}
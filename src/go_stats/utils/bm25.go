@@ -1,8 +1,8 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"math"
 	"strings"
 	"sync"
@@ -18,6 +18,7 @@ import (
 // usando informações pré-indexadas do corpus.
 //
 // Parâmetros:
+//   - ctx: contexto de cancelamento; o cálculo é abortado se ele for cancelado.
 //   - trigramList: lista de trigrams pertencentes a um único documento.
 //   - totalDocs: número total de documentos no corpus.
 //   - totalGrams: quantidade total de trigrams no corpus (para cálculo do avgDL).
@@ -28,12 +29,15 @@ import (
 // Retorno:
 //   - map[string]*float64: pontuação BM25 de cada trigram do documento.
 func ComputeDocPreIndexedBM25(
+	ctx context.Context,
 	trigramList []interfaces.IGram,
 	totalDocs, totalGrams int,
 	cacheN map[string]map[uint16]interfaces.IGram,
 	normalizeJumps, parallel bool,
 ) (map[string]*float64, error) {
-
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(trigramList) == 0 {
 		return nil, fmt.Errorf("trigram list is empty")
 	}
@@ -65,6 +69,9 @@ func ComputeDocPreIndexedBM25(
 		var wg sync.WaitGroup
 
 		for key := range tf {
+			if ctx.Err() != nil {
+				break
+			}
 			wg.Add(1)
 			sem <- struct{}{}
 			go func(k string) {
@@ -104,13 +111,14 @@ func ComputeDocPreIndexedBM25(
 		bm25[res.key] = mgu.Ptr(score)
 	}
 
-	return bm25, nil
+	return completeVector(ctx, bm25)
 }
 
 // ComputeDocPosIndexedBM25 calcula os pesos BM25 dos n-grams (1, 2 ou 3) de um documento
 // específico, acessando diretamente os dados do banco via GORM.
 //
 // Parâmetros:
+//   - ctx: contexto de cancelamento, também repassado às consultas SQL.
 //   - docID: identificador do documento a ser processado.
 //   - gramSize: tamanho do n-gram (1 = unigram, 2 = bigram, 3 = trigram).
 //   - totalDocs: número total de documentos no corpus.
@@ -120,7 +128,11 @@ func ComputeDocPreIndexedBM25(
 //
 // Retorno:
 //   - map[string]*float64: pontuação BM25 calculada para cada n-gram do documento.
-func ComputeDocPosIndexedBM25(docID uint16, gramSize, totalDocs int, db *gorm.DB, normalizeJumps, parallel bool) (map[string]*float64, error) {
+func ComputeDocPosIndexedBM25(ctx context.Context, docID uint16, gramSize, totalDocs int, db *gorm.DB, normalizeJumps, parallel bool) (map[string]*float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db = db.WithContext(ctx)
 	if totalDocs <= 0 {
 		return nil, fmt.Errorf("totalDocs must be positive")
 	}
@@ -154,6 +166,9 @@ func ComputeDocPosIndexedBM25(docID uint16, gramSize, totalDocs int, db *gorm.DB
 		var wg sync.WaitGroup
 
 		for _, r := range tfResults {
+			if ctx.Err() != nil {
+				break
+			}
 			wg.Add(1)
 			sem <- struct{}{} // Adquire semaforo
 			go func(r interfaces.IGram) {
@@ -177,6 +192,9 @@ func ComputeDocPosIndexedBM25(docID uint16, gramSize, totalDocs int, db *gorm.DB
 	} else {
 		// Sequencial
 		for _, r := range tfResults {
+			if ctx.Err() != nil {
+				break
+			}
 			key := r.GetCacheKey(normalizeJumps, true)
 			var docCount int64
 			query := r.ApplyWordWheres(db)
@@ -192,7 +210,7 @@ func ComputeDocPosIndexedBM25(docID uint16, gramSize, totalDocs int, db *gorm.DB
 	var totalTrigramsAllDocs int64
 	err = db.Table("WORD_DOC").Select("SUM(count)").Scan(&totalTrigramsAllDocs).Error
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
 	}
 
 	k := 1.5
@@ -207,13 +225,14 @@ func ComputeDocPosIndexedBM25(docID uint16, gramSize, totalDocs int, db *gorm.DB
 		bm25[res.key] = mgu.Ptr(bm25Score)
 	}
 
-	return bm25, nil
+	return completeVector(ctx, bm25)
 }
 
 // ComputeStringBM25 calcula os pesos BM25 de uma frase simples (não associada a um documento real),
 // convertendo-a em n-grams e utilizando o cache global como base para obter DF.
 //
 // Parâmetros:
+// - ctx: contexto de cancelamento (ex.: timeout por consulta)
 // - str: texto de entrada
 // - gramsSize: tamanho do n-gram (1, 2 ou 3)
 // - jumpSize: tamanho máximo dos jumps entre termos
@@ -224,13 +243,16 @@ func ComputeDocPosIndexedBM25(docID uint16, gramSize, totalDocs int, db *gorm.DB
 // - normalizeJumps: indica se os jumps devem ser normalizados
 // - parallel: ativa execução concorrente
 func ComputeStringBM25(
+	ctx context.Context,
 	str string,
 	gramsSize, jumpSize, totalDocs, totalGrams int,
 	cacheN map[string]map[uint16]interfaces.IGram,
 	cacheWords map[string]models.Word,
 	normalizeJumps, parallel bool,
 ) (map[string]*float64, error) {
-
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if str == "" {
 		return nil, fmt.Errorf("input text is empty")
 	}
@@ -243,7 +265,10 @@ func ComputeStringBM25(
 		return nil, fmt.Errorf("no valid tokens found")
 	}
 
-	result, jumps := GetGramsLim(text, gramsSize, jumpSize)
+	result, jumps, err := GetGramsLim(text, gramsSize, jumpSize)
+	if err != nil {
+		return nil, err
+	}
 	if len(result) == 0 {
 		return nil, nil
 	}
@@ -279,7 +304,7 @@ func ComputeStringBM25(
 			ngram = models.NewInverseTrigram(0, 0, w0.ID, w1.ID, w2.ID, jumps[i][0], jumps[i][1])
 			break
 		default:
-			return nil, fmt.Errorf("invalid gramsSize: %d", gramsSize)
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedGramSize, gramsSize)
 		}
 		ngram.Increment()
 		grams = append(grams, ngram)
@@ -304,6 +329,9 @@ func ComputeStringBM25(
 		sem := make(chan struct{}, 25)
 		var wg sync.WaitGroup
 		for key := range tf {
+			if ctx.Err() != nil {
+				break
+			}
 			wg.Add(1)
 			sem <- struct{}{}
 			go func(k string) {
@@ -341,5 +369,5 @@ func ComputeStringBM25(
 		bm25[res.key] = mgu.Ptr(score)
 	}
 
-	return bm25, nil
+	return completeVector(ctx, bm25)
 }
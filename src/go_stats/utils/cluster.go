@@ -0,0 +1,364 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// ClusterVectors é um conjunto de vetores agrupável por KMeans e Agglomerative. Os vetores
+// são comparados pela similaridade de cosseno.
+type ClusterVectors interface {
+	// Len retorna a quantidade de vetores.
+	Len() int
+	// Sim retorna a similaridade de cosseno entre os vetores i e j.
+	Sim(i, j int) float64
+	// Centroids calcula o centroide de cada um dos k grupos de labels e retorna a função de
+	// similaridade de cosseno entre o vetor i e o centroide do grupo c.
+	Centroids(labels []int, k int) func(i, c int) float64
+}
+
+// SparseVectors são vetores esparsos (ex.: ComputeDocPreIndexedTFIDF), normalizados na criação.
+type SparseVectors []map[string]float64
+
+// NewSparseVectors converte vetores no formato de ComputeDocPreIndexedTFIDF em SparseVectors.
+func NewSparseVectors(vecs []map[string]*float64) SparseVectors {
+	ret := make(SparseVectors, len(vecs))
+	for i, v := range vecs {
+		m := make(map[string]float64, len(v))
+		for k, x := range v {
+			if x != nil && *x != 0 {
+				m[k] = *x
+			}
+		}
+		ret[i] = normalizeSparse(m)
+	}
+	return ret
+}
+
+func (vs SparseVectors) Len() int {
+	return len(vs)
+}
+
+func (vs SparseVectors) Sim(i, j int) float64 {
+	return dotSparse(vs[i], vs[j])
+}
+
+func (vs SparseVectors) Centroids(labels []int, k int) func(i, c int) float64 {
+	centroids := make([]map[string]float64, k)
+	for c := range centroids {
+		centroids[c] = make(map[string]float64)
+	}
+	for i, c := range labels {
+		for key, x := range vs[i] {
+			centroids[c][key] += x
+		}
+	}
+	for c := range centroids {
+		centroids[c] = normalizeSparse(centroids[c])
+	}
+	return func(i, c int) float64 {
+		return dotSparse(vs[i], centroids[c])
+	}
+}
+
+// DenseVectors são vetores densos (ex.: embeddings de BertClient), normalizados na criação.
+type DenseVectors [][]float64
+
+// NewDenseVectors converte embeddings em DenseVectors. Retorna ErrDimensionMismatch se as
+// dimensões forem diferentes.
+func NewDenseVectors(vecs [][]float32) (DenseVectors, error) {
+	ret := make(DenseVectors, len(vecs))
+	for i, v := range vecs {
+		if len(v) != len(vecs[0]) {
+			return nil, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(v), len(vecs[0]))
+		}
+		d := make([]float64, len(v))
+		for j, x := range v {
+			d[j] = float64(x)
+		}
+		ret[i] = normalizeDense(d)
+	}
+	return ret, nil
+}
+
+func (vs DenseVectors) Len() int {
+	return len(vs)
+}
+
+func (vs DenseVectors) Sim(i, j int) float64 {
+	return dotDense(vs[i], vs[j])
+}
+
+func (vs DenseVectors) Centroids(labels []int, k int) func(i, c int) float64 {
+	centroids := make([][]float64, k)
+	for c := range centroids {
+		if len(vs) > 0 {
+			centroids[c] = make([]float64, len(vs[0]))
+		}
+	}
+	for i, c := range labels {
+		for j, x := range vs[i] {
+			centroids[c][j] += x
+		}
+	}
+	for c := range centroids {
+		centroids[c] = normalizeDense(centroids[c])
+	}
+	return func(i, c int) float64 {
+		return dotDense(vs[i], centroids[c])
+	}
+}
+
+// KMeans agrupa vecs em k grupos por k-means esférico (similaridade de cosseno com os
+// centroides), com sementes escolhidas por k-means++ a partir de seed. Para após maxIter
+// iterações ou quando nenhum vetor muda de grupo. Grupos que ficam vazios recebem o vetor
+// mais distante do próprio centroide. Retorna o grupo (0 a k-1) de cada vetor.
+func KMeans(vecs ClusterVectors, k, maxIter int, seed uint64) ([]int, error) {
+	n := vecs.Len()
+	if k < 1 || k > n {
+		return nil, fmt.Errorf("invalid number of clusters %d for %d vectors", k, n)
+	}
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+
+	// k-means++: cada semente é sorteada com probabilidade proporcional à distância
+	// (1 - cosseno) até a semente mais próxima
+	seeds := []int{rng.IntN(n)}
+	dist := make([]float64, n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	for len(seeds) < k {
+		last := seeds[len(seeds)-1]
+		total := 0.0
+		for i := range dist {
+			dist[i] = min(dist[i], math.Max(0, 1-vecs.Sim(i, last)))
+			total += dist[i]
+		}
+		next := 0
+		if total > 0 {
+			r := rng.Float64() * total
+			for next = 0; next < n-1 && r >= dist[next]; next++ {
+				r -= dist[next]
+			}
+		} else {
+			next = rng.IntN(n) // vetores idênticos: qualquer um serve
+		}
+		seeds = append(seeds, next)
+	}
+
+	labels := make([]int, n)
+	sim := func(i, c int) float64 { return vecs.Sim(i, seeds[c]) }
+	for iter := 0; ; iter++ {
+		changed := false
+		best := make([]float64, n)
+		counts := make([]int, k)
+		for i := range labels {
+			label, s := 0, math.Inf(-1)
+			for c := 0; c < k; c++ {
+				if v := sim(i, c); v > s {
+					label, s = c, v
+				}
+			}
+			if iter == 0 || label != labels[i] {
+				changed = true
+			}
+			labels[i], best[i] = label, s
+			counts[label]++
+		}
+
+		for c := 0; c < k; c++ {
+			if counts[c] > 0 {
+				continue
+			}
+			worst := -1
+			for i := range labels {
+				if counts[labels[i]] > 1 && (worst < 0 || best[i] < best[worst]) {
+					worst = i
+				}
+			}
+			if worst >= 0 {
+				counts[labels[worst]]--
+				labels[worst], best[worst] = c, 1
+				counts[c]++
+				changed = true
+			}
+		}
+
+		if !changed || iter+1 >= maxIter {
+			return labels, nil
+		}
+		sim = vecs.Centroids(labels, k)
+	}
+}
+
+// Agglomerative agrupa vecs em k grupos por agrupamento hierárquico aglomerativo com ligação
+// média (UPGMA): começa com um grupo por vetor e une, a cada passo, os dois grupos com maior
+// similaridade média até restarem k. Usa uma matriz n x n de similaridades. Retorna o grupo
+// (0 a k-1, na ordem do primeiro vetor de cada grupo) de cada vetor.
+func Agglomerative(vecs ClusterVectors, k int) ([]int, error) {
+	n := vecs.Len()
+	if k < 1 || k > n {
+		return nil, fmt.Errorf("invalid number of clusters %d for %d vectors", k, n)
+	}
+
+	sim := make([][]float64, n)
+	for i := range sim {
+		sim[i] = make([]float64, n)
+		for j := 0; j < i; j++ {
+			sim[i][j] = vecs.Sim(i, j)
+			sim[j][i] = sim[i][j]
+		}
+	}
+	size := make([]int, n)
+	parent := make([]int, n)
+	active := make([]bool, n)
+	for i := range size {
+		size[i], parent[i], active[i] = 1, i, true
+	}
+
+	// nearest[i] é o grupo ativo mais similar a i
+	nearest := make([]int, n)
+	updateNearest := func(i int) {
+		nearest[i] = -1
+		for j := 0; j < n; j++ {
+			if j != i && active[j] && (nearest[i] < 0 || sim[i][j] > sim[i][nearest[i]]) {
+				nearest[i] = j
+			}
+		}
+	}
+	for i := range nearest {
+		updateNearest(i)
+	}
+
+	for groups := n; groups > k; groups-- {
+		a := -1
+		for i := 0; i < n; i++ {
+			if active[i] && (a < 0 || sim[i][nearest[i]] > sim[a][nearest[a]]) {
+				a = i
+			}
+		}
+		b := nearest[a]
+		if b < a {
+			a, b = b, a
+		}
+
+		// Lance-Williams para ligação média: a absorve b
+		for j := 0; j < n; j++ {
+			if active[j] && j != a && j != b {
+				sim[a][j] = (sim[a][j]*float64(size[a]) + sim[b][j]*float64(size[b])) / float64(size[a]+size[b])
+				sim[j][a] = sim[a][j]
+			}
+		}
+		size[a] += size[b]
+		active[b] = false
+		parent[b] = a
+
+		updateNearest(a)
+		for j := 0; j < n; j++ {
+			if !active[j] || j == a {
+				continue
+			}
+			if nearest[j] == a || nearest[j] == b {
+				updateNearest(j)
+			} else if sim[j][a] > sim[j][nearest[j]] {
+				nearest[j] = a
+			}
+		}
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	ids := make(map[int]int, k)
+	labels := make([]int, n)
+	for i := range labels {
+		root := find(i)
+		if _, ok := ids[root]; !ok {
+			ids[root] = len(ids)
+		}
+		labels[i] = ids[root]
+	}
+	return labels, nil
+}
+
+// TopTerms retorna as n chaves de maior peso somado entre os vetores esparsos de vecs, da
+// maior para a menor (empates em ordem alfabética).
+func TopTerms(vecs []map[string]*float64, n int) []string {
+	weight := make(map[string]float64)
+	for _, v := range vecs {
+		for k, x := range v {
+			if x != nil {
+				weight[k] += *x
+			}
+		}
+	}
+	ret := make([]string, 0, len(weight))
+	for k := range weight {
+		ret = append(ret, k)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if weight[ret[i]] == weight[ret[j]] {
+			return ret[i] < ret[j]
+		}
+		return weight[ret[i]] > weight[ret[j]]
+	})
+	if n > 0 && len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
+
+func normalizeSparse(v map[string]float64) map[string]float64 {
+	norm := 0.0
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	for k := range v {
+		v[k] /= norm
+	}
+	return v
+}
+
+func dotSparse(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	ret := 0.0
+	for k, x := range a {
+		ret += x * b[k]
+	}
+	return ret
+}
+
+func normalizeDense(v []float64) []float64 {
+	norm := 0.0
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+func dotDense(a, b []float64) float64 {
+	ret := 0.0
+	for i := range a {
+		ret += a[i] * b[i]
+	}
+	return ret
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClustering(t *testing.T) {
+	w := func(x float64) *float64 { return &x }
+	sparse := NewSparseVectors([]map[string]*float64{
+		{"penal": w(2), "crime": w(1)},
+		{"penal": w(1), "pena": w(1), "crime": w(1)},
+		{"imposto": w(2), "credito": w(1)},
+		{"imposto": w(1), "fiscal": w(1)},
+		{"crime": w(1), "pena": w(2)},
+	})
+	dense, err := NewDenseVectors([][]float32{{1, 0.1}, {0.9, 0.2}, {0, 1}, {0.1, 0.8}, {1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, vecs := range map[string]ClusterVectors{"sparse": sparse, "dense": dense} {
+		km, err := KMeans(vecs, 2, 20, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hc, err := Agglomerative(vecs, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for method, labels := range map[string][]int{"kmeans": km, "hierarchical": hc} {
+			if labels[0] != labels[1] || labels[0] != labels[4] || labels[2] != labels[3] || labels[0] == labels[2] {
+				t.Errorf("%s/%s: unexpected labels %v", name, method, labels)
+			}
+		}
+	}
+
+	if _, err = KMeans(dense, 6, 10, 1); err == nil {
+		t.Error("expected error for k > n")
+	}
+	if _, err = NewDenseVectors([][]float32{{1, 0}, {1}}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if got := TopTerms([]map[string]*float64{{"a": w(1), "b": w(2)}, {"a": w(2)}}, 1); len(got) != 1 || got[0] != "a" {
+		t.Errorf("TopTerms = %v", got)
+	}
+}
@@ -1,15 +1,17 @@
 package utils
 
 import (
-	"log"
+	"fmt"
 	"math"
 )
 
 type Float interface{ float64 | float32 }
 
-func CosineSimVecs[F Float](a, b []F) F {
+// CosineSimVecs retorna a similaridade de cosseno entre dois vetores densos.
+// Retorna ErrDimensionMismatch se os vetores tiverem tamanhos diferentes.
+func CosineSimVecs[F Float](a, b []F) (F, error) {
 	if len(a) != len(b) {
-		log.Fatal("Vectors must have the same length")
+		return 0, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(a), len(b))
 	}
 
 	var dot, magA, magB F
@@ -20,11 +22,11 @@ func CosineSimVecs[F Float](a, b []F) F {
 	}
 
 	if magA == 0 || magB == 0 {
-		return 0
+		return 0, nil
 	}
 
 	rDot, ra, rb := float64(dot), float64(magA), float64(magB)
-	return F(rDot / (math.Sqrt(ra) * math.Sqrt(rb)))
+	return F(rDot / (math.Sqrt(ra) * math.Sqrt(rb))), nil
 }
 
 func CosineSimMaps[F Float](a, b map[string]*F) F {
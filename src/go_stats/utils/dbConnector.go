@@ -2,7 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
 
@@ -11,67 +10,104 @@ import (
 	"gorm.io/gorm"
 )
 
-// InitDB inicializa o banco de dados e faz a migração automática dos modelos
-func InitDB(id int64, gramSize int, dbFile string, fromScratch bool) (string, *gorm.DB) {
+// InitDB inicializa o banco de dados e faz a migração automática dos modelos.
+// Falhas de banco são embrulhadas em ErrDatabase e tamanhos de n-grama fora
+// de [1, 3] retornam ErrUnsupportedGramSize. Se o erro ocorrer depois de criada a cópia do
+// banco, a conexão é fechada e o nome da cópia é retornado para que o chamador a remova.
+func InitDB(id int64, gramSize int, dbFile string, fromScratch bool) (string, *gorm.DB, error) {
+	var gramModel any
+	var index string
+
+	switch gramSize {
+	case 1:
+		gramModel = &models.InverseUnigram{}
+		index = "(wd0Id)"
+		break
+	case 2:
+		gramModel = &models.InverseBigram{}
+		index = "(wd0Id, wd1Id)"
+		break
+	case 3:
+		gramModel = &models.InverseTrigram{}
+		index = "(wd0Id, wd1Id, wd2Id)"
+		break
+	default:
+		return "", nil, fmt.Errorf("%w: %d", ErrUnsupportedGramSize, gramSize)
+	}
+
 	var targetFile string
 
 	if fromScratch {
 		if err := os.Remove(dbFile); err != nil && !os.IsNotExist(err) {
-			println("failed to delete database")
-			log.Fatal(err)
+			return "", nil, fmt.Errorf("%w: failed to delete database: %v", ErrDatabase, err)
 		}
 		targetFile = dbFile
 	} else {
 		newName := fmt.Sprintf("%s_%d.db", strings.TrimSuffix(dbFile, ".db"), id)
 		err := DuplicateFile(dbFile, newName)
 		if err != nil {
-			println("failed to duplicate database")
-			log.Fatal(err)
+			_ = os.Remove(newName)
+			return "", nil, fmt.Errorf("%w: failed to duplicate database: %v", ErrDatabase, err)
 		}
 		targetFile = newName
 	}
 
 	ret, err := gorm.Open(sqlite.Open(targetFile), &gorm.Config{})
 	if err != nil {
-		println("failed to connect database")
-		log.Fatal(err)
+		return targetFile, nil, fmt.Errorf("%w: failed to connect database: %v", ErrDatabase, err)
 	}
-
-	var gramModel any
-	var index string
-
-	switch gramSize {
-	case 1:
-		gramModel = &models.InverseUnigram{}
-		index = "(wd0Id)"
-		break
-	case 2:
-		gramModel = &models.InverseBigram{}
-		index = "(wd0Id, wd1Id)"
-		break
-	case 3:
-		gramModel = &models.InverseTrigram{}
-		index = "(wd0Id, wd1Id, wd2Id)"
-		break
+	fail := func(err error) (string, *gorm.DB, error) {
+		if sqlDB, e := ret.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+		return targetFile, nil, err
 	}
 
 	// AutoMigrate cria as tabelas para os modelos, se não existirem
-	err = ret.AutoMigrate(
-		&models.Document{},
-		&models.Word{},
-		&gramModel,
-	)
+	err = ret.AutoMigrate(append(docModels(), &gramModel)...)
 	if err != nil {
-		println("failed to migrate models")
-		log.Fatal(err)
+		return fail(fmt.Errorf("%w: failed to migrate models: %v", ErrDatabase, err))
 	}
 
 	query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_worddoc_wdids ON WORD_DOC %s;", index)
 	err = ret.Exec(query).Error
 	if err != nil {
-		println("failed to create index")
-		log.Fatal(err)
+		return fail(fmt.Errorf("%w: failed to create index: %v", ErrDatabase, err))
 	}
 
-	return targetFile, ret
+	return targetFile, ret, nil
+}
+
+// docModels são os modelos migrados em todo banco, independentes do tamanho de n-grama.
+func docModels() []any {
+	return []any{
+		&models.Document{},
+		&models.Word{},
+		&models.IndexMeta{},
+		&models.Citation{},
+		&models.WordPosition{},
+		&models.DocOffsets{},
+		&models.Snapshot{},
+		&models.Cluster{},
+	}
+}
+
+// OpenDB abre dbFile no próprio arquivo, sem a cópia de InitDB, para gravar resultados que
+// devem valer para as próximas execuções (ex.: quase-duplicatas e tópicos). O arquivo precisa
+// existir; as tabelas de docModels são migradas e as de n-gramas não são tocadas.
+func OpenDB(dbFile string) (*gorm.DB, error) {
+	if _, err := os.Stat(dbFile); err != nil {
+		return nil, fmt.Errorf("%w: failed to open database: %v", ErrDatabase, err)
+	}
+	ret, err := gorm.Open(sqlite.Open(dbFile), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to connect database: %v", ErrDatabase, err)
+	}
+	if err = ret.AutoMigrate(docModels()...); err != nil {
+		if sqlDB, e := ret.DB(); e == nil {
+			_ = sqlDB.Close()
+		}
+		return nil, fmt.Errorf("%w: failed to migrate models: %v", ErrDatabase, err)
+	}
+	return ret, nil
 }
@@ -0,0 +1,17 @@
+package utils
+
+import "errors"
+
+// Erros sentinela retornados pelos pacotes utils e corpus. Os chamadores devem
+// compará-los com errors.Is, pois normalmente chegam embrulhados com contexto
+// (ex.: fmt.Errorf("%w: %d", ErrUnsupportedGramSize, size)).
+var (
+	// ErrEmptyCorpus indica que não há documentos ou n-gramas para indexar.
+	ErrEmptyCorpus = errors.New("empty corpus")
+	// ErrUnsupportedGramSize indica um tamanho de n-grama fora do intervalo [1, 3].
+	ErrUnsupportedGramSize = errors.New("unsupported gram size")
+	// ErrDimensionMismatch indica vetores de tamanhos diferentes.
+	ErrDimensionMismatch = errors.New("vector dimension mismatch")
+	// ErrDatabase indica falha ao abrir, migrar ou consultar o banco de dados.
+	ErrDatabase = errors.New("database error")
+)
@@ -1,7 +1,8 @@
 package utils
 
 import (
-	"log"
+	"fmt"
+	"sort"
 )
 
 //func main() {
@@ -22,10 +23,11 @@ import (
 
 // GetGramsLim Generates n-grams explicitly/limitedly (max 3-grams, max 2 jumps).
 // [T any] defines the function as generic, accepting slices of any type T.
-func GetGramsLim[T any](vec []T, size, jump int) ([][]T, [][]int8) {
+// Returns ErrUnsupportedGramSize when size is outside [1, 3].
+func GetGramsLim[T any](vec []T, size, jump int) ([][]T, [][]int8, error) {
 	n := len(vec)
 	if size <= 0 || size > 3 {
-		log.Fatalln("Error: getGramsLim supports a maximum of 3-grams and minimum of 1.")
+		return nil, nil, fmt.Errorf("%w: getGramsLim supports a maximum of 3-grams and minimum of 1, got %d", ErrUnsupportedGramSize, size)
 	}
 
 	var ret [][]T
@@ -38,7 +40,7 @@ func GetGramsLim[T any](vec []T, size, jump int) ([][]T, [][]int8) {
 			// vec[i:i+1] creates a slice of a single element.
 			ret = append(ret, vec[i:i+1])
 		}
-		return ret, nil
+		return ret, nil, nil
 	}
 
 	// Case size=2 (Bigrams with Jumps)
@@ -51,7 +53,7 @@ func GetGramsLim[T any](vec []T, size, jump int) ([][]T, [][]int8) {
 				retJumps = append(retJumps, []int8{int8(j)})
 			}
 		}
-		return ret, retJumps
+		return ret, retJumps, nil
 	}
 
 	// Case size=3 (Trigrams with Jumps)
@@ -67,7 +69,7 @@ func GetGramsLim[T any](vec []T, size, jump int) ([][]T, [][]int8) {
 		}
 	}
 
-	return ret, retJumps
+	return ret, retJumps, nil
 }
 
 // GetGrams Generates all possible n-grams with jumps up to "jump".
@@ -116,3 +118,40 @@ func GetGrams[T any](vec []T, size, jump int) [][]T {
 
 	return ret
 }
+
+// MatchJumps finds occurrences of a sequence of terms given their sorted position lists,
+// using the same jump notion as GetGramsLim: jumps[i] is the distance between term i and
+// term i+1 (1 = adjacent). When exact is true the distance must be exactly jumps[i]
+// (phrase queries); otherwise any distance from 1 to jumps[i] is accepted (proximity).
+// Returns the positions of the first term where the whole sequence matches.
+func MatchJumps(lists [][]int, jumps []int, exact bool) []int {
+	if len(lists) == 0 || len(jumps) != len(lists)-1 {
+		return nil
+	}
+
+	var ret []int
+	for _, start := range lists[0] {
+		if matchFrom(lists, jumps, exact, 1, start) {
+			ret = append(ret, start)
+		}
+	}
+	return ret
+}
+
+func matchFrom(lists [][]int, jumps []int, exact bool, i, pos int) bool {
+	if i == len(lists) {
+		return true
+	}
+	lo, hi := pos+jumps[i-1], pos+jumps[i-1]
+	if !exact {
+		lo = pos + 1
+	}
+	// First position >= lo in the list of term i.
+	k := sort.SearchInts(lists[i], lo)
+	for ; k < len(lists[i]) && lists[i][k] <= hi; k++ {
+		if matchFrom(lists, jumps, exact, i+1, lists[i][k]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetGramsLim(t *testing.T) {
+	vec := []string{"a", "b", "c", "d"}
+
+	grams, jumps, err := GetGramsLim(vec, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (a,b)1 (a,c)2 (b,c)1 (b,d)2 (c,d)1
+	if len(grams) != 5 || len(jumps) != 5 {
+		t.Fatalf("expected 5 bigrams, got %d (%v)", len(grams), grams)
+	}
+	if grams[1][0] != "a" || grams[1][1] != "c" || jumps[1][0] != 2 {
+		t.Errorf("unexpected second bigram %v jump %v", grams[1], jumps[1])
+	}
+
+	for _, size := range []int{0, 4} {
+		if _, _, err = GetGramsLim(vec, size, 0); !errors.Is(err, ErrUnsupportedGramSize) {
+			t.Errorf("size %d: expected ErrUnsupportedGramSize, got %v", size, err)
+		}
+	}
+}
+
+func TestCosineSimVecsDimensionMismatch(t *testing.T) {
+	if _, err := CosineSimVecs([]float32{1, 0}, []float32{1}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	sim, err := CosineSimVecs([]float64{1, 0}, []float64{1, 0})
+	if err != nil || sim != 1 {
+		t.Fatalf("expected 1, got %v (%v)", sim, err)
+	}
+}
+
+func TestMatchJumps(t *testing.T) {
+	a, b, c := []int{0, 4, 10}, []int{1, 7, 11}, []int{2, 13}
+
+	if got := MatchJumps([][]int{a, b, c}, []int{1, 1}, true); len(got) != 1 || got[0] != 0 {
+		t.Errorf("phrase: got %v, want [0]", got)
+	}
+	if got := MatchJumps([][]int{a, b}, []int{3}, false); len(got) != 3 {
+		t.Errorf("proximity: got %v, want [0 4 10]", got)
+	}
+}
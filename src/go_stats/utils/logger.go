@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// NewLogger cria um logger estruturado.
+//   - w: destino das mensagens (ex.: os.Stderr)
+//   - format: "text" ou "json"
+//   - level: "debug", "info", "warn" ou "error"
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %v", level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (expected text or json)", format)
+	}
+}
+
+// SetupLogger cria o logger com NewLogger e o define como padrão do processo
+// (slog.Default), que é o logger usado pelos pacotes corpus e utils. Mensagens
+// emitidas pelo pacote log também passam a sair por ele.
+func SetupLogger(w io.Writer, format, level string) error {
+	logger, err := NewLogger(w, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// Fatal registra msg com nível de erro no logger padrão e encerra o processo com status 1.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
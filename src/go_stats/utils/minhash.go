@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+)
+
+// MinHasher calcula assinaturas MinHash: para cada uma de k funções de hash, o menor valor
+// entre os shingles de um conjunto. A fração de posições iguais entre duas assinaturas estima
+// a similaridade de Jaccard entre os conjuntos.
+type MinHasher struct {
+	a, b []uint64
+}
+
+// NewMinHasher cria um MinHasher com k funções de hash derivadas de seed. Assinaturas só são
+// comparáveis se geradas com o mesmo k e a mesma seed.
+func NewMinHasher(k int, seed uint64) *MinHasher {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	ret := &MinHasher{a: make([]uint64, k), b: make([]uint64, k)}
+	for i := 0; i < k; i++ {
+		ret.a[i] = rng.Uint64() | 1 // ímpar, para a multiplicação ser uma permutação
+		ret.b[i] = rng.Uint64()
+	}
+	return ret
+}
+
+// Signature retorna a assinatura do conjunto de shingles (já reduzidos a hashes de 64 bits).
+// Um conjunto vazio tem todas as posições em math.MaxUint64.
+func (m *MinHasher) Signature(shingles []uint64) []uint64 {
+	ret := make([]uint64, len(m.a))
+	for i := range ret {
+		ret[i] = math.MaxUint64
+	}
+	for _, x := range shingles {
+		for i := range ret {
+			v := m.a[i]*x + m.b[i]
+			v ^= v >> 31 // mistura os bits altos, que a multiplicação concentra
+			if v < ret[i] {
+				ret[i] = v
+			}
+		}
+	}
+	return ret
+}
+
+// MinHashSimilarity estima a similaridade de Jaccard a partir de duas assinaturas.
+// Retorna ErrDimensionMismatch se os tamanhos forem diferentes.
+func MinHashSimilarity(s1, s2 []uint64) (float64, error) {
+	if len(s1) != len(s2) {
+		return 0, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(s1), len(s2))
+	}
+	if len(s1) == 0 {
+		return 0, nil
+	}
+	equal := 0
+	for i := range s1 {
+		if s1[i] == s2[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s1)), nil
+}
+
+// LSHBands divide a assinatura em bands faixas e retorna uma chave por faixa (LSH). Dois
+// conjuntos com similaridade s compartilham alguma chave com probabilidade
+// 1 - (1 - s^r)^bands, com r = len(sig)/bands; posições que sobram no fim são ignoradas.
+func LSHBands(sig []uint64, bands int) []uint64 {
+	if bands <= 0 {
+		return nil
+	}
+	rows := len(sig) / bands
+	ret := make([]uint64, 0, bands)
+	for b := 0; b < bands && rows > 0; b++ {
+		h := fnv.New64a()
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(b))
+		h.Write(buf[:])
+		for _, v := range sig[b*rows : (b+1)*rows] {
+			binary.LittleEndian.PutUint64(buf[:], v)
+			h.Write(buf[:])
+		}
+		ret = append(ret, h.Sum64())
+	}
+	return ret
+}
+
+// HashShingles reduz cada shingle (ex.: um n-grama de GetGrams) a um hash FNV-1a de 64
+// bits, usando key para serializar os elementos.
+func HashShingles[T any](shingles [][]T, key func(T) uint64) []uint64 {
+	ret := make([]uint64, len(shingles))
+	var buf [8]byte
+	for i, s := range shingles {
+		h := fnv.New64a()
+		for _, t := range s {
+			binary.LittleEndian.PutUint64(buf[:], key(t))
+			h.Write(buf[:])
+		}
+		ret[i] = h.Sum64()
+	}
+	return ret
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMinHash(t *testing.T) {
+	words := make([]string, 200)
+	for i := range words {
+		words[i] = "w" + string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+	edited := append([]string(nil), words...)
+	edited[100] = "outra"
+	key := func(s string) uint64 {
+		var h uint64
+		for _, c := range s {
+			h = h*31 + uint64(c)
+		}
+		return h
+	}
+	shingles := func(tokens []string) []uint64 {
+		return HashShingles(GetGrams(tokens, 3, 0), key)
+	}
+
+	m := NewMinHasher(128, 1)
+	s1, s2, s3 := m.Signature(shingles(words)), m.Signature(shingles(edited)), m.Signature(shingles(words[:20]))
+	if sim, err := MinHashSimilarity(s1, s2); err != nil || sim < 0.85 {
+		t.Errorf("near-duplicates: similarity %v (%v)", sim, err)
+	}
+	if sim, _ := MinHashSimilarity(s1, s3); sim > 0.3 {
+		t.Errorf("different sets: similarity %v", sim)
+	}
+	if b1, b2 := LSHBands(s1, 16), LSHBands(s1, 16); len(b1) != 16 || b1[3] != b2[3] {
+		t.Errorf("unexpected bands %v", b1)
+	}
+	if _, err := MinHashSimilarity(s1, s1[:64]); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+}
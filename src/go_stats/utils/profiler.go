@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSampleInterval é o intervalo padrão entre duas amostras de memória.
+const DefaultSampleInterval = 30 * time.Millisecond
+
+// ProfileOptions configura uma execução de Profile.
+//   - Interval: intervalo entre amostras (DefaultSampleInterval se zero).
+//   - Dir: diretório onde os perfis pprof são gravados; vazio desativa o pprof.
+//   - Name: prefixo dos arquivos pprof (ex.: "test_12" gera test_12.cpu.pprof).
+//   - CPU/Heap: quais perfis pprof gravar quando Dir estiver definido.
+type ProfileOptions struct {
+	Interval time.Duration
+	Dir      string
+	Name     string
+	CPU      bool
+	Heap     bool
+}
+
+// MemoryProfile resume o consumo de memória de uma execução.
+// Todos os valores de memória estão em bytes.
+type MemoryProfile struct {
+	PeakHeapInuse uint64 // maior HeapInuse amostrado
+	AvgHeapInuse  uint64 // média do HeapInuse amostrado
+	TotalAlloc    uint64 // bytes alocados durante a execução (acumulado, inclui memória já liberada)
+	Mallocs       uint64 // número de alocações durante a execução
+	NumGC         uint32 // ciclos de GC concluídos durante a execução
+	PeakRSS       uint64 // maior RSS do processo observado (0 se indisponível na plataforma)
+	Samples       int    // quantidade de amostras coletadas
+}
+
+// ProfileCSVHeader são as colunas geradas por MemoryProfile.CSV, na mesma ordem.
+var ProfileCSVHeader = []string{"PeakHeapInuse", "AvgHeapInuse", "TotalAlloc", "Mallocs", "NumGC", "PeakRSS"}
+
+// CSV retorna os campos do perfil separados por vírgula, na ordem de ProfileCSVHeader.
+func (p MemoryProfile) CSV() string {
+	return strings.Join([]string{
+		fmt.Sprintf("%d", p.PeakHeapInuse),
+		fmt.Sprintf("%d", p.AvgHeapInuse),
+		fmt.Sprintf("%d", p.TotalAlloc),
+		fmt.Sprintf("%d", p.Mallocs),
+		fmt.Sprintf("%d", p.NumGC),
+		fmt.Sprintf("%d", p.PeakRSS),
+	}, ",")
+}
+
+// memSampler coleta amostras periódicas de runtime.MemStats em uma goroutine
+// própria. O estado é protegido por mutex e a goroutine termina em stop().
+type memSampler struct {
+	mu      sync.Mutex
+	peak    uint64
+	sum     uint64
+	count   int
+	peakRSS uint64
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func startMemSampler(interval time.Duration) *memSampler {
+	s := &memSampler{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	s.sample()
+
+	go func() {
+		defer close(s.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.sample()
+			}
+		}
+	}()
+	return s
+}
+
+func (s *memSampler) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	rss := currentRSS()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.HeapInuse > s.peak {
+		s.peak = m.HeapInuse
+	}
+	if rss > s.peakRSS {
+		s.peakRSS = rss
+	}
+	s.sum += m.HeapInuse
+	s.count++
+}
+
+// stop encerra a goroutine de amostragem, coleta uma última amostra e
+// retorna (pico, média, pico de RSS, quantidade de amostras).
+func (s *memSampler) stop() (uint64, uint64, uint64, int) {
+	close(s.done)
+	<-s.stopped
+	s.sample()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	avg := uint64(0)
+	if s.count > 0 {
+		avg = s.sum / uint64(s.count)
+	}
+	return s.peak, avg, s.peakRSS, s.count
+}
+
+// Profile executa fn medindo o uso de memória (heap em uso, total alocado,
+// ciclos de GC e pico de RSS). Se opts.Dir estiver definido, também grava
+// perfis pprof de CPU e/ou heap da execução.
+func Profile(opts ProfileOptions, fn func()) (ret MemoryProfile, err error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSampleInterval
+	}
+
+	var cpuFile *os.File
+	if opts.Dir != "" {
+		if err = os.MkdirAll(opts.Dir, 0755); err != nil {
+			return ret, err
+		}
+		if opts.CPU {
+			cpuFile, err = os.Create(filepath.Join(opts.Dir, opts.Name+".cpu.pprof"))
+			if err != nil {
+				return ret, err
+			}
+			if err = pprof.StartCPUProfile(cpuFile); err != nil {
+				return ret, errors.Join(err, cpuFile.Close())
+			}
+		}
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	sampler := startMemSampler(opts.Interval)
+
+	fn() // executa a função-alvo
+
+	ret.PeakHeapInuse, ret.AvgHeapInuse, ret.PeakRSS, ret.Samples = sampler.stop()
+	runtime.ReadMemStats(&after)
+	ret.TotalAlloc = after.TotalAlloc - before.TotalAlloc
+	ret.Mallocs = after.Mallocs - before.Mallocs
+	ret.NumGC = after.NumGC - before.NumGC
+
+	if cpuFile != nil {
+		pprof.StopCPUProfile()
+		err = cpuFile.Close()
+	}
+	if opts.Dir != "" && opts.Heap {
+		err = errors.Join(err, writeHeapProfile(filepath.Join(opts.Dir, opts.Name+".heap.pprof")))
+	}
+	return ret, err
+}
+
+func writeHeapProfile(path string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, f.Close()) }()
+	return pprof.WriteHeapProfile(f)
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sink [][]byte
+
+func TestMemSamplerStop(t *testing.T) {
+	s := startMemSampler(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	_, _, _, count := s.stop()
+
+	select {
+	case <-s.stopped:
+	default:
+		t.Fatal("sampler goroutine still running after stop")
+	}
+	if count < 2 {
+		t.Errorf("expected periodic samples, got %d", count)
+	}
+	// Sem a goroutine, nenhuma amostra nova é coletada
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Lock()
+	after := s.count
+	s.mu.Unlock()
+	if after != count {
+		t.Errorf("samples kept growing after stop: %d -> %d", count, after)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	const size = 4 << 20
+	p, err := Profile(ProfileOptions{Interval: time.Millisecond, Dir: dir, Name: "run", CPU: true, Heap: true}, func() {
+		for i := 0; i < 4; i++ {
+			sink = append(sink, make([]byte, size/4))
+			time.Sleep(2 * time.Millisecond)
+		}
+	})
+	sink = nil
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Samples < 1 || p.TotalAlloc < size || p.Mallocs == 0 || p.PeakHeapInuse == 0 || p.AvgHeapInuse > p.PeakHeapInuse {
+		t.Errorf("unexpected profile %+v", p)
+	}
+	for _, name := range []string{"run.cpu.pprof", "run.heap.pprof"} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("pprof file not written: %v", err)
+		}
+	}
+
+	fields := strings.Split(p.CSV(), ",")
+	if len(fields) != len(ProfileCSVHeader) {
+		t.Fatalf("CSV has %d fields, header has %d", len(fields), len(ProfileCSVHeader))
+	}
+	want := map[string]uint64{
+		"PeakHeapInuse": p.PeakHeapInuse,
+		"AvgHeapInuse":  p.AvgHeapInuse,
+		"TotalAlloc":    p.TotalAlloc,
+		"Mallocs":       p.Mallocs,
+		"NumGC":         uint64(p.NumGC),
+		"PeakRSS":       p.PeakRSS,
+	}
+	for i, col := range ProfileCSVHeader {
+		if v, err := strconv.ParseUint(fields[i], 10, 64); err != nil || v != want[col] {
+			t.Errorf("CSV column %s = %q, want %d", col, fields[i], want[col])
+		}
+	}
+}
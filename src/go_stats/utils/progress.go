@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultProgressInterval é o intervalo mínimo entre dois registros de progresso.
+const DefaultProgressInterval = 5 * time.Second
+
+// Progress acompanha uma etapa longa (documentos indexados, consultas processadas...)
+// e registra periodicamente no log a quantidade concluída, a taxa e o tempo restante
+// estimado (ETA). É seguro para uso concorrente.
+type Progress struct {
+	mu       sync.Mutex
+	stage    string
+	total    int
+	done     int
+	start    time.Time
+	last     time.Time
+	interval time.Duration
+}
+
+// NewProgress inicia o acompanhamento de uma etapa com total itens. Se total <= 0 o
+// total é desconhecido e o ETA não é calculado.
+func NewProgress(stage string, total int) *Progress {
+	now := time.Now()
+	return &Progress{
+		stage:    stage,
+		total:    total,
+		start:    now,
+		last:     now,
+		interval: DefaultProgressInterval,
+	}
+}
+
+// Add registra n itens concluídos e emite um log se o intervalo mínimo já passou.
+func (p *Progress) Add(n int) {
+	p.mu.Lock()
+	p.done += n
+	now := time.Now()
+	if now.Sub(p.last) < p.interval {
+		p.mu.Unlock()
+		return
+	}
+	p.last = now
+	attrs := p.attrs(now)
+	p.mu.Unlock()
+
+	slog.Info("progresso", attrs...)
+}
+
+// Finish registra o resumo final da etapa.
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	attrs := p.attrs(time.Now())
+	p.mu.Unlock()
+
+	slog.Info("etapa concluída", attrs...)
+}
+
+// attrs monta os atributos do log; deve ser chamado com p.mu travado.
+func (p *Progress) attrs(now time.Time) []any {
+	elapsed := now.Sub(p.start)
+	ret := []any{
+		slog.String("stage", p.stage),
+		slog.Int("done", p.done),
+		slog.Duration("elapsed", elapsed.Round(time.Millisecond)),
+	}
+	if secs := elapsed.Seconds(); secs > 0 {
+		ret = append(ret, slog.Float64("rate_per_s", float64(int(float64(p.done)/secs*100))/100))
+	}
+	if p.total > 0 {
+		ret = append(ret, slog.Int("total", p.total))
+		if p.done > 0 && p.done < p.total {
+			eta := time.Duration(float64(elapsed) / float64(p.done) * float64(p.total-p.done))
+			ret = append(ret, slog.Duration("eta", eta.Round(time.Second)))
+		}
+	}
+	return ret
+}
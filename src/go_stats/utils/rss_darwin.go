@@ -0,0 +1,15 @@
+//go:build darwin
+
+package utils
+
+import "syscall"
+
+// currentRSS retorna o pico de RSS do processo em bytes. No macOS não há uma
+// forma barata de ler o RSS instantâneo, então usamos o ru_maxrss (em bytes).
+func currentRSS() uint64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return uint64(usage.Maxrss)
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package utils
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// currentRSS retorna o RSS atual do processo em bytes, lido de /proc/self/statm.
+func currentRSS() uint64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * uint64(os.Getpagesize())
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package utils
+
+// currentRSS não é suportado nesta plataforma.
+func currentRSS() uint64 {
+	return 0
+}
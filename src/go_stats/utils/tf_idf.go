@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strings"
@@ -15,18 +16,22 @@ import (
 
 // ComputeDocPreIndexedTFIDF calcula o TF-IDF de um documento previamente indexado
 // usando um cache de n-gramas no formato map[string]map[uint16]interfaces.IGram.
+// - ctx: contexto de cancelamento; o cálculo é abortado se ele for cancelado
 // - trigramList: lista dos n-gramas do documento alvo
 // - totalDocs: número total de documentos do corpus
 // - cacheN: cache global de n-gramas agrupado por chave e docID
 // - normalizeJumps: define se jumps são normalizados
 // - parallel: ativa processamento concorrente
 func ComputeDocPreIndexedTFIDF(
+	ctx context.Context,
 	trigramList []interfaces.IGram,
 	totalDocs int,
 	cacheN map[string]map[uint16]interfaces.IGram,
 	normalizeJumps, parallel bool,
 ) (map[string]*float64, error) {
-
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(trigramList) == 0 {
 		return nil, fmt.Errorf("trigram list is empty")
 	}
@@ -60,6 +65,9 @@ func ComputeDocPreIndexedTFIDF(
 		var wg sync.WaitGroup
 
 		for key := range tf {
+			if ctx.Err() != nil {
+				break
+			}
 			wg.Add(1)
 			sem <- struct{}{}
 			go func(key string) {
@@ -89,7 +97,7 @@ func ComputeDocPreIndexedTFIDF(
 		tfidf[res.key] = mgu.Ptr(tfVal * idfVal)
 	}
 
-	return tfidf, nil
+	return completeVector(ctx, tfidf)
 }
 
 // ComputeDocPosIndexedTFIDF calcula o TF-IDF de um documento diretamente a partir do banco de dados,
@@ -97,6 +105,7 @@ func ComputeDocPreIndexedTFIDF(
 // de documentos (DF) usando consultas SQL, podendo operar de forma concorrente.
 //
 // Parâmetros:
+// - ctx: contexto de cancelamento, também repassado às consultas SQL
 // - docID: identificador do documento alvo
 // - gramSize: tamanho do n-grama (1, 2 ou 3)
 // - totalDocs: número total de documentos do corpus
@@ -106,7 +115,11 @@ func ComputeDocPreIndexedTFIDF(
 //
 // Retorna:
 // - map[string]*float64: mapa de chaves de n-grama para seus valores TF-IDF
-func ComputeDocPosIndexedTFIDF(docID uint16, gramSize, totalDocs int, db *gorm.DB, normalizeJumps, parallel bool) (map[string]*float64, error) {
+func ComputeDocPosIndexedTFIDF(ctx context.Context, docID uint16, gramSize, totalDocs int, db *gorm.DB, normalizeJumps, parallel bool) (map[string]*float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db = db.WithContext(ctx)
 	if totalDocs <= 0 {
 		return nil, fmt.Errorf("totalDocs must be positive")
 	}
@@ -140,6 +153,9 @@ func ComputeDocPosIndexedTFIDF(docID uint16, gramSize, totalDocs int, db *gorm.D
 		var wg sync.WaitGroup
 
 		for _, r := range tfResults {
+			if ctx.Err() != nil {
+				break
+			}
 			wg.Add(1)
 			sem <- struct{}{} // Adquire semaforo
 			go func(r interfaces.IGram) {
@@ -163,6 +179,9 @@ func ComputeDocPosIndexedTFIDF(docID uint16, gramSize, totalDocs int, db *gorm.D
 	} else {
 		// Sequencial
 		for _, r := range tfResults {
+			if ctx.Err() != nil {
+				break
+			}
 			key := r.GetCacheKey(normalizeJumps, true)
 			var docCount int64
 			query := r.ApplyWordWheres(db)
@@ -182,7 +201,7 @@ func ComputeDocPosIndexedTFIDF(docID uint16, gramSize, totalDocs int, db *gorm.D
 		tfidf[res.key] = mgu.Ptr(tfVal * idfVal)
 	}
 
-	return tfidf, nil
+	return completeVector(ctx, tfidf)
 }
 
 // ComputeStringTFIDF calcula o TF-IDF de uma frase simples, convertendo-a internamente
@@ -190,6 +209,7 @@ func ComputeDocPosIndexedTFIDF(docID uint16, gramSize, totalDocs int, db *gorm.D
 // a nenhum documento real.
 //
 // Parâmetros:
+// - ctx: contexto de cancelamento (ex.: timeout por consulta)
 // - str: texto alvo
 // - gramsSize: tamanho do n-gram (1, 2 ou 3)
 // - jumpSize: distância máxima entre termos (para gerar jumps)
@@ -203,13 +223,16 @@ func ComputeDocPosIndexedTFIDF(docID uint16, gramSize, totalDocs int, db *gorm.D
 // - map[string]*float64: valores TF-IDF por chave de n-gram
 // - error: erro em caso de falha
 func ComputeStringTFIDF(
+	ctx context.Context,
 	str string,
 	gramsSize, jumpSize, totalDocs int,
 	cacheN map[string]map[uint16]interfaces.IGram,
 	CacheWords map[string]models.Word,
 	smoothJumps, parallel bool,
 ) (map[string]*float64, error) {
-
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if str == "" {
 		return nil, fmt.Errorf("input text is empty")
 	}
@@ -222,7 +245,10 @@ func ComputeStringTFIDF(
 		return nil, fmt.Errorf("no valid tokens found")
 	}
 
-	result, jumps := GetGramsLim(text, gramsSize, jumpSize)
+	result, jumps, err := GetGramsLim(text, gramsSize, jumpSize)
+	if err != nil {
+		return nil, err
+	}
 	if len(result) == 0 {
 		return nil, nil
 	}
@@ -244,7 +270,7 @@ func ComputeStringTFIDF(
 				CacheWords[word[2]].ID, jumps[i][0], jumps[i][1])
 			break
 		default:
-			return nil, fmt.Errorf("invalid gramsSize: %d", gramsSize)
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedGramSize, gramsSize)
 		}
 		ngram.Increment()
 		grams = append(grams, ngram)
@@ -270,6 +296,9 @@ func ComputeStringTFIDF(
 		var wg sync.WaitGroup
 
 		for key := range tf {
+			if ctx.Err() != nil {
+				break
+			}
 			wg.Add(1)
 			sem <- struct{}{}
 			go func(key string) {
@@ -299,5 +328,14 @@ func ComputeStringTFIDF(
 		tfidf[res.key] = mgu.Ptr(tfVal * idfVal)
 	}
 
-	return tfidf, nil
+	return completeVector(ctx, tfidf)
+}
+
+// completeVector devolve vec, ou o erro de ctx se ele tiver sido cancelado: o cancelamento
+// durante o cálculo do DF interrompe o envio dos resultados e deixa o vetor incompleto.
+func completeVector(ctx context.Context, vec map[string]*float64) (map[string]*float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return vec, nil
 }